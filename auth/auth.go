@@ -0,0 +1,114 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth 使用 HMAC-SHA256 签名和校验私有的认证选项
+//
+// 签名覆盖 MessageID (2字节大端) || Token || Path || Payload, 以十六进制字符串
+// 存储在 GiterLabKey (或 AccessKey) 选项中, ID 存储在 GiterLabID (或 AccessID) 选项中.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+var (
+	ErrInvalidGiterLabKey = errors.New("auth: invalid GiterLabKey")
+	ErrInvalidAccessKey   = errors.New("auth: invalid AccessKey")
+)
+
+// Sign 设置 GiterLabID 为 id, 并将签名写入 GiterLabKey, 其他选项保持不变
+func Sign(msg *message.Message, id string, secretKey []byte) error {
+	return sign(msg, secoapcore.GiterLabID, secoapcore.GiterLabKey, id, secretKey)
+}
+
+// Verify 校验 GiterLabKey 中的签名, 不一致时返回 ErrInvalidGiterLabKey
+func Verify(msg *message.Message, secretKey []byte) error {
+	return verify(msg, secoapcore.GiterLabKey, secretKey, ErrInvalidGiterLabKey)
+}
+
+// AccessSign 同 Sign, 使用 AccessID 和 AccessKey 选项
+func AccessSign(msg *message.Message, id string, secretKey []byte) error {
+	return sign(msg, secoapcore.AccessID, secoapcore.AccessKey, id, secretKey)
+}
+
+// AccessVerify 同 Verify, 校验 AccessKey 中的签名, 不一致时返回 ErrInvalidAccessKey
+func AccessVerify(msg *message.Message, secretKey []byte) error {
+	return verify(msg, secoapcore.AccessKey, secretKey, ErrInvalidAccessKey)
+}
+
+func sign(msg *message.Message, idOpt, keyOpt secoapcore.OptionID, id string, secretKey []byte) error {
+	if msg == nil {
+		return secoapcore.ErrMessageNil
+	}
+	if !secoapcore.VerifyOptLen(secoapcore.CoapOptionDefs, idOpt, len(id)) {
+		return fmt.Errorf("%w: %v", secoapcore.ErrInvalidValueLength, idOpt)
+	}
+	digest, err := digest(msg, secretKey)
+	if err != nil {
+		return err
+	}
+	msg.SetOptstring(idOpt, id)
+	msg.SetOptstring(keyOpt, hex.EncodeToString(digest))
+	return nil
+}
+
+func verify(msg *message.Message, keyOpt secoapcore.OptionID, secretKey []byte, errInvalid error) error {
+	if msg == nil {
+		return secoapcore.ErrMessageNil
+	}
+	value, err := msg.GetOptionBytes(keyOpt)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalid, err)
+	}
+	got, err := hex.DecodeString(string(value))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalid, err)
+	}
+	want, err := digest(msg, secretKey)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(got, want) {
+		return errInvalid
+	}
+	return nil
+}
+
+// digest 计算 HMAC-SHA256(MessageID || Token || Path || Payload)
+func digest(msg *message.Message, secretKey []byte) ([]byte, error) {
+	path, err := msg.Path()
+	if err != nil && !errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return nil, err
+	}
+	payload, err := msg.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	var mid [2]byte
+	binary.BigEndian.PutUint16(mid[:], uint16(msg.MessageID()))
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write(mid[:])
+	mac.Write(msg.Token())
+	mac.Write([]byte(path))
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+var secretKey = []byte("device-secret")
+
+func newTestMessage(t *testing.T) *message.Message {
+	msg := message.NewMessage(context.Background())
+	msg.SetCode(secoapcore.POST)
+	msg.SetMessageID(0x1234)
+	msg.SetToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, msg.SetPath("/iotda/v3/device/status"))
+	msg.SetContentFormat(secoapcore.AppJSON)
+	require.NoError(t, msg.SetPayloadBytes([]byte(`{"online":true}`)))
+	return msg
+}
+
+func TestSignVerify(t *testing.T) {
+	msg := newTestMessage(t)
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	id, err := msg.GetOptionBytes(secoapcore.GiterLabID)
+	require.NoError(t, err)
+	require.Equal(t, "device-1", string(id))
+	key, err := msg.GetOptionBytes(secoapcore.GiterLabKey)
+	require.NoError(t, err)
+	require.Len(t, key, 64)
+	require.NoError(t, Verify(msg, secretKey))
+	require.ErrorIs(t, Verify(msg, []byte("other")), ErrInvalidGiterLabKey)
+
+	// other options are left intact
+	path, err := msg.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/iotda/v3/device/status", path)
+	cf, err := msg.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSON, cf)
+	require.False(t, msg.HasOption(secoapcore.AccessKey))
+
+	// signing twice replaces the options
+	require.NoError(t, Sign(msg, "device-2", secretKey))
+	n, err := msg.GetOptionAllBytes(secoapcore.GiterLabKey, make([][]byte, 4))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, Verify(msg, secretKey))
+}
+
+func TestVerifyTampered(t *testing.T) {
+	msg := newTestMessage(t)
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	require.NoError(t, msg.SetPayloadBytes([]byte(`{"online":false}`)))
+	require.ErrorIs(t, Verify(msg, secretKey), ErrInvalidGiterLabKey)
+
+	msg = newTestMessage(t)
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	msg.SetMessageID(0x1235)
+	require.ErrorIs(t, Verify(msg, secretKey), ErrInvalidGiterLabKey)
+
+	msg = newTestMessage(t)
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	msg.SetOptstring(secoapcore.GiterLabKey, "not hex")
+	require.ErrorIs(t, Verify(msg, secretKey), ErrInvalidGiterLabKey)
+}
+
+func TestVerifyMissingOption(t *testing.T) {
+	msg := newTestMessage(t)
+	err := Verify(msg, secretKey)
+	require.ErrorIs(t, err, ErrInvalidGiterLabKey)
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	err = AccessVerify(msg, secretKey)
+	require.ErrorIs(t, err, ErrInvalidAccessKey)
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+	require.ErrorIs(t, Verify(nil, secretKey), secoapcore.ErrMessageNil)
+}
+
+func TestAccessSignVerify(t *testing.T) {
+	msg := newTestMessage(t)
+	require.NoError(t, Sign(msg, "device-1", secretKey))
+	require.NoError(t, AccessSign(msg, "access-1", []byte("access-secret")))
+	require.NoError(t, AccessVerify(msg, []byte("access-secret")))
+	require.NoError(t, Verify(msg, secretKey))
+	require.ErrorIs(t, AccessVerify(msg, secretKey), ErrInvalidAccessKey)
+	id, err := msg.GetOptionBytes(secoapcore.AccessID)
+	require.NoError(t, err)
+	require.Equal(t, "access-1", string(id))
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blockwise 实现块传输 (RFC 7959) 的客户端状态机
+package blockwise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// DefaultMaxBodySize Get 重组负载的默认最大长度
+const DefaultMaxBodySize = 1 << 20
+
+var (
+	ErrUnexpectedResponse = errors.New("blockwise: unexpected response")
+	ErrBodyTooLarge       = errors.New("blockwise: body too large")
+)
+
+// SendReceiver 发送请求并等待对应的响应, 由调用方实现传输层
+type SendReceiver interface {
+	SendReceive(req *message.Message) (*message.Message, error)
+}
+
+// Client 块传输客户端, 服务端在传输过程中减小块大小时按新的块大小继续传输
+type Client struct {
+	Conn        SendReceiver
+	MaxBodySize int // <= 0 时使用 DefaultMaxBodySize
+}
+
+func NewClient(conn SendReceiver) *Client {
+	return &Client{Conn: conn}
+}
+
+// BlockSize 返回块大小指数 szx 对应的块大小
+func BlockSize(szx uint8) int {
+	return 1 << (szx + 4)
+}
+
+// Get 使用 Block2 获取资源, szx 为期望的块大小指数, 返回重组后的负载
+func (c *Client) Get(ctx context.Context, path string, szx uint8) ([]byte, error) {
+	if szx > secoapcore.MaxBlockSZX {
+		return nil, fmt.Errorf("%w: block szx %d", secoapcore.ErrInvalidValueLength, szx)
+	}
+	maxBodySize := c.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	var body []byte
+	var num uint32
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := message.NewMessage(ctx)
+		req.SetCode(secoapcore.GET)
+		if err := req.SetPath(path); err != nil {
+			return nil, err
+		}
+		if err := req.SetBlock2(num, false, szx); err != nil {
+			return nil, err
+		}
+		resp, err := c.Conn.SendReceive(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Code() != secoapcore.Content {
+			return nil, fmt.Errorf("%w: code %v", ErrUnexpectedResponse, resp.Code())
+		}
+		payload, err := resp.ReadBody()
+		if err != nil {
+			return nil, err
+		}
+		respNum, respSZX, more, err := resp.Block2()
+		if errors.Is(err, secoapcore.ErrOptionNotFound) && len(body) == 0 {
+			// 服务端不支持块传输, 直接返回完整负载
+			return payload, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		size := BlockSize(uint8(respSZX))
+		if int(respNum)*size != len(body) {
+			return nil, fmt.Errorf("%w: block %d/%d at offset %d", ErrUnexpectedResponse, respNum, size, len(body))
+		}
+		if more && len(payload) != size {
+			return nil, fmt.Errorf("%w: block %d has %d bytes, want %d", ErrUnexpectedResponse, respNum, len(payload), size)
+		}
+		if len(body)+len(payload) > maxBodySize {
+			return nil, ErrBodyTooLarge
+		}
+		body = append(body, payload...)
+		if !more {
+			return body, nil
+		}
+		// 之后的请求使用服务端的块大小
+		szx = uint8(respSZX)
+		num = uint32(len(body) / size)
+	}
+}
+
+// Put 使用 Block1 分块发送 payload, szx 为块大小指数, 服务端可以在 2.31 Continue 中要求更小的块
+func (c *Client) Put(ctx context.Context, path string, payload []byte, szx uint8) error {
+	if szx > secoapcore.MaxBlockSZX {
+		return fmt.Errorf("%w: block szx %d", secoapcore.ErrInvalidValueLength, szx)
+	}
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		size := BlockSize(szx)
+		end := offset + size
+		more := end < len(payload)
+		if !more {
+			end = len(payload)
+		}
+		num := uint32(offset / size)
+		req := message.NewMessage(ctx)
+		req.SetCode(secoapcore.PUT)
+		if err := req.SetPath(path); err != nil {
+			return err
+		}
+		if err := req.SetBlock1(num, more, szx); err != nil {
+			return err
+		}
+		if err := req.SetPayloadBytes(payload[offset:end]); err != nil {
+			return err
+		}
+		resp, err := c.Conn.SendReceive(req)
+		if err != nil {
+			return err
+		}
+		if !more {
+			switch resp.Code() {
+			case secoapcore.Changed, secoapcore.Created:
+				return nil
+			}
+			return fmt.Errorf("%w: code %v", ErrUnexpectedResponse, resp.Code())
+		}
+		if resp.Code() != secoapcore.Continue {
+			return fmt.Errorf("%w: code %v", ErrUnexpectedResponse, resp.Code())
+		}
+		respNum, respSZX, _, err := resp.Block1()
+		switch {
+		case errors.Is(err, secoapcore.ErrOptionNotFound):
+		case err != nil:
+			return err
+		case respNum != num:
+			return fmt.Errorf("%w: acknowledged block %d, want %d", ErrUnexpectedResponse, respNum, num)
+		case uint8(respSZX) < szx:
+			szx = uint8(respSZX)
+		}
+		offset = end
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockwise
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+type sendReceiverFunc func(req *message.Message) (*message.Message, error)
+
+func (f sendReceiverFunc) SendReceive(req *message.Message) (*message.Message, error) {
+	return f(req)
+}
+
+func newResponse(code secoapcore.Code) *message.Message {
+	resp := message.NewMessage(context.Background())
+	resp.SetCode(code)
+	return resp
+}
+
+// blockServer serves resource with Block2, the n-th response uses szx[n] if the
+// request asks for a larger block.
+func blockServer(t *testing.T, resource []byte, szx []uint8, requests *int) SendReceiver {
+	return sendReceiverFunc(func(req *message.Message) (*message.Message, error) {
+		require.Equal(t, secoapcore.GET, req.Code())
+		path, err := req.Path()
+		require.NoError(t, err)
+		require.Equal(t, "/fw/image", path)
+		num, reqSZX, _, err := req.Block2()
+		require.NoError(t, err)
+
+		offset := int(num) * BlockSize(uint8(reqSZX))
+		respSZX := uint8(reqSZX)
+		if s := szx[*requests]; s < respSZX {
+			respSZX = s
+		}
+		*requests++
+		size := BlockSize(respSZX)
+		end := offset + size
+		more := end < len(resource)
+		if !more {
+			end = len(resource)
+		}
+		resp := newResponse(secoapcore.Content)
+		require.NoError(t, resp.SetBlock2(uint32(offset/size), more, respSZX))
+		require.NoError(t, resp.SetPayloadBytes(resource[offset:end]))
+		return resp, nil
+	})
+}
+
+func TestClientGet(t *testing.T) {
+	resource := bytes.Repeat([]byte("0123456789abcdef"), 7) // 112 bytes
+	var requests int
+	// 64 bytes, then 32 bytes for the remaining 48 bytes
+	c := NewClient(blockServer(t, resource, []uint8{2, 1, 1}, &requests))
+	body, err := c.Get(context.Background(), "/fw/image", 6)
+	require.NoError(t, err)
+	require.Equal(t, resource, body)
+	require.Equal(t, 3, requests)
+
+	requests = 0
+	c.MaxBodySize = 100
+	_, err = c.Get(context.Background(), "/fw/image", 6)
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+}
+
+func TestClientGetWithoutBlock(t *testing.T) {
+	c := NewClient(sendReceiverFunc(func(req *message.Message) (*message.Message, error) {
+		resp := newResponse(secoapcore.Content)
+		require.NoError(t, resp.SetPayloadBytes([]byte("small")))
+		return resp, nil
+	}))
+	body, err := c.Get(context.Background(), "/a", 2)
+	require.NoError(t, err)
+	require.Equal(t, []byte("small"), body)
+
+	c = NewClient(sendReceiverFunc(func(req *message.Message) (*message.Message, error) {
+		return newResponse(secoapcore.NotFound), nil
+	}))
+	_, err = c.Get(context.Background(), "/a", 2)
+	require.ErrorIs(t, err, ErrUnexpectedResponse)
+	_, err = c.Get(context.Background(), "/a", 7)
+	require.ErrorIs(t, err, secoapcore.ErrInvalidValueLength)
+}
+
+func TestClientPut(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 7) // 112 bytes
+	var received []byte
+	var sizes []int
+	c := NewClient(sendReceiverFunc(func(req *message.Message) (*message.Message, error) {
+		require.Equal(t, secoapcore.PUT, req.Code())
+		num, szx, more, err := req.Block1()
+		require.NoError(t, err)
+		size := BlockSize(uint8(szx))
+		require.Equal(t, len(received), int(num)*size)
+		data, err := req.ReadBody()
+		require.NoError(t, err)
+		received = append(received, data...)
+		sizes = append(sizes, len(data))
+		if !more {
+			return newResponse(secoapcore.Changed), nil
+		}
+		resp := newResponse(secoapcore.Continue)
+		// ask for 32 bytes blocks
+		respSZX := uint8(szx)
+		if respSZX > 1 {
+			respSZX = 1
+		}
+		require.NoError(t, resp.SetBlock1(num, true, respSZX))
+		return resp, nil
+	}))
+	require.NoError(t, c.Put(context.Background(), "/fw/image", payload, 2))
+	require.Equal(t, payload, received)
+	require.Equal(t, []int{64, 32, 16}, sizes)
+}
+
+func TestClientPutUnexpectedResponse(t *testing.T) {
+	c := NewClient(sendReceiverFunc(func(req *message.Message) (*message.Message, error) {
+		return newResponse(secoapcore.Changed), nil
+	}))
+	err := c.Put(context.Background(), "/a", make([]byte, 100), 1)
+	require.ErrorIs(t, err, ErrUnexpectedResponse)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, c.Put(ctx, "/a", nil, 1), context.Canceled)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/hashicorp/go-multierror"
+)
+
+// BroadcastMessage 发送给多个设备的同一条消息
+type BroadcastMessage struct {
+	Payload *message.Message // 要发送的消息, 不会被修改
+	Targets []string         // 设备地址或路径, 原样传给 send
+}
+
+// Broadcast 将 b.Payload 编码后依次发送给 b.Targets 中的每个目标
+//
+// 每个目标使用 b.Payload 的独立副本, 消息ID和令牌分别由 secoapcore.GetMID 和
+// secoapcore.GetToken 重新生成, 编码使用 s 的编解码器和长度限制. 广播不等待确认,
+// Confirmable 消息会以 NonConfirmable 发送, 并通过 secoapcore.GetLogger 记录一条调试日志.
+// 编码失败时立即返回, send 返回的错误汇总为 *multierror.Error, 不影响其他目标的发送.
+func (s *Secoap) Broadcast(b BroadcastMessage, send func(target string, data []byte) error) error {
+	if b.Payload == nil {
+		return secoapcore.ErrMessageNil
+	}
+	typ := b.Payload.Type()
+	if typ == secoapcore.Confirmable {
+		typ = secoapcore.NonConfirmable
+		secoapcore.GetLogger().Debug("broadcast: confirmable message sent as non-confirmable", "targets", len(b.Targets))
+	}
+
+	var errs *multierror.Error
+	for _, target := range b.Targets {
+		msg := message.NewMessage(s.GetContext())
+		if err := b.Payload.Clone(msg); err != nil {
+			return err
+		}
+		token, err := secoapcore.GetToken()
+		if err != nil {
+			return err
+		}
+		msg.SetVersion(s.Version)
+		msg.SetEncoderID(b.Payload.EncoderID())
+		msg.SetEncoderType(b.Payload.EncoderType())
+		msg.SetType(typ)
+		msg.SetMessageID(secoapcore.GetMID())
+		msg.SetToken(token)
+		data, err := s.marshalMessage(msg)
+		if err != nil {
+			return err
+		}
+		if err := send(target, data); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/internal/testutil"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+type debugRecorder struct {
+	msgs []string
+}
+
+func (l *debugRecorder) Debug(msg string, _ ...interface{}) {
+	l.msgs = append(l.msgs, msg)
+}
+
+type sendCall struct {
+	target string
+	data   []byte
+}
+
+func TestSecoapBroadcast(t *testing.T) {
+	s := NewSecoap(Version2)
+	l := &debugRecorder{}
+	secoapcore.SetLogger(l)
+	t.Cleanup(func() { secoapcore.SetLogger(nil) })
+	msg := testutil.NewV2Message(
+		testutil.WithCode(secoapcore.PUT),
+		testutil.WithType(secoapcore.Confirmable),
+		testutil.WithMessageID(0x1234),
+		testutil.WithToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04}),
+		testutil.WithPath("/led"),
+		testutil.WithPayload(secoapcore.TextPlain, []byte("on")),
+	)
+
+	var calls []sendCall
+	targets := []string{"dev-1", "dev-2", "dev-3"}
+	err := s.Broadcast(BroadcastMessage{Payload: msg, Targets: targets}, func(target string, data []byte) error {
+		calls = append(calls, sendCall{target: target, data: data})
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, calls, len(targets))
+	require.Equal(t, []string{"broadcast: confirmable message sent as non-confirmable"}, l.msgs)
+
+	mids := make(map[int32]bool)
+	tokens := make(map[string]bool)
+	for i, c := range calls {
+		require.Equal(t, targets[i], c.target)
+		got, err := ParseVersion2(c.data)
+		require.NoError(t, err)
+		require.Equal(t, secoapcore.NonConfirmable, got.Type)
+		require.Equal(t, secoapcore.PUT, got.Code)
+		require.Equal(t, []byte("on"), got.Payload)
+		path, err := got.Opts.Path()
+		require.NoError(t, err)
+		require.Equal(t, "/led", path)
+		mids[got.MessageID] = true
+		tokens[got.Token.String()] = true
+	}
+	require.Len(t, mids, len(targets))
+	require.Len(t, tokens, len(targets))
+
+	// 原消息不被修改
+	require.Equal(t, secoapcore.Confirmable, msg.Type())
+	require.Equal(t, int32(0x1234), msg.MessageID())
+	require.Equal(t, secoapcore.Token{0x01, 0x02, 0x03, 0x04}, msg.Token())
+	body, err := msg.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, []byte("on"), body)
+}
+
+func TestSecoapBroadcastErrors(t *testing.T) {
+	s := NewSecoap(Version2)
+	require.ErrorIs(t, s.Broadcast(BroadcastMessage{Targets: []string{"dev-1"}}, nil), secoapcore.ErrMessageNil)
+
+	msg := testutil.NewV2Message(testutil.WithCode(secoapcore.POST), testutil.WithType(secoapcore.NonConfirmable))
+	errOffline := errors.New("offline")
+	var sent []string
+	err := s.Broadcast(BroadcastMessage{Payload: msg, Targets: []string{"dev-1", "dev-2", "dev-3"}}, func(target string, data []byte) error {
+		sent = append(sent, target)
+		if target == "dev-2" {
+			return nil
+		}
+		return errOffline
+	})
+	require.Equal(t, []string{"dev-1", "dev-2", "dev-3"}, sent)
+	require.ErrorIs(t, err, errOffline)
+	var merr *multierror.Error
+	require.ErrorAs(t, err, &merr)
+	require.Len(t, merr.Errors, 2)
+	require.Contains(t, merr.Errors[0].Error(), "dev-1")
+	require.Contains(t, merr.Errors[1].Error(), "dev-3")
+}
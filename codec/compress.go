@@ -0,0 +1,245 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codec 提供包装任意编解码器的负载压缩支持
+//
+// 压缩后的负载通过私有 ContentEncoding 选项标记所用算法, 压缩在内部编码器
+// 计算 CRC16 之前完成, 因此校验和覆盖的是实际发送的压缩数据.
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip uint32 = 1 // EncodingGzip gzip 压缩
+	EncodingZstd uint32 = 2 // EncodingZstd zstd 压缩
+)
+
+// DefaultMaxDecompressedSize 解压后负载的默认最大长度
+const DefaultMaxDecompressedSize = 1 << 20
+
+// optionOverhead ContentEncoding 选项头部的最大长度
+const optionOverhead = 3
+
+var (
+	ErrUnknownEncoding         = errors.New("unknown content encoding")
+	ErrDecompressedTooLarge    = errors.New("decompressed payload too large")
+	ErrInvalidCompressionLevel = errors.New("invalid compression level")
+)
+
+// Compressor 负载压缩算法, ID 写入 ContentEncoding 选项
+type Compressor interface {
+	ID() uint32
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte, maxSize int) ([]byte, error)
+}
+
+// GzipCompressor gzip 压缩算法
+type GzipCompressor struct {
+	level int
+}
+
+// NewGzipCompressor 创建指定压缩级别的 gzip 压缩算法, level 取值同 compress/gzip
+func NewGzipCompressor(level int) (*GzipCompressor, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCompressionLevel, level)
+	}
+	return &GzipCompressor{level: level}, nil
+}
+
+func (c *GzipCompressor) ID() uint32 {
+	return EncodingGzip
+}
+
+func (c *GzipCompressor) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *GzipCompressor) Decompress(src []byte, maxSize int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// 多读一个字节用于判断是否超出 maxSize
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, ErrDecompressedTooLarge
+	}
+	return data, nil
+}
+
+// ZstdCompressor zstd 压缩算法, 可并发使用
+type ZstdCompressor struct {
+	enc *zstd.Encoder
+}
+
+// NewZstdCompressor 创建指定压缩级别的 zstd 压缩算法, level 为 zstd 的压缩级别 1-22
+func NewZstdCompressor(level int) (*ZstdCompressor, error) {
+	if level < 1 || level > 22 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCompressionLevel, level)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return nil, err
+	}
+	return &ZstdCompressor{enc: enc}, nil
+}
+
+func (c *ZstdCompressor) ID() uint32 {
+	return EncodingZstd
+}
+
+func (c *ZstdCompressor) Compress(src []byte) ([]byte, error) {
+	return c.enc.EncodeAll(src, nil), nil
+}
+
+func (c *ZstdCompressor) Decompress(src []byte, maxSize int) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(src), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// 多读一个字节用于判断是否超出 maxSize
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, ErrDecompressedTooLarge
+	}
+	return data, nil
+}
+
+// CompressedEncoder 在内部编码器编码前压缩负载
+//
+// 压缩后的负载不小于原负载时按原样发送, 且不设置 ContentEncoding 选项
+type CompressedEncoder struct {
+	Inner      message.Encoder
+	Compressor Compressor
+}
+
+func NewCompressedEncoder(inner message.Encoder, c Compressor) *CompressedEncoder {
+	return &CompressedEncoder{Inner: inner, Compressor: c}
+}
+
+func (e *CompressedEncoder) Size(m secoapcore.Message) (int, error) {
+	m, err := e.compress(m)
+	if err != nil {
+		return -1, err
+	}
+	return e.Inner.Size(m)
+}
+
+func (e *CompressedEncoder) Encode(m secoapcore.Message, buf []byte) (int, error) {
+	m, err := e.compress(m)
+	if err != nil {
+		return -1, err
+	}
+	return e.Inner.Encode(m, buf)
+}
+
+// compress 返回负载压缩后的消息副本, m 的选项不会被修改
+func (e *CompressedEncoder) compress(m secoapcore.Message) (secoapcore.Message, error) {
+	if len(m.Payload) == 0 || m.Opts.HasOption(secoapcore.ContentEncoding) {
+		return m, nil
+	}
+	data, err := e.Compressor.Compress(m.Payload)
+	if err != nil {
+		return m, err
+	}
+	opts, err := m.Opts.Clone()
+	if err != nil {
+		return m, err
+	}
+	opt := secoapcore.Option{ID: secoapcore.ContentEncoding, Value: e.Compressor.ID()}
+	// 选项本身也占用空间, 压缩收益不足时原样发送
+	if len(data)+len(opt.ToBytes())+optionOverhead >= len(m.Payload) {
+		return m, nil
+	}
+	m.Opts = opts.Set(opt)
+	m.Payload = data
+	return m, nil
+}
+
+// CompressedDecoder 在内部解码器解码后解压负载, 并移除 ContentEncoding 选项
+type CompressedDecoder struct {
+	Inner               message.Decoder
+	MaxDecompressedSize int // <= 0 时使用 DefaultMaxDecompressedSize
+	compressors         map[uint32]Compressor
+}
+
+// NewCompressedDecoder 创建解码器, cs 为可识别的压缩算法
+func NewCompressedDecoder(inner message.Decoder, cs ...Compressor) *CompressedDecoder {
+	d := &CompressedDecoder{
+		Inner:       inner,
+		compressors: make(map[uint32]Compressor, len(cs)),
+	}
+	for _, c := range cs {
+		d.compressors[c.ID()] = c
+	}
+	return d
+}
+
+func (d *CompressedDecoder) Decode(buf []byte, m *secoapcore.Message) (int, error) {
+	n, err := d.Inner.Decode(buf, m)
+	if err != nil {
+		return n, err
+	}
+	id, err := m.Opts.GetUint32(secoapcore.ContentEncoding)
+	if errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return n, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	c, ok := d.compressors[id]
+	if !ok {
+		return -1, fmt.Errorf("%w: %d", ErrUnknownEncoding, id)
+	}
+	maxSize := d.MaxDecompressedSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+	data, err := c.Decompress(m.Payload, maxSize)
+	if err != nil {
+		return -1, err
+	}
+	m.Opts = m.Opts.Remove(secoapcore.ContentEncoding)
+	m.Payload = data
+	return n, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/rand"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMessage(payload []byte) secoapcore.Message {
+	return secoapcore.Message{
+		Token:     []byte{1, 2, 3, 4},
+		Code:      secoapcore.POST,
+		MessageID: 0x1234,
+		Type:      secoapcore.Confirmable,
+		Opts:      secoapcore.Options{{ID: secoapcore.URIPath, Value: []byte("telemetry")}},
+		Payload:   payload,
+	}
+}
+
+func encode(t *testing.T, e *CompressedEncoder, m secoapcore.Message) []byte {
+	size, err := e.Size(m)
+	require.NoError(t, err)
+	buf := make([]byte, size)
+	n, err := e.Encode(m, buf)
+	require.NoError(t, err)
+	require.Equal(t, size, n)
+	return buf
+}
+
+func TestCompressedRoundTrip(t *testing.T) {
+	gz, err := NewGzipCompressor(gzip.BestCompression)
+	require.NoError(t, err)
+	payload := bytes.Repeat([]byte(`{"temperature":21.5,"humidity":40}`), 32)
+	m := newTestMessage(payload)
+
+	data := encode(t, NewCompressedEncoder(coderv2.DefaultCoder, gz), m)
+	plain, err := coderv2.DefaultCoder.Size(m)
+	require.NoError(t, err)
+	require.Less(t, len(data), plain)
+	require.False(t, m.Opts.HasOption(secoapcore.ContentEncoding))
+
+	// the inner decoder sees a valid frame with the compressed payload
+	raw := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = coderv2.DefaultCoder.Decode(data, &raw)
+	require.NoError(t, err)
+	v, err := raw.Opts.GetUint32(secoapcore.ContentEncoding)
+	require.NoError(t, err)
+	require.Equal(t, EncodingGzip, v)
+
+	got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = NewCompressedDecoder(coderv2.DefaultCoder, gz).Decode(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, payload, got.Payload)
+	require.False(t, got.Opts.HasOption(secoapcore.ContentEncoding))
+	path, err := got.Opts.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/telemetry", path)
+}
+
+func TestCompressedRoundTripZstd(t *testing.T) {
+	_, err := NewZstdCompressor(0)
+	require.ErrorIs(t, err, ErrInvalidCompressionLevel)
+
+	zs, err := NewZstdCompressor(3)
+	require.NoError(t, err)
+	gz, err := NewGzipCompressor(gzip.DefaultCompression)
+	require.NoError(t, err)
+	payload := bytes.Repeat([]byte(`{"temperature":21.5,"humidity":40}`), 32)
+	m := newTestMessage(payload)
+
+	data := encode(t, NewCompressedEncoder(coderv2.DefaultCoder, zs), m)
+	plain, err := coderv2.DefaultCoder.Size(m)
+	require.NoError(t, err)
+	require.Less(t, len(data), plain)
+
+	raw := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = coderv2.DefaultCoder.Decode(data, &raw)
+	require.NoError(t, err)
+	v, err := raw.Opts.GetUint32(secoapcore.ContentEncoding)
+	require.NoError(t, err)
+	require.Equal(t, EncodingZstd, v)
+
+	// the decoder picks the compressor by the ContentEncoding option
+	got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = NewCompressedDecoder(coderv2.DefaultCoder, gz, zs).Decode(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, payload, got.Payload)
+	require.False(t, got.Opts.HasOption(secoapcore.ContentEncoding))
+
+	d := NewCompressedDecoder(coderv2.DefaultCoder, zs)
+	d.MaxDecompressedSize = 64
+	got = secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = d.Decode(data, &got)
+	require.ErrorIs(t, err, ErrDecompressedTooLarge)
+}
+
+func TestCompressedIncompressible(t *testing.T) {
+	gz, err := NewGzipCompressor(gzip.DefaultCompression)
+	require.NoError(t, err)
+	payload := make([]byte, 256)
+	rand.New(rand.NewSource(1)).Read(payload)
+	m := newTestMessage(payload)
+
+	data := encode(t, NewCompressedEncoder(coderv2.DefaultCoder, gz), m)
+	plain, err := coderv2.DefaultCoder.Size(m)
+	require.NoError(t, err)
+	require.Equal(t, plain, len(data))
+
+	got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = NewCompressedDecoder(coderv2.DefaultCoder, gz).Decode(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, payload, got.Payload)
+	require.False(t, got.Opts.HasOption(secoapcore.ContentEncoding))
+}
+
+func TestCompressedDecoderErrors(t *testing.T) {
+	_, err := NewGzipCompressor(42)
+	require.ErrorIs(t, err, ErrInvalidCompressionLevel)
+
+	gz, err := NewGzipCompressor(gzip.BestSpeed)
+	require.NoError(t, err)
+	data := encode(t, NewCompressedEncoder(coderv2.DefaultCoder, gz), newTestMessage(make([]byte, 4096)))
+
+	got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = NewCompressedDecoder(coderv2.DefaultCoder).Decode(data, &got)
+	require.ErrorIs(t, err, ErrUnknownEncoding)
+
+	d := NewCompressedDecoder(coderv2.DefaultCoder, gz)
+	d.MaxDecompressedSize = 1024
+	got = secoapcore.Message{Opts: make(secoapcore.Options, 0, 8)}
+	_, err = d.Decode(data, &got)
+	require.ErrorIs(t, err, ErrDecompressedTooLarge)
+}
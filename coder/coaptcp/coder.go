@@ -0,0 +1,229 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coaptcp 实现 COAP over TCP (RFC 8323) 的消息格式
+package coaptcp
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/GiterLab/go-secoap/coder/internal/coapbody"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+var DefaultCoder = new(Coder)
+
+const (
+	lenExtByteCode    = 13
+	lenExtByteAddend  = 13
+	lenExtWordCode    = 14
+	lenExtWordAddend  = 269
+	lenExtDWordCode   = 15
+	lenExtDWordAddend = 65805
+)
+
+type Coder struct{}
+
+// lenHeader 返回 Len 字段的值及其扩展字节数
+func lenHeader(bodyLen int) (int, int) {
+	switch {
+	case bodyLen < lenExtByteAddend:
+		return bodyLen, 0
+	case bodyLen < lenExtWordAddend:
+		return lenExtByteCode, 1
+	case bodyLen < lenExtDWordAddend:
+		return lenExtWordCode, 2
+	default:
+		return lenExtDWordCode, 4
+	}
+}
+
+func (c *Coder) Size(m secoapcore.Message) (int, error) {
+	if len(m.Token) > secoapcore.MaxTokenSize {
+		return -1, secoapcore.ErrTokenTooLong
+	}
+	bodyLen, err := coapbody.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	_, extLen := lenHeader(bodyLen)
+	return 1 + extLen + 1 + len(m.Token) + bodyLen, nil
+}
+
+func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
+	/*
+		  0                   1                   2                   3
+		  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+		 +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 |  Len  |  TKL  | Extended Length (0-4 bytes) ...
+		 +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 |      Code     | Token (if any, TKL bytes) ...
+		 +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 |   Options (if any) ...
+		 +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 |1 1 1 1 1 1 1 1|    Payload (if any) ...
+		 +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+		Len is the length of Options and Payload, 13/14/15 extend it by 1/2/4 bytes.
+	*/
+	size, err := c.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	if len(buf) < size {
+		return size, secoapcore.ErrTooSmall
+	}
+	bodyLen, err := coapbody.Size(m)
+	if err != nil {
+		return -1, err
+	}
+
+	lenField, extLen := lenHeader(bodyLen)
+	buf[0] = byte(lenField<<4) | byte(len(m.Token))
+	buf = buf[1:]
+	switch extLen {
+	case 1:
+		buf[0] = byte(bodyLen - lenExtByteAddend)
+	case 2:
+		binary.BigEndian.PutUint16(buf, uint16(bodyLen-lenExtWordAddend))
+	case 4:
+		binary.BigEndian.PutUint32(buf, uint32(bodyLen-lenExtDWordAddend))
+	}
+	buf = buf[extLen:]
+
+	buf[0] = byte(m.Code)
+	buf = buf[1:]
+	copy(buf, m.Token)
+	buf = buf[len(m.Token):]
+
+	if _, err := coapbody.Encode(m, buf); err != nil {
+		return -1, err
+	}
+	return size, nil
+}
+
+// parseHeader 解析 Len 和 TKL, 返回头部 (含 Code 和 Token) 长度和 Options+Payload 长度
+func parseHeader(data []byte) (int, int, error) {
+	if len(data) < 1 {
+		return -1, -1, secoapcore.ErrMessageTruncated
+	}
+	lenField := int(data[0] >> 4)
+	tokenLen := int(data[0] & 0xf)
+	if tokenLen > secoapcore.MaxTokenSize {
+		return -1, -1, secoapcore.ErrTokenTooLong
+	}
+	extLen := 0
+	switch lenField {
+	case lenExtByteCode:
+		extLen = 1
+	case lenExtWordCode:
+		extLen = 2
+	case lenExtDWordCode:
+		extLen = 4
+	}
+	if len(data) < 1+extLen {
+		return -1, -1, secoapcore.ErrMessageTruncated
+	}
+	bodyLen := lenField
+	ext := data[1 : 1+extLen]
+	switch extLen {
+	case 1:
+		bodyLen = int(ext[0]) + lenExtByteAddend
+	case 2:
+		bodyLen = int(binary.BigEndian.Uint16(ext)) + lenExtWordAddend
+	case 4:
+		bodyLen = int(binary.BigEndian.Uint32(ext)) + lenExtDWordAddend
+	}
+	return 1 + extLen + 1 + tokenLen, bodyLen, nil
+}
+
+// Decode 解码一个消息, data 可以包含后续消息的数据, 返回该消息的字节数
+func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
+	hdrLen, bodyLen, err := parseHeader(data)
+	if err != nil {
+		return -1, err
+	}
+	size := hdrLen + bodyLen
+	if len(data) < size {
+		return -1, secoapcore.ErrMessageTruncated
+	}
+	tokenLen := int(data[0] & 0xf)
+	code := secoapcore.Code(data[hdrLen-tokenLen-1])
+	if !secoapcore.ValidateCode(code) {
+		secoapcore.GetLogger().Debug("unrecognized code", "code", code)
+	}
+	token := data[hdrLen-tokenLen : hdrLen]
+	if len(token) == 0 {
+		token = nil
+	}
+
+	if _, err := coapbody.Decode(data[hdrLen:size], m, false); err != nil {
+		return -1, err
+	}
+
+	m.Ver = secoapcore.Version1
+	m.Token = token
+	m.Code = code
+	// reliable transports have neither message type nor message id
+	m.MessageID = -1
+	m.Type = secoapcore.Unset
+
+	return size, nil
+}
+
+// WriteFrame 编码消息并写入流
+func WriteFrame(w io.Writer, m secoapcore.Message) (int, error) {
+	size, err := DefaultCoder.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	buf := make([]byte, size)
+	if _, err := DefaultCoder.Encode(m, buf); err != nil {
+		return -1, err
+	}
+	return w.Write(buf)
+}
+
+// ReadFrame 从流中读取一个完整的消息, 返回的数据可直接交给 Coder.Decode
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return nil, err
+	}
+	extLen := 0
+	switch first[0] >> 4 {
+	case lenExtByteCode:
+		extLen = 1
+	case lenExtWordCode:
+		extLen = 2
+	case lenExtDWordCode:
+		extLen = 4
+	}
+	hdr := make([]byte, 1+extLen)
+	hdr[0] = first[0]
+	if _, err := io.ReadFull(r, hdr[1:]); err != nil {
+		return nil, err
+	}
+	hdrLen, bodyLen, err := parseHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, hdrLen+bodyLen)
+	copy(frame, hdr)
+	if _, err := io.ReadFull(r, frame[len(hdr):]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coaptcp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		payloadLen int
+		lenField   byte
+	}{
+		{name: "empty", payloadLen: 0, lenField: 4},
+		{name: "4 bits", payloadLen: 7, lenField: 12},
+		{name: "1 byte", payloadLen: 8, lenField: 13},
+		{name: "2 bytes", payloadLen: 300, lenField: 14},
+		{name: "4 bytes", payloadLen: 70000, lenField: 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := secoapcore.Message{
+				Code:  secoapcore.POST,
+				Token: secoapcore.Token{0x01, 0x02},
+				// URIPath "abc": 4 bytes
+				Opts:    secoapcore.Options{{ID: secoapcore.URIPath, Value: "abc"}},
+				Payload: bytes.Repeat([]byte{0x55}, tt.payloadLen),
+			}
+			size, err := DefaultCoder.Size(m)
+			require.NoError(t, err)
+			buf := make([]byte, size)
+			n, err := DefaultCoder.Encode(m, buf)
+			require.NoError(t, err)
+			require.Equal(t, size, n)
+			require.Equal(t, tt.lenField, buf[0]>>4)
+			require.Equal(t, byte(2), buf[0]&0xf)
+
+			var got secoapcore.Message
+			got.Opts = secoapcore.NewOptions(secoapcore.DefaultOptionsCapacity)
+			n, err = DefaultCoder.Decode(append(buf, 0xAA), &got)
+			require.NoError(t, err)
+			require.Equal(t, size, n)
+			require.Equal(t, m.Code, got.Code)
+			require.Equal(t, m.Token, got.Token)
+			require.Equal(t, "abc", got.Opts.PathString())
+			if tt.payloadLen == 0 {
+				require.Nil(t, got.Payload)
+			} else {
+				require.Equal(t, m.Payload, got.Payload)
+			}
+			require.Equal(t, int32(-1), got.MessageID)
+
+			_, err = DefaultCoder.Decode(buf[:size-1], &got)
+			require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+		})
+	}
+}
+
+func TestFrames(t *testing.T) {
+	msgs := []secoapcore.Message{
+		{Code: secoapcore.GET, Token: secoapcore.Token{0x01}},
+		{Code: secoapcore.Content, Payload: bytes.Repeat([]byte("x"), 1000)},
+	}
+	var stream bytes.Buffer
+	for _, m := range msgs {
+		_, err := WriteFrame(&stream, m)
+		require.NoError(t, err)
+	}
+	for _, m := range msgs {
+		frame, err := ReadFrame(&stream)
+		require.NoError(t, err)
+		var got secoapcore.Message
+		n, err := DefaultCoder.Decode(frame, &got)
+		require.NoError(t, err)
+		require.Equal(t, len(frame), n)
+		require.Equal(t, m.Code, got.Code)
+		require.Equal(t, m.Token, got.Token)
+		require.Equal(t, m.Payload, got.Payload)
+	}
+	require.Equal(t, 0, stream.Len())
+}
@@ -23,7 +23,16 @@ import (
 
 var DefaultCoder = new(Coder)
 
-type Coder struct{}
+func init() {
+	secoapcore.RegisterSizer(secoapcore.Version0, DefaultCoder)
+	secoapcore.RegisterDecoder(secoapcore.Version0, DefaultCoder)
+	secoapcore.RegisterEncoder(secoapcore.Version0, DefaultCoder)
+}
+
+type Coder struct {
+	// Checksum CRC16的实现, nil 时使用 secoapcore.SetCRC16Backend 设置的实现
+	Checksum secoapcore.ChecksumBackend
+}
 
 func (c *Coder) Size(m secoapcore.Message) (int, error) {
 	size := 4
@@ -59,7 +68,7 @@ func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
 		return size, secoapcore.ErrTooSmall
 	}
 
-	m.Crc16 = secoapcore.CRC16Bytes(m.Payload)
+	m.Crc16 = secoapcore.CRC16BytesWith(c.Checksum, m.Payload)
 	tmpbufCRC16 := []byte{0, 0}
 	binary.LittleEndian.PutUint16(tmpbufCRC16, m.Crc16)
 
@@ -99,7 +108,7 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	m.EncoderType = etp
 
 	m.Crc16 = crc16
-	if m.Crc16 != secoapcore.CRC16Bytes(m.Payload) {
+	if !secoapcore.CRC16VerifyWith(c.Checksum, m.Payload, m.Crc16) {
 		return -1, secoapcore.ErrInvalidRCRC16
 	}
 
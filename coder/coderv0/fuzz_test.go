@@ -0,0 +1,37 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv0
+
+import (
+	"testing"
+
+	"github.com/GiterLab/go-secoap/internal/fuzzseed"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// FuzzDecodeV0 checks that arbitrary input never makes the decoder panic.
+func FuzzDecodeV0(f *testing.F) {
+	for _, seed := range fuzzseed.DecodeSeeds(DefaultCoder, secoapcore.Version0) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = DefaultCoder.Decode(data, &secoapcore.Message{})
+		// with room for options the decoder also walks the options section
+		m := secoapcore.Message{Opts: make(secoapcore.Options, 0, 16)}
+		if n, err := DefaultCoder.Decode(data, &m); err == nil && n > len(data) {
+			t.Fatalf("decoded %d bytes from %d bytes of input", n, len(data))
+		}
+	})
+}
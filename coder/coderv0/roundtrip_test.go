@@ -0,0 +1,56 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv0
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripV0(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		m := secoapcore.RandomMessage(r)
+		m.Ver = secoapcore.Version0
+
+		size, err := DefaultCoder.Size(m)
+		require.NoError(t, err)
+		data := make([]byte, size)
+		n, err := DefaultCoder.Encode(m, data)
+		require.NoError(t, err, "iteration %d", i)
+		require.Equal(t, size, n)
+
+		var got secoapcore.Message
+		_, err = DefaultCoder.Decode(data, &got)
+		require.NoError(t, err, "iteration %d", i)
+
+		// version 0 only carries the type, the encoder and the payload
+		want := secoapcore.Message{
+			Ver:         secoapcore.Version0,
+			Type:        m.Type,
+			EncoderID:   m.EncoderID,
+			EncoderType: m.EncoderType,
+			Payload:     m.Payload,
+			Crc16:       secoapcore.CRC16Bytes(m.Payload),
+		}
+		if want.Payload == nil {
+			want.Payload = []byte{}
+		}
+		require.Equal(t, want, got, "iteration %d", i)
+	}
+}
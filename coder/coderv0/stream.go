@@ -0,0 +1,95 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv0
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// streamPrefixSize 流传输时消息前的长度前缀大小
+const streamPrefixSize = 2
+
+// DefaultStreamCoder 流传输 (TCP 等) 使用的V0编解码器
+var DefaultStreamCoder = new(StreamCoder)
+
+// StreamCoder V0 流传输编解码器
+//
+// V0 头部没有长度字段, 在流传输中无法区分消息边界, 因此在每个消息前附加
+// 2字节大端长度前缀. 数据报传输请使用 DefaultCoder
+type StreamCoder struct {
+	Coder
+}
+
+func (c *StreamCoder) Size(m secoapcore.Message) (int, error) {
+	size, err := c.Coder.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	return streamPrefixSize + size, nil
+}
+
+func (c *StreamCoder) Encode(m secoapcore.Message, buf []byte) (int, error) {
+	size, err := c.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	if size-streamPrefixSize > 0xffff {
+		return -1, fmt.Errorf("message too large for stream framing: %d", size-streamPrefixSize)
+	}
+	if len(buf) < size {
+		return size, secoapcore.ErrTooSmall
+	}
+	n, err := c.Coder.Encode(m, buf[streamPrefixSize:])
+	if err != nil {
+		return n, err
+	}
+	binary.BigEndian.PutUint16(buf, uint16(n))
+	return streamPrefixSize + n, nil
+}
+
+func (c *StreamCoder) Decode(data []byte, m *secoapcore.Message) (int, error) {
+	if len(data) < streamPrefixSize {
+		return -1, secoapcore.ErrMessageTruncated
+	}
+	size := int(binary.BigEndian.Uint16(data))
+	data = data[streamPrefixSize:]
+	if len(data) < size {
+		return -1, secoapcore.ErrMessageTruncated
+	}
+	n, err := c.Coder.Decode(data[:size], m)
+	if err != nil {
+		return n, err
+	}
+	return streamPrefixSize + n, nil
+}
+
+// ReadFrame 从流中读取一个完整的消息 (不含长度前缀), 返回的数据可直接交给 Coder.Decode
+//
+// 未命名为 ReadFrom, 以免与 io.ReaderFrom 的方法签名冲突
+func (c *StreamCoder) ReadFrame(r io.Reader) ([]byte, error) {
+	var prefix [streamPrefixSize]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv0
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCoder(t *testing.T) {
+	msgs := []secoapcore.Message{
+		{Type: secoapcore.Confirmable, Payload: []byte("hello")},
+		{Type: secoapcore.NonConfirmable, EncoderID: 1, EncoderType: 2, Payload: []byte{}},
+	}
+
+	var stream bytes.Buffer
+	for _, m := range msgs {
+		size, err := DefaultStreamCoder.Size(m)
+		require.NoError(t, err)
+		buf := make([]byte, size)
+		n, err := DefaultStreamCoder.Encode(m, buf)
+		require.NoError(t, err)
+		require.Equal(t, size, n)
+
+		var got secoapcore.Message
+		n, err = DefaultStreamCoder.Decode(buf, &got)
+		require.NoError(t, err)
+		require.Equal(t, size, n)
+		require.Equal(t, m.Payload, got.Payload)
+		stream.Write(buf)
+	}
+
+	for _, m := range msgs {
+		data, err := DefaultStreamCoder.ReadFrame(&stream)
+		require.NoError(t, err)
+		var got secoapcore.Message
+		_, err = DefaultCoder.Decode(data, &got)
+		require.NoError(t, err)
+		require.Equal(t, m.Type, got.Type)
+		require.Equal(t, m.EncoderID, got.EncoderID)
+		require.Equal(t, m.EncoderType, got.EncoderType)
+		require.Equal(t, m.Payload, got.Payload)
+	}
+
+	_, err := DefaultStreamCoder.Decode([]byte{0x00, 0x08, 0x00}, &secoapcore.Message{})
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+}
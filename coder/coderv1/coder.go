@@ -16,32 +16,34 @@ package coderv1
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 
+	"github.com/GiterLab/go-secoap/coder/internal/coapbody"
 	"github.com/GiterLab/go-secoap/secoapcore"
 )
 
 var DefaultCoder = new(Coder)
 
-type Coder struct{}
+func init() {
+	secoapcore.RegisterSizer(secoapcore.Version1, DefaultCoder)
+	secoapcore.RegisterDecoder(secoapcore.Version1, DefaultCoder)
+	secoapcore.RegisterEncoder(secoapcore.Version1, DefaultCoder)
+}
+
+type Coder struct {
+	// SkipPadding 解码时跳过 Payload 分隔符前的 0x00 填充, 默认关闭, 见 secoapcore.Options.UnmarshalSkipPadding
+	SkipPadding bool
+}
 
 func (c *Coder) Size(m secoapcore.Message) (int, error) {
 	if len(m.Token) > secoapcore.MaxTokenSize {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
-	size := 4 + len(m.Token)
-	payloadLen := len(m.Payload)
-	optionsLen, err := m.Opts.Marshal(nil)
-	if !errors.Is(err, secoapcore.ErrTooSmall) {
+	bodyLen, err := coapbody.Size(m)
+	if err != nil {
 		return -1, err
 	}
-	if payloadLen > 0 {
-		// for separator 0xff
-		payloadLen++
-	}
-	size += payloadLen + optionsLen
-	return size, nil
+	return 4 + len(m.Token) + bodyLen, nil
 }
 
 func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
@@ -82,26 +84,14 @@ func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
 	buf = buf[4:]
 
 	if len(m.Token) > secoapcore.MaxTokenSize {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
 	copy(buf, m.Token)
 	buf = buf[len(m.Token):]
 
-	optionsLen, err := m.Opts.Marshal(buf)
-	switch {
-	case err == nil:
-	case errors.Is(err, secoapcore.ErrTooSmall):
-		return size, err
-	default:
+	if _, err := coapbody.Encode(m, buf); err != nil {
 		return -1, err
 	}
-	buf = buf[optionsLen:]
-
-	if len(m.Payload) > 0 {
-		buf[0] = 0xff // payload separator
-		buf = buf[1:]
-	}
-	copy(buf, m.Payload)
 	return size, nil
 }
 
@@ -118,10 +108,13 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	typ := secoapcore.Type((data[0] >> 4) & 0x3)
 	tokenLen := int(data[0] & 0xf)
 	if tokenLen > 8 {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
 
 	code := secoapcore.Code(data[1])
+	if !secoapcore.ValidateCode(code) && secoapcore.DebugEnabled() {
+		secoapcore.GetLogger().Debug("unrecognized code", "code", code)
+	}
 	messageID := binary.BigEndian.Uint16(data[2:4])
 	data = data[4:]
 	if len(data) < tokenLen {
@@ -133,20 +126,13 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	}
 	data = data[tokenLen:]
 
-	optionDefs := secoapcore.CoapOptionDefs
-	proc, err := m.Opts.Unmarshal(data, optionDefs)
-	if err != nil {
+	if _, err := coapbody.Decode(data, m, c.SkipPadding); err != nil {
 		return -1, err
 	}
-	data = data[proc:]
-	if len(data) == 0 {
-		data = nil
-	}
 
 	m.Ver = secoapcore.Version1
 	m.Token = token
 	m.Code = code
-	m.Payload = data
 
 	m.MessageID = int32(messageID)
 	m.Type = typ
@@ -0,0 +1,66 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv1
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripV1(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		m := secoapcore.RandomMessage(r)
+		m.Ver = secoapcore.Version1
+
+		size, err := DefaultCoder.Size(m)
+		require.NoError(t, err)
+		data := make([]byte, size)
+		n, err := DefaultCoder.Encode(m, data)
+		require.NoError(t, err, "iteration %d", i)
+		require.Equal(t, size, n)
+
+		got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 16)}
+		_, err = DefaultCoder.Decode(data, &got)
+		require.NoError(t, err, "iteration %d", i)
+
+		// version 1 has no encoder fields and no checksums
+		want := m
+		want.Opts = append(secoapcore.Options{}, m.Opts...)
+		want.EncoderID = 0
+		want.EncoderType = 0
+		require.Equal(t, want, got, "iteration %d", i)
+	}
+}
+
+func TestDecodeEmptyOptionBeforePayload(t *testing.T) {
+	// Uri-Path "a", Uri-Path "", payload "x"
+	data := []byte{0x40, 0x01, 0x00, 0x01, 0xb1, 0x61, 0x00, 0xff, 0x78}
+
+	got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 16)}
+	_, err := DefaultCoder.Decode(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, "/a/", got.Opts.URL())
+	require.Equal(t, []byte("x"), got.Payload)
+
+	// SkipPadding 将 0x00 当作填充
+	got = secoapcore.Message{Opts: make(secoapcore.Options, 0, 16)}
+	_, err = (&Coder{SkipPadding: true}).Decode(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, "/a", got.Opts.URL())
+}
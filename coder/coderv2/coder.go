@@ -24,11 +24,22 @@ import (
 
 var DefaultCoder = new(Coder)
 
-type Coder struct{}
+func init() {
+	secoapcore.RegisterSizer(secoapcore.Version2, DefaultCoder)
+	secoapcore.RegisterDecoder(secoapcore.Version2, DefaultCoder)
+	secoapcore.RegisterEncoder(secoapcore.Version2, DefaultCoder)
+}
+
+type Coder struct {
+	// Checksum CRC16的实现, nil 时使用 secoapcore.SetCRC16Backend 设置的实现
+	Checksum secoapcore.ChecksumBackend
+	// SkipPadding 解码时跳过 Payload 分隔符前的 0x00 填充, 默认关闭, 见 secoapcore.Options.UnmarshalSkipPadding
+	SkipPadding bool
+}
 
 func (c *Coder) Size(m secoapcore.Message) (int, error) {
 	if len(m.Token) > secoapcore.MaxTokenSize {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
 	size := 8 + len(m.Token)
 	payloadLen := len(m.Payload)
@@ -45,6 +56,32 @@ func (c *Coder) Size(m secoapcore.Message) (int, error) {
 }
 
 func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
+	size, err := c.Size(m)
+	if err != nil {
+		return -1, err
+	}
+	if len(buf) < size {
+		return size, secoapcore.ErrTooSmall
+	}
+
+	headerLen, err := c.EncodeHeader(m, len(m.Payload), buf)
+	if err != nil {
+		return headerLen, err
+	}
+	copy(buf[headerLen:], m.Payload)
+
+	if err := finalizeHeader(buf[:size], headerLen, c.Checksum); err != nil {
+		return -1, err
+	}
+	return size, nil
+}
+
+// EncodeHeader encodes everything except the payload bytes, the payload separator
+// is written when payloadLen > 0. CRC16 and RSUM8 are left as 0x0000 and 0x00,
+// call FinalizeHeader once the payload has been written after the header.
+//
+// Returns the number of header bytes.
+func (c *Coder) EncodeHeader(m secoapcore.Message, payloadLen int, buf []byte) (int, error) {
 	/*
 		 0                   1                   2                   3
 		 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
@@ -72,26 +109,27 @@ func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
 	if !secoapcore.ValidateETP(m.EncoderType) {
 		return -1, fmt.Errorf("invalid EncoderType(%v)", m.EncoderType)
 	}
+	m.Payload = nil
 	size, err := c.Size(m)
 	if err != nil {
 		return -1, err
 	}
+	if payloadLen > 0 {
+		// for separator 0xff
+		size++
+	}
 	if len(buf) < size {
 		return size, secoapcore.ErrTooSmall
 	}
 
-	m.Crc16 = secoapcore.CRC16Bytes(m.Payload)
-	tmpbufCRC16 := []byte{0, 0}
-	binary.BigEndian.PutUint16(tmpbufCRC16, m.Crc16)
-
 	tmpbufMessageID := []byte{0, 0}
 	binary.BigEndian.PutUint16(tmpbufMessageID, uint16(m.MessageID))
 
 	pbuf := buf
 	pbuf[0] = (2 << 6) | (byte(0xf&len(m.Token)) << 2) | byte(m.Type)
 	pbuf[1] = byte(m.EncoderID<<4) | byte(m.EncoderType)
-	pbuf[2] = tmpbufCRC16[0]
-	pbuf[3] = tmpbufCRC16[1]
+	pbuf[2] = 0x00 // 写入Payload后再计算CRC16
+	pbuf[3] = 0x00
 	pbuf[4] = tmpbufMessageID[0]
 	pbuf[5] = tmpbufMessageID[1]
 	pbuf[6] = byte(m.Code)
@@ -99,7 +137,7 @@ func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
 	pbuf = pbuf[8:]
 
 	if len(m.Token) > secoapcore.MaxTokenSize {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
 	copy(pbuf, m.Token)
 	pbuf = pbuf[len(m.Token):]
@@ -114,17 +152,29 @@ func (c *Coder) Encode(m secoapcore.Message, buf []byte) (int, error) {
 	}
 	pbuf = pbuf[optionsLen:]
 
-	if len(m.Payload) > 0 {
+	if payloadLen > 0 {
 		pbuf[0] = 0xff // payload separator
-		pbuf = pbuf[1:]
 	}
-	copy(pbuf, m.Payload)
-
-	buf[7] = secoapcore.RSUM8(buf[0:size]) // 计算RSUM8后填充
 
 	return size, nil
 }
 
+// FinalizeHeader fills in CRC16 and RSUM8 of a frame produced by EncodeHeader,
+// buf is the complete frame and headerLen the value returned by EncodeHeader.
+func FinalizeHeader(buf []byte, headerLen int) error {
+	return finalizeHeader(buf, headerLen, nil)
+}
+
+func finalizeHeader(buf []byte, headerLen int, checksum secoapcore.ChecksumBackend) error {
+	if len(buf) < 8 || headerLen < 8 || headerLen > len(buf) {
+		return secoapcore.ErrMessageTruncated
+	}
+	binary.BigEndian.PutUint16(buf[2:4], secoapcore.CRC16BytesWith(checksum, buf[headerLen:]))
+	buf[7] = 0x00
+	buf[7] = secoapcore.RSUM8(buf) // 计算RSUM8后填充
+	return nil
+}
+
 func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	size := len(data)
 	if size < 8 {
@@ -132,7 +182,11 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	}
 
 	rsum8 := data[7]
-	if secoapcore.RSUM8(data) != 0 {
+	sum := secoapcore.RSUM8(data)
+	if secoapcore.DebugEnabled() {
+		secoapcore.GetLogger().Debug("verify rsum8", "rsum8", rsum8, "sum", sum, "size", size)
+	}
+	if sum != 0 {
 		return -1, secoapcore.ErrMessageInvalidRSUM8
 	}
 
@@ -143,13 +197,16 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	typ := secoapcore.Type(data[0] & 0x3)
 	tokenLen := int((data[0] >> 2) & 0xf)
 	if tokenLen > 8 {
-		return -1, secoapcore.ErrInvalidTokenLen
+		return -1, secoapcore.ErrTokenTooLong
 	}
 	eid := int32(data[1] >> 4)
 	etp := int32(data[1] & 0xf)
 	crc16 := binary.BigEndian.Uint16(data[2:4])
 	messageID := binary.BigEndian.Uint16(data[4:6])
 	code := secoapcore.Code(data[6])
+	if !secoapcore.ValidateCode(code) && secoapcore.DebugEnabled() {
+		secoapcore.GetLogger().Debug("unrecognized code", "code", code)
+	}
 	data = data[8:]
 	if len(data) < tokenLen {
 		return -1, secoapcore.ErrMessageTruncated
@@ -160,8 +217,14 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	}
 	data = data[tokenLen:]
 
-	optionDefs := secoapcore.CoapOptionDefs
-	proc, err := m.Opts.Unmarshal(data, optionDefs)
+	optionDefs := secoapcore.OptionDefs()
+	var proc int
+	var err error
+	if c.SkipPadding {
+		proc, err = m.Opts.UnmarshalSkipPadding(data, optionDefs)
+	} else {
+		proc, err = m.Opts.Unmarshal(data, optionDefs)
+	}
 	if err != nil {
 		return -1, err
 	}
@@ -181,7 +244,7 @@ func (c *Coder) Decode(data []byte, m *secoapcore.Message) (int, error) {
 	m.EncoderType = etp
 
 	m.Crc16 = crc16
-	if m.Crc16 != secoapcore.CRC16Bytes(m.Payload) {
+	if !secoapcore.CRC16VerifyWith(c.Checksum, m.Payload, m.Crc16) {
 		return -1, secoapcore.ErrInvalidRCRC16
 	}
 	m.Rsum8 = rsum8
@@ -0,0 +1,143 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv2
+
+import (
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+// fixRSUM8 rewrites the RSUM8 byte so that the frame passes verification.
+func fixRSUM8(data []byte) {
+	data[7] = 0
+	others := secoapcore.RSUM8(data) - 0xFF
+	data[7] = ^(-others)
+}
+
+func TestCoderTokenTooLong(t *testing.T) {
+	m := secoapcore.Message{
+		Token:     make(secoapcore.Token, secoapcore.MaxTokenSize+1),
+		MessageID: 1,
+		Type:      secoapcore.Confirmable,
+	}
+	_, err := DefaultCoder.Size(m)
+	require.ErrorIs(t, err, secoapcore.ErrTokenTooLong)
+	_, err = DefaultCoder.Encode(m, make([]byte, 64))
+	require.ErrorIs(t, err, secoapcore.ErrTokenTooLong)
+
+	data := make([]byte, 32)
+	data[0] = 2<<6 | 9<<2
+	fixRSUM8(data)
+	_, err = DefaultCoder.Decode(data, &secoapcore.Message{})
+	require.ErrorIs(t, err, secoapcore.ErrTokenTooLong)
+	require.ErrorIs(t, err, secoapcore.ErrInvalidTokenLen)
+}
+
+func TestCoderEncodeHeader(t *testing.T) {
+	opts, _, err := secoapcore.NewOptions(4).SetPath(make([]byte, 32), "/a/b")
+	require.NoError(t, err)
+	m := secoapcore.Message{
+		Token:       secoapcore.Token{0x01, 0x02},
+		Opts:        opts,
+		Code:        secoapcore.POST,
+		Payload:     []byte("payload"),
+		MessageID:   0x1234,
+		Type:        secoapcore.NonConfirmable,
+		EncoderID:   0,
+		EncoderType: 6,
+	}
+	size, err := DefaultCoder.Size(m)
+	require.NoError(t, err)
+	want := make([]byte, size)
+	_, err = DefaultCoder.Encode(m, want)
+	require.NoError(t, err)
+
+	buf := make([]byte, size)
+	headerLen, err := DefaultCoder.EncodeHeader(m, len(m.Payload), buf)
+	require.NoError(t, err)
+	require.Equal(t, size-len(m.Payload), headerLen)
+	require.Equal(t, byte(0xff), buf[headerLen-1])
+	copy(buf[headerLen:], m.Payload)
+	require.NoError(t, FinalizeHeader(buf, headerLen))
+	require.Equal(t, want, buf)
+
+	_, err = DefaultCoder.EncodeHeader(m, len(m.Payload), buf[:headerLen-1])
+	require.ErrorIs(t, err, secoapcore.ErrTooSmall)
+}
+
+type constBackend uint16
+
+func (c constBackend) Checksum16([]byte) uint16 { return uint16(c) }
+
+func TestCoderCRC16Backend(t *testing.T) {
+	secoapcore.SetCRC16Backend(constBackend(0xABCD))
+	t.Cleanup(func() { secoapcore.SetCRC16Backend(nil) })
+
+	m := secoapcore.Message{
+		MessageID: 1,
+		Type:      secoapcore.Confirmable,
+		Code:      secoapcore.POST,
+		Payload:   []byte("hello"),
+	}
+	buf := make([]byte, 64)
+	n, err := DefaultCoder.Encode(m, buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xAB, 0xCD}, buf[2:4])
+
+	var got secoapcore.Message
+	_, err = DefaultCoder.Decode(buf[:n], &got)
+	require.NoError(t, err)
+
+	secoapcore.SetCRC16Backend(secoapcore.CRC16CCITTBackend)
+	_, err = DefaultCoder.Decode(buf[:n], &got)
+	require.ErrorIs(t, err, secoapcore.ErrInvalidRCRC16)
+}
+
+func TestCoderDecodeNopLoggerNoAllocs(t *testing.T) {
+	data := make([]byte, 8)
+	data[0] = 2 << 6
+	data[6] = 0xff // unrecognized code
+	require.NoError(t, FinalizeHeader(data, len(data)))
+	var m secoapcore.Message
+	allocs := testing.AllocsPerRun(100, func() {
+		_, err := DefaultCoder.Decode(data, &m)
+		require.NoError(t, err)
+	})
+	require.Zero(t, allocs)
+}
+
+type debugRecorder struct {
+	msgs []string
+}
+
+func (l *debugRecorder) Debug(msg string, _ ...interface{}) {
+	l.msgs = append(l.msgs, msg)
+}
+
+func TestCoderDecodeLogs(t *testing.T) {
+	l := &debugRecorder{}
+	secoapcore.SetLogger(l)
+	t.Cleanup(func() { secoapcore.SetLogger(nil) })
+
+	data := make([]byte, 8)
+	data[0] = 2 << 6
+	data[6] = 0xff // unrecognized code
+	require.NoError(t, FinalizeHeader(data, len(data)))
+	_, err := DefaultCoder.Decode(data, &secoapcore.Message{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"verify rsum8", "unrecognized code"}, l.msgs)
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coderv2
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripV2(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		m := secoapcore.RandomMessage(r)
+		m.Ver = secoapcore.Version2
+
+		size, err := DefaultCoder.Size(m)
+		require.NoError(t, err)
+		data := make([]byte, size)
+		n, err := DefaultCoder.Encode(m, data)
+		require.NoError(t, err, "iteration %d", i)
+		require.Equal(t, size, n)
+
+		got := secoapcore.Message{Opts: make(secoapcore.Options, 0, 16)}
+		_, err = DefaultCoder.Decode(data, &got)
+		require.NoError(t, err, "iteration %d", i)
+
+		// recompute the checksums independently of the decoded values
+		frame := append([]byte(nil), data...)
+		frame[7] = 0
+		want := m
+		want.Opts = append(secoapcore.Options{}, m.Opts...)
+		want.Crc16 = secoapcore.CRC16Bytes(m.Payload)
+		want.Rsum8 = secoapcore.RSUM8(frame)
+		require.Equal(t, want, got, "iteration %d", i)
+	}
+}
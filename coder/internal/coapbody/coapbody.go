@@ -0,0 +1,89 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coapbody 编解码 COAP 消息头部之后的 Options 和 Payload, 供 coderv1 和 coaptcp 共用
+package coapbody
+
+import (
+	"errors"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// Size 返回 Options, 分隔符 0xFF 和 Payload 编码后的字节数
+func Size(m secoapcore.Message) (int, error) {
+	optionsLen, err := m.Opts.Marshal(nil)
+	if !errors.Is(err, secoapcore.ErrTooSmall) {
+		return -1, err
+	}
+	payloadLen := len(m.Payload)
+	if payloadLen > 0 {
+		// for separator 0xff
+		payloadLen++
+	}
+	return optionsLen + payloadLen, nil
+}
+
+// Encode 将 Options, 分隔符 0xFF 和 Payload 写入 buf, 返回写入的字节数
+func Encode(m secoapcore.Message, buf []byte) (int, error) {
+	/*
+		+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		|   Options (if any) ...
+		+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		|1 1 1 1 1 1 1 1|    Payload (if any) ...
+		+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	*/
+	size, err := Size(m)
+	if err != nil {
+		return -1, err
+	}
+	if len(buf) < size {
+		return size, secoapcore.ErrTooSmall
+	}
+	optionsLen, err := m.Opts.Marshal(buf)
+	if err != nil {
+		return -1, err
+	}
+	buf = buf[optionsLen:]
+
+	if len(m.Payload) > 0 {
+		buf[0] = 0xff // payload separator
+		buf = buf[1:]
+	}
+	copy(buf, m.Payload)
+	return size, nil
+}
+
+// Decode 解析 data 中的 Options 和 Payload 到 m, Payload 引用 data 中的数据
+//
+// skipPadding 为 true 时跳过 Payload 分隔符前的 0x00 填充, 见 secoapcore.Options.UnmarshalSkipPadding
+func Decode(data []byte, m *secoapcore.Message, skipPadding bool) (int, error) {
+	size := len(data)
+	var proc int
+	var err error
+	if skipPadding {
+		proc, err = m.Opts.UnmarshalSkipPadding(data, secoapcore.OptionDefs())
+	} else {
+		proc, err = m.Opts.Unmarshal(data, secoapcore.OptionDefs())
+	}
+	if err != nil {
+		return -1, err
+	}
+	data = data[proc:]
+	if len(data) == 0 {
+		data = nil
+	}
+	m.Payload = data
+	return size, nil
+}
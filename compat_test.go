@@ -0,0 +1,90 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv0"
+	"github.com/GiterLab/go-secoap/coder/coderv1"
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+// corpusEntry testdata/corpus.json 中的一条记录, 供其他实现做兼容性测试
+type corpusEntry struct {
+	Description string `json:"description"`
+	Version     int    `json:"version"`
+	Hex         string `json:"hex"`
+}
+
+func TestDecodeCorpus(t *testing.T) {
+	data, err := os.ReadFile("testdata/corpus.json")
+	require.NoError(t, err)
+	var entries []corpusEntry
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.NotEmpty(t, entries)
+
+	coders := map[int]message.Coder{
+		0: coderv0.DefaultCoder,
+		1: coderv1.DefaultCoder,
+		2: coderv2.DefaultCoder,
+	}
+	for _, e := range entries {
+		e := e
+		t.Run(fmt.Sprintf("v%d/%s", e.Version, e.Description), func(t *testing.T) {
+			coder, ok := coders[e.Version]
+			require.True(t, ok, "unknown version %d", e.Version)
+			raw, err := hex.DecodeString(e.Hex)
+			require.NoError(t, err)
+
+			ver, err := secoapcore.GetVersion(raw)
+			require.NoError(t, err)
+			require.EqualValues(t, e.Version, ver)
+
+			m := secoapcore.Message{Opts: make(secoapcore.Options, 0, 32)}
+			n, err := coder.Decode(raw, &m)
+			require.NoError(t, err)
+			require.Equal(t, len(raw), n)
+
+			// the corpus holds canonical encodings, re-encoding gives the same bytes
+			size, err := coder.Size(m)
+			require.NoError(t, err)
+			buf := make([]byte, size)
+			_, err = coder.Encode(m, buf)
+			require.NoError(t, err)
+			require.Equal(t, raw, buf)
+
+			require.NotContains(t, secoapcore.HexDump(raw, ver), "[PARSE ERROR")
+		})
+	}
+}
+
+func TestHexDumpChecksum(t *testing.T) {
+	s := newTestSecoap(t)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	require.NotContains(t, secoapcore.HexDump(data, Version2), "[PARSE ERROR")
+
+	// the registered coderv2 decoder reports the corrupted RSUM8
+	data[7]++
+	require.Contains(t, secoapcore.HexDump(data, Version2), "[PARSE ERROR: "+secoapcore.ErrMessageInvalidRSUM8.Error()+"]")
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config 构建和解析设备参数配置消息 (GiterlabErrnoParamConfigure)
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrNotParamConfig 消息不是参数配置消息
+var ErrNotParamConfig = errors.New("config: not a parameter configuration message")
+
+// Params 设备参数, 键为参数名
+type Params map[string]string
+
+// NewParamConfigMessage 创建参数配置消息, 负载为 JSON 编码的 params, 并设置 CheckCRC32
+func NewParamConfigMessage(params Params, token secoapcore.Token) (*message.Message, error) {
+	if params == nil {
+		params = Params{}
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.NewMessage(context.Background())
+	msg.SetVersion(secoapcore.Version2)
+	msg.SetCode(secoapcore.GiterlabErrnoParamConfigure)
+	msg.SetToken(token)
+	msg.SetContentFormat(secoapcore.AppJSON)
+	if err := msg.SetPayloadBytes(payload); err != nil {
+		return nil, err
+	}
+	if err := msg.SetCheckCRC32FromBody(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ParseParamConfigMessage 解析参数配置消息, 消息必须带有正确的 CheckCRC32 选项
+func ParseParamConfigMessage(msg *message.Message) (Params, error) {
+	if msg == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	if msg.Code() != secoapcore.GiterlabErrnoParamConfigure {
+		return nil, fmt.Errorf("%w: code %v", ErrNotParamConfig, msg.Code())
+	}
+	if cf, err := msg.ContentFormat(); err == nil && cf != secoapcore.AppJSON {
+		return nil, fmt.Errorf("%w: content format %v", ErrNotParamConfig, cf)
+	}
+	if err := msg.VerifyCheckCRC32(); err != nil {
+		return nil, err
+	}
+	payload, err := msg.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	params := Params{}
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return nil, err
+	}
+	if params == nil {
+		// 负载为 null
+		params = Params{}
+	}
+	return params, nil
+}
+
+// Diff 比较两组参数, 返回 new 中新增的参数, old 中被删除的参数, 以及值发生变化的参数 (取 new 中的值)
+func Diff(old, new Params) (added, removed, changed map[string]string) {
+	added = make(map[string]string)
+	removed = make(map[string]string)
+	changed = make(map[string]string)
+	for k, v := range new {
+		ov, ok := old[k]
+		if !ok {
+			added[k] = v
+		} else if ov != v {
+			changed[k] = v
+		}
+	}
+	for k, v := range old {
+		if _, ok := new[k]; !ok {
+			removed[k] = v
+		}
+	}
+	return added, removed, changed
+}
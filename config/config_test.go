@@ -0,0 +1,79 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   Params
+	}{
+		{name: "nil", params: nil, want: Params{}},
+		{name: "empty", params: Params{}, want: Params{}},
+		{name: "ascii", params: Params{"interval": "60", "server": "coap://example.com"}},
+		{name: "unicode", params: Params{"温度上限": "35℃", "名称": "传感器-01", "emoji": "🌡"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := secoapcore.Token{0x01, 0x02, 0x03, 0x04}
+			msg, err := NewParamConfigMessage(tt.params, token)
+			require.NoError(t, err)
+			require.Equal(t, secoapcore.Code(secoapcore.GiterlabErrnoParamConfigure), msg.Code())
+			require.Equal(t, token, msg.Token())
+			require.True(t, msg.HasCheckCRC32())
+
+			got, err := ParseParamConfigMessage(msg)
+			require.NoError(t, err)
+			want := tt.want
+			if want == nil {
+				want = tt.params
+			}
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseParamConfigTampered(t *testing.T) {
+	msg, err := NewParamConfigMessage(Params{"interval": "60"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, msg.SetPayloadBytes([]byte(`{"interval":"1"}`)))
+	_, err = ParseParamConfigMessage(msg)
+	require.ErrorIs(t, err, secoapcore.ErrCheckCRC32Mismatch)
+
+	msg.SetCode(secoapcore.POST)
+	_, err = ParseParamConfigMessage(msg)
+	require.ErrorIs(t, err, ErrNotParamConfig)
+}
+
+func TestDiff(t *testing.T) {
+	old := Params{"a": "1", "b": "2", "c": "3"}
+	new := Params{"b": "2", "c": "30", "d": "4"}
+	added, removed, changed := Diff(old, new)
+	require.Equal(t, map[string]string{"d": "4"}, added)
+	require.Equal(t, map[string]string{"a": "1"}, removed)
+	require.Equal(t, map[string]string{"c": "30"}, changed)
+
+	added, removed, changed = Diff(nil, nil)
+	require.Empty(t, added)
+	require.Empty(t, removed)
+	require.Empty(t, changed)
+}
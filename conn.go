@@ -0,0 +1,143 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// maxDatagramSize UDP 数据报的最大长度
+const maxDatagramSize = 65535
+
+// Conn Secoap协议连接
+//
+// UDP 连接每个数据报为一个消息, TCP 连接的每个消息前附加2字节大端长度前缀, 见 message.WriteFrame
+type Conn struct {
+	conn   net.Conn
+	ctx    context.Context
+	ver    secoapcore.Ver
+	stream bool
+	buf    []byte
+}
+
+// DialUDP 连接UDP地址, 返回可直接收发的Secoap连接
+func DialUDP(ctx context.Context, addr string, ver secoapcore.Ver) (*Conn, error) {
+	return dial(ctx, "udp", addr, ver)
+}
+
+// DialTCP 连接TCP地址, 返回可直接收发的Secoap连接
+func DialTCP(ctx context.Context, addr string, ver secoapcore.Ver) (*Conn, error) {
+	return dial(ctx, "tcp", addr, ver)
+}
+
+func dial(ctx context.Context, network, addr string, ver secoapcore.Ver) (*Conn, error) {
+	if ver > Version2 {
+		return nil, secoapcore.ErrMessageInvalidVersion
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(ctx, conn, ver), nil
+}
+
+// NewConn 使用已建立的连接创建Secoap连接, 非 net.PacketConn 的连接按流传输处理
+func NewConn(ctx context.Context, conn net.Conn, ver secoapcore.Ver) *Conn {
+	_, isPacket := conn.(net.PacketConn)
+	return &Conn{
+		conn:   conn,
+		ctx:    ctx,
+		ver:    ver,
+		stream: !isPacket,
+		buf:    make([]byte, maxDatagramSize),
+	}
+}
+
+// Version returns the version used by NewSecoap for outgoing messages.
+func (c *Conn) Version() secoapcore.Ver {
+	return c.ver
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// NewSecoap 创建一个与连接版本一致的Secoap协议实例
+func (c *Conn) NewSecoap() *Secoap {
+	s := NewSecoap(c.ver)
+	s.SetContext(c.ctx)
+	return s
+}
+
+// Send 编码并发送一个消息
+func (c *Conn) Send(s *Secoap) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	if !c.stream {
+		_, err = c.conn.Write(data)
+		return err
+	}
+	_, err = message.WriteFrame(c.conn, data)
+	return err
+}
+
+// Receive 接收并解码一个消息, 协议版本根据数据包自动识别
+func (c *Conn) Receive() (*Secoap, error) {
+	var data []byte
+	if c.stream {
+		frame, err := message.ReadFrameAutoDetect(c.conn)
+		if err != nil {
+			return nil, err
+		}
+		data = frame
+	} else {
+		n, err := c.conn.Read(c.buf)
+		if err != nil {
+			return nil, err
+		}
+		data = c.buf[:n]
+	}
+
+	s, _, err := NewSecoapFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	s.SetContext(c.ctx)
+	return s, nil
+}
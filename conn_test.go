@@ -0,0 +1,96 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnUDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	ctx := context.Background()
+	c, err := DialUDP(ctx, server.LocalAddr().String(), Version2)
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	req := newTestSecoap(t)
+	require.NoError(t, c.Send(req))
+
+	buf := make([]byte, 1500)
+	n, addr, err := server.ReadFrom(buf)
+	require.NoError(t, err)
+	got, err := ParseVersion2(buf[:n])
+	require.NoError(t, err)
+	require.Equal(t, req.Message.MessageID(), got.MessageID)
+
+	resp := NewSecoap(Version1)
+	resp.Message.SetType(secoapcore.Acknowledgement)
+	resp.Message.SetCode(secoapcore.Content)
+	resp.Message.SetMessageID(got.MessageID)
+	data, err := resp.Marshal()
+	require.NoError(t, err)
+	_, err = server.WriteTo(data, addr)
+	require.NoError(t, err)
+
+	recv, err := c.Receive()
+	require.NoError(t, err)
+	require.True(t, resp.Equal(recv), resp.Diff(recv))
+}
+
+func TestConnTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	ctx := context.Background()
+	done := make(chan error, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		server := NewConn(ctx, conn, Version2)
+		defer server.Close()
+		req, err := server.Receive()
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- server.Send(req)
+	}()
+
+	c, err := DialTCP(ctx, l.Addr().String(), Version2)
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	req := newTestSecoap(t)
+	require.NoError(t, c.Send(req))
+	recv, err := c.Receive()
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+	require.True(t, req.Equal(recv), req.Diff(recv))
+}
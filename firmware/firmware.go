@@ -0,0 +1,82 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmware 构建和解析固件更新通知 (GiterlabErrnoFirmwareUpdate)
+package firmware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrNotUpdateNotification 消息不是固件更新通知
+var ErrNotUpdateNotification = errors.New("firmware: not an update notification")
+
+// UpdateAvailable 固件更新通知的内容
+type UpdateAvailable struct {
+	Version     string `json:"version"`
+	URL         string `json:"url"`
+	Size        uint32 `json:"size"`
+	Checksum    uint32 `json:"checksum"` // 固件文件的CRC32
+	ForceUpdate bool   `json:"forceUpdate"`
+}
+
+// NewUpdateNotification 创建固件更新通知, 负载为 JSON 编码的 u, 并设置 CheckCRC32
+func NewUpdateNotification(u UpdateAvailable) (*message.Message, error) {
+	payload, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.NewMessage(context.Background())
+	msg.SetVersion(secoapcore.Version2)
+	msg.SetCode(secoapcore.GiterlabErrnoFirmwareUpdate)
+	msg.SetContentFormat(secoapcore.AppJSON)
+	if err := msg.SetPayloadBytes(payload); err != nil {
+		return nil, err
+	}
+	if err := msg.SetCheckCRC32FromBody(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ParseUpdateNotification 解析固件更新通知, 消息必须带有正确的 CheckCRC32 选项
+func ParseUpdateNotification(msg *message.Message) (*UpdateAvailable, error) {
+	if msg == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	if msg.Code() != secoapcore.GiterlabErrnoFirmwareUpdate {
+		return nil, fmt.Errorf("%w: code %v", ErrNotUpdateNotification, msg.Code())
+	}
+	if cf, err := msg.ContentFormat(); err == nil && cf != secoapcore.AppJSON {
+		return nil, fmt.Errorf("%w: content format %v", ErrNotUpdateNotification, cf)
+	}
+	if err := msg.VerifyCheckCRC32(); err != nil {
+		return nil, err
+	}
+	payload, err := msg.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	var u UpdateAvailable
+	if err := json.Unmarshal(payload, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
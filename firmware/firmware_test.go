@@ -0,0 +1,61 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmware
+
+import (
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateNotificationRoundTrip(t *testing.T) {
+	u := UpdateAvailable{
+		Version:     "1.2.3",
+		URL:         "coap://fw.example.com/firmware/1.2.3.bin",
+		Size:        204800,
+		Checksum:    0xDEADBEEF,
+		ForceUpdate: true,
+	}
+	msg, err := NewUpdateNotification(u)
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.Version2, msg.Version())
+	require.Equal(t, secoapcore.Code(secoapcore.GiterlabErrnoFirmwareUpdate), msg.Code())
+	cf, err := msg.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSON, cf)
+	require.True(t, msg.HasCheckCRC32())
+
+	got, err := ParseUpdateNotification(msg)
+	require.NoError(t, err)
+	require.Equal(t, u, *got)
+}
+
+func TestParseUpdateNotificationErrors(t *testing.T) {
+	msg, err := NewUpdateNotification(UpdateAvailable{Version: "1.0.0"})
+	require.NoError(t, err)
+
+	// 负载被篡改后 CRC32 校验失败
+	require.NoError(t, msg.SetPayloadBytes([]byte(`{"version":"6.6.6"}`)))
+	_, err = ParseUpdateNotification(msg)
+	require.ErrorIs(t, err, secoapcore.ErrCheckCRC32Mismatch)
+
+	msg.SetCode(secoapcore.GET)
+	_, err = ParseUpdateNotification(msg)
+	require.ErrorIs(t, err, ErrNotUpdateNotification)
+
+	_, err = ParseUpdateNotification(nil)
+	require.Error(t, err)
+}
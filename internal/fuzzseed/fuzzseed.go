@@ -0,0 +1,72 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fuzzseed builds seed corpora for the decoder fuzz targets.
+//
+// It must not import the message package, which imports the coders.
+package fuzzseed
+
+import (
+	"math/rand"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// Encoder is the subset of message.Encoder needed to build the seeds.
+type Encoder interface {
+	Size(m secoapcore.Message) (int, error)
+	Encode(m secoapcore.Message, buf []byte) (int, error)
+}
+
+// DecodeSeeds returns the seed corpus for the decoder fuzz targets: a valid
+// frame encoded by enc, 4 zero bytes, 256 random bytes and a frame whose
+// options section is truncated.
+func DecodeSeeds(enc Encoder, ver secoapcore.Ver) [][]byte {
+	m := secoapcore.Message{
+		Ver:       ver,
+		Token:     secoapcore.Token{0x01, 0x02, 0x03, 0x04},
+		Code:      secoapcore.POST,
+		MessageID: 0x1234,
+		Type:      secoapcore.Confirmable,
+		Opts: secoapcore.Options{
+			{ID: secoapcore.URIPath, Value: []byte("iotda")},
+			{ID: secoapcore.URIPath, Value: []byte("status")},
+			{ID: secoapcore.ContentFormat, Value: secoapcore.AppJSON},
+		},
+		Payload: []byte(`{"temperature":21.5}`),
+	}
+	valid := encode(enc, m)
+
+	m.Payload = nil
+	noPayload := encode(enc, m)
+	truncated := noPayload[:len(noPayload)-3]
+
+	random := make([]byte, 256)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	return [][]byte{valid, make([]byte, 4), random, truncated}
+}
+
+func encode(enc Encoder, m secoapcore.Message) []byte {
+	size, err := enc.Size(m)
+	if err != nil {
+		panic(err)
+	}
+	buf := make([]byte, size)
+	n, err := enc.Encode(m, buf)
+	if err != nil {
+		panic(err)
+	}
+	return buf[:n]
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides helpers for building test messages.
+//
+// It is internal to prevent accidental production use, all helpers panic on error.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// MsgOption configures a test message.
+type MsgOption func(m *message.Message)
+
+// NewV2Message creates a version 2 confirmable GET message with MessageID 1, then applies opts.
+func NewV2Message(opts ...MsgOption) *message.Message {
+	m := message.NewMessage(context.Background())
+	m.SetVersion(secoapcore.Version2)
+	m.SetType(secoapcore.Confirmable)
+	m.SetCode(secoapcore.GET)
+	m.SetMessageID(1)
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+func WithCode(c secoapcore.Code) MsgOption {
+	return func(m *message.Message) {
+		m.SetCode(c)
+	}
+}
+
+func WithType(typ secoapcore.Type) MsgOption {
+	return func(m *message.Message) {
+		m.SetType(typ)
+	}
+}
+
+func WithMessageID(mid int32) MsgOption {
+	return func(m *message.Message) {
+		m.SetMessageID(mid)
+	}
+}
+
+func WithToken(t secoapcore.Token) MsgOption {
+	return func(m *message.Message) {
+		m.SetToken(t)
+	}
+}
+
+func WithPath(p string) MsgOption {
+	return func(m *message.Message) {
+		m.MustSetPath(p)
+	}
+}
+
+func WithQuery(q string) MsgOption {
+	return func(m *message.Message) {
+		m.AddQuery(q)
+	}
+}
+
+// WithPayload sets the body and the content format.
+func WithPayload(cf secoapcore.MediaType, payload []byte) MsgOption {
+	return func(m *message.Message) {
+		m.SetContentFormat(cf)
+		m.SetBody(bytes.NewReader(payload))
+	}
+}
+
+// WithJSONPayload sets v encoded as JSON as the body with ContentFormat application/json.
+func WithJSONPayload(v interface{}) MsgOption {
+	return func(m *message.Message) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		WithPayload(secoapcore.AppJSON, data)(m)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package linkformat 解析和生成 RFC 6690 定义的 CoRE Link Format (application/link-format),
+// 用于 /.well-known/core 资源发现
+package linkformat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidLinkFormat 数据不符合 link-format 语法
+var ErrInvalidLinkFormat = errors.New("invalid link format")
+
+// Resource 一个链接, 即 link-value
+//
+// 没有值的属性 (如 obs) 记录为空字符串
+type Resource struct {
+	URI        string
+	Attributes map[string][]string
+}
+
+// Parse 解析 link-format 数据, 链接之间允许出现空白字符 (如换行)
+func Parse(data []byte) ([]Resource, error) {
+	p := parser{data: data}
+	p.skipSpace()
+	if p.eof() {
+		return nil, nil
+	}
+	var resources []Resource
+	for {
+		r, err := p.link()
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+		p.skipSpace()
+		if p.eof() {
+			return resources, nil
+		}
+		if p.data[p.pos] != ',' {
+			return nil, p.errorf("expected ',' but found %q", p.data[p.pos])
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+// Format 将资源编码为 link-format, 属性按名称排序, 值不是合法的 ptoken 时使用 quoted-string
+func Format(resources []Resource) []byte {
+	var buf bytes.Buffer
+	for i, r := range resources {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('<')
+		buf.WriteString(r.URI)
+		buf.WriteByte('>')
+		names := make([]string, 0, len(r.Attributes))
+		for name := range r.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range r.Attributes[name] {
+				buf.WriteByte(';')
+				buf.WriteString(name)
+				if v == "" {
+					continue
+				}
+				buf.WriteByte('=')
+				if isPtoken(v) {
+					buf.WriteString(v)
+					continue
+				}
+				buf.WriteByte('"')
+				for j := 0; j < len(v); j++ {
+					if v[j] == '"' || v[j] == '\\' {
+						buf.WriteByte('\\')
+					}
+					buf.WriteByte(v[j])
+				}
+				buf.WriteByte('"')
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidLinkFormat, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// link 解析 "<" URI-Reference ">" *( ";" link-param )
+func (p *parser) link() (Resource, error) {
+	if p.eof() || p.data[p.pos] != '<' {
+		return Resource{}, p.errorf("expected '<'")
+	}
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return Resource{}, p.errorf("unterminated URI")
+	}
+	r := Resource{
+		URI:        string(p.data[p.pos+1 : p.pos+end]),
+		Attributes: make(map[string][]string),
+	}
+	if strings.ContainsAny(r.URI, "<\"") {
+		return Resource{}, p.errorf("invalid URI %q", r.URI)
+	}
+	p.pos += end + 1
+	for {
+		p.skipSpace()
+		if p.eof() || p.data[p.pos] != ';' {
+			return r, nil
+		}
+		p.pos++
+		p.skipSpace()
+		name, value, err := p.param()
+		if err != nil {
+			return Resource{}, err
+		}
+		r.Attributes[name] = append(r.Attributes[name], value)
+	}
+}
+
+// param 解析 parmname [ "=" ( ptoken / quoted-string ) ]
+func (p *parser) param() (string, string, error) {
+	start := p.pos
+	for !p.eof() && isParmnameChar(p.data[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", "", p.errorf("expected parameter name")
+	}
+	name := string(p.data[start:p.pos])
+	p.skipSpace()
+	if p.eof() || p.data[p.pos] != '=' {
+		return name, "", nil
+	}
+	p.pos++
+	p.skipSpace()
+	if p.eof() {
+		return "", "", p.errorf("missing value of parameter %q", name)
+	}
+	if p.data[p.pos] == '"' {
+		value, err := p.quotedString()
+		return name, value, err
+	}
+	start = p.pos
+	for !p.eof() && isPtokenChar(p.data[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", "", p.errorf("missing value of parameter %q", name)
+	}
+	return name, string(p.data[start:p.pos]), nil
+}
+
+func (p *parser) quotedString() (string, error) {
+	start := p.pos
+	p.pos++ // 跳过 '"'
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated escape")
+			}
+			sb.WriteByte(p.data[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated quoted string")
+}
+
+// isParmnameChar RFC 5988: ALPHA / DIGIT / "!" / "#" / "$" / "&" / "+" / "-" / "." / "^" / "_" / "`" / "|" / "~"
+func isParmnameChar(c byte) bool {
+	return isAlnum(c) || strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
+// isPtokenChar RFC 5988: ALPHA / DIGIT / "!" / "#" / "$" / "%" / "&" / "'" / "(" / ")" / "*" / "+" / "-" / "." / "/" / ":" / "<" / "=" / ">" / "?" / "@" / "[" / "]" / "^" / "_" / "`" / "{" / "|" / "}" / "~"
+//
+// "<" "=" ">" 虽然合法, 但为避免歧义不作为未加引号的值
+func isPtokenChar(c byte) bool {
+	return isAlnum(c) || strings.IndexByte("!#$%&'()*+-./:?@[]^_`{|}~", c) >= 0
+}
+
+func isPtoken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isPtokenChar(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
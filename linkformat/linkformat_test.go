@@ -0,0 +1,86 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6690Example RFC 6690 §5 的示例
+const rfc6690Example = `</sensors/temp>;rt="temperature-c";if="sensor";obs,
+</sensors/light>;rt="light-lux";if="sensor",
+</sensors>;ct=40;title="Sensor Index",
+<http://www.example.com/sensors/t123>;anchor="/sensors/temp";rel="describedby",
+</t>;anchor="/sensors/temp";rel="alternate"`
+
+func TestParseRFC6690Example(t *testing.T) {
+	got, err := Parse([]byte(rfc6690Example))
+	require.NoError(t, err)
+	require.Equal(t, []Resource{
+		{URI: "/sensors/temp", Attributes: map[string][]string{"rt": {"temperature-c"}, "if": {"sensor"}, "obs": {""}}},
+		{URI: "/sensors/light", Attributes: map[string][]string{"rt": {"light-lux"}, "if": {"sensor"}}},
+		{URI: "/sensors", Attributes: map[string][]string{"ct": {"40"}, "title": {"Sensor Index"}}},
+		{URI: "http://www.example.com/sensors/t123", Attributes: map[string][]string{"anchor": {"/sensors/temp"}, "rel": {"describedby"}}},
+		{URI: "/t", Attributes: map[string][]string{"anchor": {"/sensors/temp"}, "rel": {"alternate"}}},
+	}, got)
+
+	again, err := Parse(Format(got))
+	require.NoError(t, err)
+	require.Equal(t, got, again)
+}
+
+func TestFormat(t *testing.T) {
+	data := Format([]Resource{
+		{URI: "/a", Attributes: map[string][]string{"rt": {"x", "y"}, "obs": {""}, "title": {`say "hi"`}}},
+		{URI: "/b"},
+	})
+	require.Equal(t, `</a>;obs;rt=x;rt=y;title="say \"hi\"",</b>`, string(data))
+
+	got, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, []string{`say "hi"`}, got[0].Attributes["title"])
+	require.Empty(t, Format(nil))
+}
+
+func TestParseEmpty(t *testing.T) {
+	got, err := Parse(nil)
+	require.NoError(t, err)
+	require.Empty(t, got)
+	got, err = Parse([]byte(" \n"))
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, in := range []string{
+		`/sensors`,
+		`</sensors`,
+		`</a>,`,
+		`</a>;`,
+		`</a>;rt=`,
+		`</a>;rt="open`,
+		`</a>;rt="x\`,
+		`</a>;=x`,
+		`</a> </b>`,
+		`</a>;rt=x y`,
+		`<</a>`,
+		`</a>,,</b>`,
+	} {
+		_, err := Parse([]byte(in))
+		require.ErrorIs(t, err, ErrInvalidLinkFormat, in)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lwm2m 编码和解码 OMA LightweightM2M 的 TLV 格式 (application/vnd.oma.lwm2m+tlv),
+// 见 OMA-TS-LightweightM2M §6.3.3
+package lwm2m
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidTLV   = errors.New("invalid lwm2m tlv")
+	ErrTLVTruncated = errors.New("lwm2m tlv truncated")
+)
+
+// TLVType TLV 的标识符类型, 类型字节的 bit 7-6
+type TLVType uint8
+
+const (
+	TLVObjectInstance   TLVType = 0 // TLVObjectInstance 对象实例, 包含资源
+	TLVResourceInstance TLVType = 1 // TLVResourceInstance 多实例资源中的一个实例
+	TLVMultipleResource TLVType = 2 // TLVMultipleResource 多实例资源, 包含资源实例
+	TLVResource         TLVType = 3 // TLVResource 带值的资源
+)
+
+// maxTLVLength 长度字段最多24位
+const maxTLVLength = 1<<24 - 1
+
+func (t TLVType) String() string {
+	switch t {
+	case TLVObjectInstance:
+		return "ObjectInstance"
+	case TLVResourceInstance:
+		return "ResourceInstance"
+	case TLVMultipleResource:
+		return "MultipleResource"
+	case TLVResource:
+		return "Resource"
+	}
+	return fmt.Sprintf("TLVType(%d)", uint8(t))
+}
+
+// hasChildren 返回该类型的值是否为嵌套的 TLV
+func (t TLVType) hasChildren() bool {
+	return t == TLVObjectInstance || t == TLVMultipleResource
+}
+
+// TLVRecord 一个 TLV 记录
+//
+// TLVObjectInstance 和 TLVMultipleResource 的内容为 Children, 其他类型的内容为 Value
+type TLVRecord struct {
+	Type     TLVType
+	ID       uint16
+	Value    []byte
+	Children []TLVRecord
+}
+
+// EncodeTLV 编码 TLV 记录
+func EncodeTLV(records []TLVRecord) ([]byte, error) {
+	return appendTLV(nil, records)
+}
+
+func appendTLV(buf []byte, records []TLVRecord) ([]byte, error) {
+	for _, r := range records {
+		if r.Type > TLVResource {
+			return nil, fmt.Errorf("%w: unknown type %d", ErrInvalidTLV, r.Type)
+		}
+		value := r.Value
+		if r.Type.hasChildren() {
+			if len(r.Value) > 0 {
+				return nil, fmt.Errorf("%w: %v %d must use Children instead of Value", ErrInvalidTLV, r.Type, r.ID)
+			}
+			var err error
+			if value, err = appendTLV(nil, r.Children); err != nil {
+				return nil, err
+			}
+		} else if len(r.Children) > 0 {
+			return nil, fmt.Errorf("%w: %v %d cannot have children", ErrInvalidTLV, r.Type, r.ID)
+		}
+		if len(value) > maxTLVLength {
+			return nil, fmt.Errorf("%w: value of %v %d is too long: %d", ErrInvalidTLV, r.Type, r.ID, len(value))
+		}
+
+		typ := byte(r.Type) << 6
+		if r.ID > 0xff {
+			typ |= 1 << 5
+		}
+		n := len(value)
+		switch {
+		case n < 8:
+			typ |= byte(n)
+		case n <= 0xff:
+			typ |= 1 << 3
+		case n <= 0xffff:
+			typ |= 2 << 3
+		default:
+			typ |= 3 << 3
+		}
+		buf = append(buf, typ)
+		if r.ID > 0xff {
+			buf = append(buf, byte(r.ID>>8))
+		}
+		buf = append(buf, byte(r.ID))
+		switch (typ >> 3) & 0x3 {
+		case 1:
+			buf = append(buf, byte(n))
+		case 2:
+			buf = append(buf, byte(n>>8), byte(n))
+		case 3:
+			buf = append(buf, byte(n>>16), byte(n>>8), byte(n))
+		}
+		buf = append(buf, value...)
+	}
+	return buf, nil
+}
+
+// DecodeTLV 解码 TLV 记录, 对象实例和多实例资源的内容解码到 Children
+//
+// 返回的 Value 引用 data 的内存
+func DecodeTLV(data []byte) ([]TLVRecord, error) {
+	return decodeTLV(data, 0)
+}
+
+// decodeTLV base 为 data 在最外层数据中的偏移, 用于错误信息
+func decodeTLV(data []byte, base int) ([]TLVRecord, error) {
+	var records []TLVRecord
+	for pos := 0; pos < len(data); {
+		start := pos
+		typ := data[pos]
+		pos++
+		r := TLVRecord{Type: TLVType(typ >> 6)}
+
+		idLen := 1
+		if typ&(1<<5) != 0 {
+			idLen = 2
+		}
+		lenLen := int(typ>>3) & 0x3
+		if len(data)-pos < idLen+lenLen {
+			return nil, fmt.Errorf("%w: header at offset %d needs %d bytes, have %d", ErrTLVTruncated, base+start, 1+idLen+lenLen, len(data)-start)
+		}
+		for i := 0; i < idLen; i++ {
+			r.ID = r.ID<<8 | uint16(data[pos])
+			pos++
+		}
+		n := int(typ & 0x7)
+		if lenLen > 0 {
+			n = 0
+			for i := 0; i < lenLen; i++ {
+				n = n<<8 | int(data[pos])
+				pos++
+			}
+		}
+		if len(data)-pos < n {
+			return nil, fmt.Errorf("%w: value of %v %d at offset %d needs %d bytes, have %d", ErrTLVTruncated, r.Type, r.ID, base+pos, n, len(data)-pos)
+		}
+		value := data[pos : pos+n : pos+n]
+		if r.Type.hasChildren() {
+			children, err := decodeTLV(value, base+pos)
+			if err != nil {
+				return nil, err
+			}
+			r.Children = children
+		} else {
+			r.Value = value
+		}
+		pos += n
+		records = append(records, r)
+	}
+	return records, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lwm2m
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLVSingleResource(t *testing.T) {
+	// Device 对象的 Battery Level (资源9) = 100
+	records := []TLVRecord{{Type: TLVResource, ID: 9, Value: []byte{100}}}
+	data, err := EncodeTLV(records)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xc1, 0x09, 0x64}, data)
+	got, err := DecodeTLV(data)
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+
+	// Manufacturer (资源0), 长度使用8位长度字段
+	records = []TLVRecord{{Type: TLVResource, ID: 0, Value: []byte("Open Mobile Alliance")}}
+	data, err = EncodeTLV(records)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{0xc8, 0x00, 0x14}, "Open Mobile Alliance"...), data)
+	got, err = DecodeTLV(data)
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+}
+
+func TestTLVMultipleResource(t *testing.T) {
+	// Available Power Sources (资源6): [0]=1, [1]=5
+	records := []TLVRecord{{
+		Type: TLVMultipleResource,
+		ID:   6,
+		Children: []TLVRecord{
+			{Type: TLVResourceInstance, ID: 0, Value: []byte{0x01}},
+			{Type: TLVResourceInstance, ID: 1, Value: []byte{0x05}},
+		},
+	}}
+	data, err := EncodeTLV(records)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x86, 0x06, 0x41, 0x00, 0x01, 0x41, 0x01, 0x05}, data)
+	got, err := DecodeTLV(data)
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+}
+
+func TestTLVObjectInstance(t *testing.T) {
+	records := []TLVRecord{
+		{
+			Type: TLVObjectInstance,
+			ID:   0,
+			Children: []TLVRecord{
+				{Type: TLVResource, ID: 0, Value: []byte("Open Mobile Alliance")},
+				{Type: TLVMultipleResource, ID: 6, Children: []TLVRecord{
+					{Type: TLVResourceInstance, ID: 0, Value: []byte{0x01}},
+				}},
+				{Type: TLVResource, ID: 300, Value: bytes.Repeat([]byte{0xaa}, 300)},
+			},
+		},
+		{Type: TLVObjectInstance, ID: 1},
+	}
+	data, err := EncodeTLV(records)
+	require.NoError(t, err)
+	// 对象实例 0, 8位ID, 16位长度: 23 + 5 + 305
+	require.Equal(t, []byte{0x10, 0x00, 0x01, 0x4d}, data[:4])
+	got, err := DecodeTLV(data)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, records[0], got[0])
+	require.Equal(t, TLVObjectInstance, got[1].Type)
+	require.Empty(t, got[1].Children)
+}
+
+func TestTLVInvalid(t *testing.T) {
+	_, err := EncodeTLV([]TLVRecord{{Type: TLVResource, ID: 1, Children: []TLVRecord{{Type: TLVResource}}}})
+	require.ErrorIs(t, err, ErrInvalidTLV)
+	_, err = EncodeTLV([]TLVRecord{{Type: TLVObjectInstance, Value: []byte{1}}})
+	require.ErrorIs(t, err, ErrInvalidTLV)
+	_, err = EncodeTLV([]TLVRecord{{Type: 4}})
+	require.ErrorIs(t, err, ErrInvalidTLV)
+}
+
+func TestTLVTruncated(t *testing.T) {
+	data, err := EncodeTLV([]TLVRecord{{
+		Type:     TLVObjectInstance,
+		ID:       3,
+		Children: []TLVRecord{{Type: TLVResource, ID: 0, Value: []byte("Open Mobile Alliance")}},
+	}})
+	require.NoError(t, err)
+	for _, n := range []int{1, 2, 5, len(data) - 1} {
+		_, err := DecodeTLV(data[:n])
+		require.ErrorIs(t, err, ErrTLVTruncated, "length %d", n)
+	}
+
+	// 外层长度正确, 内层记录被截断
+	_, err = DecodeTLV([]byte{0x03, 0x00, 0xc8, 0x00, 0x14})
+	require.ErrorIs(t, err, ErrTLVTruncated)
+	require.Contains(t, err.Error(), "offset 5")
+}
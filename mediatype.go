@@ -0,0 +1,32 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import "github.com/GiterLab/go-secoap/secoapcore"
+
+// RegisterMediaType 注册自定义的内容格式, 见 secoapcore.RegisterMediaType
+func RegisterMediaType(id secoapcore.MediaType, mimeString string) error {
+	return secoapcore.RegisterMediaType(id, mimeString)
+}
+
+// UnregisterMediaType 移除注册的内容格式, 见 secoapcore.UnregisterMediaType
+func UnregisterMediaType(id secoapcore.MediaType) {
+	secoapcore.UnregisterMediaType(id)
+}
+
+// RegisteredMediaTypes 返回所有已知的内容格式, 见 secoapcore.RegisteredMediaTypes
+func RegisteredMediaTypes() map[secoapcore.MediaType]string {
+	return secoapcore.RegisteredMediaTypes()
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newAnalyseMessage(t *testing.T) *Message {
+	m := NewMessage(context.Background())
+	m.SetVersion(secoapcore.Version2)
+	m.SetType(secoapcore.Confirmable)
+	m.SetMessageID(0x1234)
+	m.SetEncoderID(1)
+	m.SetEncoderType(6)
+	m.SetCode(secoapcore.POST)
+	m.SetToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, m.SetPath("/a"))
+	m.SetContentFormat(secoapcore.AppJSON)
+	m.SetBody(bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}))
+	data, err := m.MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalWithDecoder(coderv2.DefaultCoder, data)
+	require.NoError(t, err)
+	return got
+}
+
+func TestAnalyseV2(t *testing.T) {
+	m := newAnalyseMessage(t)
+	// the body has been consumed, Analyse must still show the payload
+	_, err := m.Body().Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+
+	want := "\n" + `    0                   1                   2                   3
+    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |V 2|TKL: 4 |T 0|EID: 1 |ETP: 6 |CRC16: 0xE7B4                  |
+   |1 0|0 1 0 0|0 0|0 0 0 1|0 1 1 0|1 1 1 0 0 1 1 1 1 0 1 1 0 1 0 0|
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |Message ID: 0x1234             |Code:   2      |RSUM8: 0x80    |
+   |0 0 0 1 0 0 1 0 0 0 1 1 0 1 0 0|0 0 0 0 0 0 1 0|1 0 0 0 0 0 0 0|
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |Token: (if any) ... HEX(4)
+   | 01 02 03 04
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |Options (if any) ... HEX
+   | Path: /a
+   |
+   | ID:URIPath(11) Value:a(61)
+   | ID:ContentFormat(12) Value:50(32)
+   | ` + `
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |SEP: 0xFF      |Payload: HEX(16)
+   |1 1 1 1 1 1 1 1| 00 01 02 03 04 05 06 07 08 09 0A 0B 0C 0D 0E 0F`
+	require.Equal(t, want, m.Analyse())
+
+	var sb strings.Builder
+	require.NoError(t, m.AnalyseWriter(&sb))
+	require.Equal(t, want, sb.String())
+
+	// the read position of the body is kept
+	pos, err := m.Body().Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, 16, pos)
+}
+
+func TestAnalyseWriterLargePayload(t *testing.T) {
+	m := newAnalyseMessage(t)
+	payload := bytes.Repeat([]byte{0xAB}, 3000)
+	m.SetBody(bytes.NewReader(payload))
+
+	var buf bytes.Buffer
+	require.NoError(t, m.AnalyseWriter(&buf))
+	out := buf.String()
+	require.Contains(t, out, "|Payload: HEX(3000)\n")
+	require.True(t, strings.HasSuffix(out, "| "+strings.TrimSpace(strings.Repeat("AB ", 3000))))
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// Builder builds a Message with chainable calls.
+//
+// The calls are recorded and applied to a new message by Build, so nothing is
+// allocated or modified before Build is called.
+type Builder struct {
+	ctx     context.Context
+	pending []func(r *Message) error
+}
+
+func NewBuilder(ctx context.Context) *Builder {
+	return &Builder{ctx: ctx}
+}
+
+func (b *Builder) add(f func(r *Message) error) *Builder {
+	b.pending = append(b.pending, f)
+	return b
+}
+
+func (b *Builder) Code(code secoapcore.Code) *Builder {
+	return b.add(func(r *Message) error {
+		r.SetCode(code)
+		return nil
+	})
+}
+
+func (b *Builder) Token(token secoapcore.Token) *Builder {
+	return b.add(func(r *Message) error {
+		if err := secoapcore.ValidateToken(token, false); err != nil {
+			return err
+		}
+		r.SetToken(token)
+		return nil
+	})
+}
+
+func (b *Builder) Path(path string) *Builder {
+	return b.add(func(r *Message) error {
+		return r.SetPath(path)
+	})
+}
+
+func (b *Builder) Query(query string) *Builder {
+	return b.add(func(r *Message) error {
+		return r.SetQuery(query)
+	})
+}
+
+func (b *Builder) Type(typ secoapcore.Type) *Builder {
+	return b.add(func(r *Message) error {
+		r.SetType(typ)
+		return nil
+	})
+}
+
+func (b *Builder) MessageID(mid int32) *Builder {
+	return b.add(func(r *Message) error {
+		if !secoapcore.ValidateMID(mid) {
+			return fmt.Errorf("invalid MessageID(%v)", mid)
+		}
+		r.SetMessageID(mid)
+		return nil
+	})
+}
+
+func (b *Builder) ContentFormat(contentFormat secoapcore.MediaType) *Builder {
+	return b.add(func(r *Message) error {
+		r.SetContentFormat(contentFormat)
+		return nil
+	})
+}
+
+func (b *Builder) Body(body io.ReadSeeker) *Builder {
+	return b.add(func(r *Message) error {
+		r.SetBody(body)
+		return nil
+	})
+}
+
+// Option sets the option, value must be a string, []byte, uint32, int or secoapcore.MediaType.
+func (b *Builder) Option(id secoapcore.OptionID, value interface{}) *Builder {
+	return b.add(func(r *Message) error {
+		switch v := value.(type) {
+		case string:
+			r.SetOptstring(id, v)
+		case []byte:
+			r.SetOptionBytes(id, v)
+		case uint32:
+			r.SetOptionUint32(id, v)
+		case secoapcore.MediaType:
+			r.SetOptionUint32(id, uint32(v))
+		case int:
+			if v < 0 || int64(v) > int64(^uint32(0)) {
+				return fmt.Errorf("option %v: value %d out of range", id, v)
+			}
+			r.SetOptionUint32(id, uint32(v))
+		default:
+			return fmt.Errorf("option %v: unsupported value type %T", id, value)
+		}
+		return nil
+	})
+}
+
+// Build creates the message and applies the recorded calls in order, the first error is returned.
+func (b *Builder) Build() (*Message, error) {
+	r := NewMessage(b.ctx)
+	for _, f := range b.pending {
+		if err := f(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MustBuild is like Build but panics on error, intended for tests.
+func (b *Builder) MustBuild() *Message {
+	r, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewBuilder(ctx).
+		Code(secoapcore.POST).
+		Type(secoapcore.Confirmable).
+		MessageID(0x1234).
+		Token(secoapcore.Token{0x01, 0x02}).
+		Path("/a/b").
+		Query("x=1&y=2").
+		ContentFormat(secoapcore.AppJSON).
+		Option(secoapcore.MaxAge, 60).
+		Option(secoapcore.ETag, []byte{0xAA}).
+		Body(bytes.NewReader([]byte(`{"a":1}`))).
+		Build()
+	require.NoError(t, err)
+
+	require.Equal(t, secoapcore.POST, r.Code())
+	require.Equal(t, secoapcore.Confirmable, r.Type())
+	require.Equal(t, int32(0x1234), r.MessageID())
+	require.Equal(t, secoapcore.Token{0x01, 0x02}, r.Token())
+	path, err := r.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/a/b", path)
+	queries, err := r.Queries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"x=1", "y=2"}, queries)
+	cf, err := r.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSON, cf)
+	maxAge, err := r.GetOptionUint32(secoapcore.MaxAge)
+	require.NoError(t, err)
+	require.Equal(t, uint32(60), maxAge)
+	body, err := r.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(body))
+}
+
+func TestBuilderError(t *testing.T) {
+	b := NewBuilder(context.Background()).
+		Code(secoapcore.GET).
+		MessageID(-2).
+		Option(secoapcore.MaxAge, 1.5)
+	_, err := b.Build()
+	require.EqualError(t, err, "invalid MessageID(-2)")
+	require.Panics(t, func() { b.MustBuild() })
+
+	_, err = NewBuilder(context.Background()).Option(secoapcore.MaxAge, 1.5).Build()
+	require.Error(t, err)
+	_, err = NewBuilder(context.Background()).Token(make(secoapcore.Token, 9)).Build()
+	require.ErrorIs(t, err, secoapcore.ErrTokenTooLong)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SetCheckCRC32FromBody 计算消息体的CRC32, 并设置到 CheckCRC32 选项
+func (r *Message) SetCheckCRC32FromBody() error {
+	body, err := r.PeekBody()
+	if err != nil {
+		return err
+	}
+	r.SetOptionUint32(secoapcore.CheckCRC32, secoapcore.CRC32Bytes(body))
+	return nil
+}
+
+// VerifyCheckCRC32 校验消息体的CRC32与 CheckCRC32 选项是否一致, 不一致时返回 ErrCheckCRC32Mismatch
+func (r *Message) VerifyCheckCRC32() error {
+	checksum, err := r.GetCheckCRC32()
+	if err != nil {
+		return err
+	}
+	body, err := r.PeekBody()
+	if err != nil {
+		return err
+	}
+	if !secoapcore.CRC32Verify(body, checksum) {
+		return fmt.Errorf("%w: 0x%08X", secoapcore.ErrCheckCRC32Mismatch, checksum)
+	}
+	return nil
+}
+
+func (r *Message) HasCheckCRC32() bool {
+	return r.HasOption(secoapcore.CheckCRC32)
+}
+
+func (r *Message) GetCheckCRC32() (uint32, error) {
+	return r.GetOptionUint32(secoapcore.CheckCRC32)
+}
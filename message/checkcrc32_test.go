@@ -0,0 +1,60 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCRC32(t *testing.T) {
+	msg := NewMessage(context.Background())
+	msg.SetCode(secoapcore.POST)
+	msg.SetType(secoapcore.Confirmable)
+	msg.SetMessageID(1)
+	require.NoError(t, msg.SetPayloadBytes([]byte("firmware chunk")))
+	require.False(t, msg.HasCheckCRC32())
+	_, err := msg.GetCheckCRC32()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	require.NoError(t, msg.SetCheckCRC32FromBody())
+	require.True(t, msg.HasCheckCRC32())
+	v, err := msg.GetCheckCRC32()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.CRC32Bytes([]byte("firmware chunk")), v)
+	require.NoError(t, msg.VerifyCheckCRC32())
+
+	data, err := msg.MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalWithDecoder(coderv2.DefaultCoder, data)
+	require.NoError(t, err)
+	// verification does not consume the body
+	body, err := io.ReadAll(got.Body())
+	require.NoError(t, err)
+	require.Equal(t, []byte("firmware chunk"), body)
+
+	msg.SetOptionUint32(secoapcore.CheckCRC32, v+1)
+	require.ErrorIs(t, msg.VerifyCheckCRC32(), secoapcore.ErrCheckCRC32Mismatch)
+	data, err = msg.MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	_, err = got.UnmarshalWithDecoder(coderv2.DefaultCoder, data)
+	require.ErrorIs(t, err, secoapcore.ErrCheckCRC32Mismatch)
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"crypto/subtle"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SetIfMatch inserts/replaces If-Match option(s).
+//
+// Option definition:
+// - format: opaque, length: 0-8, repeatable
+func (r *Message) SetIfMatch(etag []byte) error {
+	if !secoapcore.VerifyOptLen(secoapcore.CoapOptionDefs, secoapcore.IfMatch, len(etag)) {
+		return secoapcore.ErrInvalidValueLength
+	}
+	r.SetOptionBytes(secoapcore.IfMatch, etag)
+	return nil
+}
+
+// AddIfMatch appends etag to existing If-Match values.
+func (r *Message) AddIfMatch(etag []byte) error {
+	if !secoapcore.VerifyOptLen(secoapcore.CoapOptionDefs, secoapcore.IfMatch, len(etag)) {
+		return secoapcore.ErrInvalidValueLength
+	}
+	r.AddOptionBytes(secoapcore.IfMatch, etag)
+	return nil
+}
+
+// IfMatchValues returns all If-Match values
+//
+// Writes If-Match values to output array, returns number of written values or error.
+func (r *Message) IfMatchValues(buf [][]byte) (int, error) {
+	return r.GetOptionAllBytes(secoapcore.IfMatch, buf)
+}
+
+func (r *Message) RemoveIfMatch() {
+	r.Remove(secoapcore.IfMatch)
+}
+
+// SetIfNoneMatch sets the If-None-Match option, it is always empty.
+func (r *Message) SetIfNoneMatch() {
+	r.SetOptionBytes(secoapcore.IfNoneMatch, []byte{})
+}
+
+func (r *Message) HasIfNoneMatch() bool {
+	return r.msg.Opts.HasOption(secoapcore.IfNoneMatch)
+}
+
+func (r *Message) RemoveIfNoneMatch() {
+	r.Remove(secoapcore.IfNoneMatch)
+}
+
+// MatchesETag reports whether any If-Match value equals etag, the values are compared in constant time.
+func (r *Message) MatchesETag(etag []byte) bool {
+	return r.matchesOption(secoapcore.IfMatch, etag)
+}
+
+func (r *Message) matchesOption(id secoapcore.OptionID, etag []byte) bool {
+	match := false
+	for _, o := range r.msg.Opts {
+		if o.ID == id && subtle.ConstantTimeCompare(o.ToBytes(), etag) == 1 {
+			match = true
+		}
+	}
+	return match
+}
+
+// ConditionalCheck evaluates If-Match and If-None-Match against the ETag of the
+// stored resource (RFC7252 section 5.10.8), storedETag is nil if the resource does not exist.
+//
+// It returns PreconditionFailed if a condition is not fulfilled, Valid if an
+// ETag of the request matches storedETag, and Changed otherwise.
+func (r *Message) ConditionalCheck(storedETag []byte) (secoapcore.Code, error) {
+	if r.msg.Opts.HasOption(secoapcore.IfMatch) {
+		fulfilled := false
+		for _, o := range r.msg.Opts {
+			if o.ID != secoapcore.IfMatch {
+				continue
+			}
+			value := o.ToBytes()
+			// an empty If-Match matches any existing representation
+			if len(value) == 0 && storedETag != nil {
+				fulfilled = true
+			}
+		}
+		if storedETag != nil && r.MatchesETag(storedETag) {
+			fulfilled = true
+		}
+		if !fulfilled {
+			return secoapcore.PreconditionFailed, nil
+		}
+	}
+	if r.HasIfNoneMatch() && storedETag != nil {
+		return secoapcore.PreconditionFailed, nil
+	}
+	if storedETag != nil && r.matchesOption(secoapcore.ETag, storedETag) {
+		return secoapcore.Valid, nil
+	}
+	return secoapcore.Changed, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv1"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatch(t *testing.T) {
+	r := NewMessage(context.Background())
+	require.NoError(t, r.SetIfMatch([]byte{0x01}))
+	require.NoError(t, r.AddIfMatch([]byte{0x02, 0x03}))
+	require.NoError(t, r.AddIfMatch([]byte{}))
+	require.ErrorIs(t, r.AddIfMatch(make([]byte, 9)), secoapcore.ErrInvalidValueLength)
+
+	buf := make([][]byte, 4)
+	n, err := r.IfMatchValues(buf)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x01}, {0x02, 0x03}, {}}, buf[:n])
+	require.True(t, r.MatchesETag([]byte{0x02, 0x03}))
+	require.False(t, r.MatchesETag([]byte{0x02}))
+
+	require.NoError(t, r.SetIfMatch([]byte{0x04}))
+	n, err = r.IfMatchValues(buf)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x04}}, buf[:n])
+
+	r.RemoveIfMatch()
+	_, err = r.IfMatchValues(buf)
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	r := NewMessage(context.Background())
+	r.SetCode(secoapcore.PUT)
+	r.SetMessageID(1)
+	r.SetType(secoapcore.Confirmable)
+	require.False(t, r.HasIfNoneMatch())
+	r.SetIfNoneMatch()
+	require.True(t, r.HasIfNoneMatch())
+
+	// the empty option survives a round trip
+	data, err := r.MarshalWithEncoder(coderv1.DefaultCoder)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x50), data[4])
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalWithDecoder(coderv1.DefaultCoder, data)
+	require.NoError(t, err)
+	require.True(t, got.HasIfNoneMatch())
+
+	r.RemoveIfNoneMatch()
+	require.False(t, r.HasIfNoneMatch())
+}
+
+func TestConditionalCheck(t *testing.T) {
+	stored := []byte{0xAA, 0xBB}
+	tests := []struct {
+		name   string
+		setup  func(r *Message)
+		stored []byte
+		want   secoapcore.Code
+	}{
+		{name: "no conditions", setup: func(r *Message) {}, stored: stored, want: secoapcore.Changed},
+		{
+			name:   "if-match one of many",
+			setup:  func(r *Message) { _ = r.AddIfMatch([]byte{0x01}); _ = r.AddIfMatch(stored) },
+			stored: stored,
+			want:   secoapcore.Changed,
+		},
+		{
+			name:   "if-match mismatch",
+			setup:  func(r *Message) { _ = r.AddIfMatch([]byte{0x01}); _ = r.AddIfMatch([]byte{0x02}) },
+			stored: stored,
+			want:   secoapcore.PreconditionFailed,
+		},
+		{
+			name:   "empty if-match existing",
+			setup:  func(r *Message) { _ = r.SetIfMatch([]byte{}) },
+			stored: stored,
+			want:   secoapcore.Changed,
+		},
+		{
+			name:  "empty if-match missing",
+			setup: func(r *Message) { _ = r.SetIfMatch([]byte{}) },
+			want:  secoapcore.PreconditionFailed,
+		},
+		{
+			name:   "if-none-match existing",
+			setup:  func(r *Message) { r.SetIfNoneMatch() },
+			stored: stored,
+			want:   secoapcore.PreconditionFailed,
+		},
+		{
+			name:  "if-none-match missing",
+			setup: func(r *Message) { r.SetIfNoneMatch() },
+			want:  secoapcore.Changed,
+		},
+		{
+			name:   "etag valid",
+			setup:  func(r *Message) { _ = r.AddETag([]byte{0x01}); _ = r.AddETag(stored) },
+			stored: stored,
+			want:   secoapcore.Valid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewMessage(context.Background())
+			tt.setup(r)
+			code, err := r.ConditionalCheck(tt.stored)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, code)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"encoding/binary"
+	"hash"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// maxETagLen ETag 选项的最大长度
+const maxETagLen = 8
+
+// GenerateETagFromBody 使用消息体的CRC32 (4字节大端) 设置 ETag
+func (r *Message) GenerateETagFromBody() error {
+	etag, err := r.bodyCRC32ETag()
+	if err != nil {
+		return err
+	}
+	return r.SetETag(etag)
+}
+
+// GenerateETagFromBodyWithHasher 使用 h 计算消息体的摘要, 取前8字节设置 ETag
+func (r *Message) GenerateETagFromBodyWithHasher(h hash.Hash) error {
+	etag, err := r.bodyHashETag(h)
+	if err != nil {
+		return err
+	}
+	return r.SetETag(etag)
+}
+
+// ETagMatchesBody 检查 ETag 是否与 GenerateETagFromBody 根据当前消息体生成的值相同
+func (r *Message) ETagMatchesBody() (bool, error) {
+	etag, err := r.bodyCRC32ETag()
+	if err != nil {
+		return false, err
+	}
+	return r.etagMatches(etag)
+}
+
+// ETagMatchesBodyWithHasher 检查 ETag 是否与 GenerateETagFromBodyWithHasher 根据当前消息体生成的值相同
+func (r *Message) ETagMatchesBodyWithHasher(h hash.Hash) (bool, error) {
+	etag, err := r.bodyHashETag(h)
+	if err != nil {
+		return false, err
+	}
+	return r.etagMatches(etag)
+}
+
+// etagMatches 检查消息的某个 ETag 是否等于 etag, 没有 ETag 时返回 ErrOptionNotFound
+func (r *Message) etagMatches(etag []byte) (bool, error) {
+	if !r.HasOption(secoapcore.ETag) {
+		return false, secoapcore.ErrOptionNotFound
+	}
+	return r.matchesOption(secoapcore.ETag, etag), nil
+}
+
+func (r *Message) bodyCRC32ETag() ([]byte, error) {
+	body, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	etag := make([]byte, 4)
+	binary.BigEndian.PutUint32(etag, secoapcore.CRC32Bytes(body))
+	return etag, nil
+}
+
+func (r *Message) bodyHashETag(h hash.Hash) ([]byte, error) {
+	body, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	h.Reset()
+	h.Write(body)
+	etag := h.Sum(nil)
+	if len(etag) > maxETagLen {
+		etag = etag[:maxETagLen]
+	}
+	return etag, nil
+}
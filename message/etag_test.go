@@ -0,0 +1,78 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateETagFromBody(t *testing.T) {
+	msg := NewMessage(context.Background())
+	_, err := msg.ETagMatchesBody()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	require.NoError(t, msg.SetPayloadBytes([]byte("sensor data")))
+	require.NoError(t, msg.GenerateETagFromBody())
+	etag, err := msg.ETag()
+	require.NoError(t, err)
+	require.Len(t, etag, 4)
+	require.Equal(t, secoapcore.CRC32Bytes([]byte("sensor data")), binary.BigEndian.Uint32(etag))
+	ok, err := msg.ETagMatchesBody()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// same content, same ETag
+	other := NewMessage(context.Background())
+	require.NoError(t, other.SetPayloadBytes([]byte("sensor data")))
+	require.NoError(t, other.GenerateETagFromBody())
+	otherETag, err := other.ETag()
+	require.NoError(t, err)
+	require.Equal(t, etag, otherETag)
+
+	require.NoError(t, msg.SetPayloadBytes([]byte("changed")))
+	ok, err = msg.ETagMatchesBody()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestGenerateETagFromBodyWithHasher(t *testing.T) {
+	msg := NewMessage(context.Background())
+	require.NoError(t, msg.SetPayloadBytes([]byte("sensor data")))
+	h := sha256.New()
+	require.NoError(t, msg.GenerateETagFromBodyWithHasher(h))
+	etag, err := msg.ETag()
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("sensor data"))
+	require.Equal(t, sum[:8], etag)
+
+	// the hasher is reset before use
+	ok, err := msg.ETagMatchesBodyWithHasher(h)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = msg.ETagMatchesBody()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the body can still be read after generating the ETag
+	body, err := msg.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, []byte("sensor data"), body)
+}
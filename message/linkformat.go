@@ -0,0 +1,47 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/linkformat"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SetLinkFormatBody 将资源编码为 link-format 作为消息体, 并设置 ContentFormat 为 AppLinkFormat
+func (r *Message) SetLinkFormatBody(resources []linkformat.Resource) {
+	r.SetContentFormat(secoapcore.AppLinkFormat)
+	_ = r.SetPayloadBytes(linkformat.Format(resources))
+}
+
+// ParseLinkFormatBody 解析 link-format 消息体, 消息的 ContentFormat 必须为 AppLinkFormat
+func ParseLinkFormatBody(msg *Message) ([]linkformat.Resource, error) {
+	if msg == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	cf, err := msg.ContentFormat()
+	if err != nil {
+		return nil, err
+	}
+	if cf != secoapcore.AppLinkFormat {
+		return nil, fmt.Errorf("%w: content format %v", linkformat.ErrInvalidLinkFormat, cf)
+	}
+	body, err := msg.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	return linkformat.Parse(body)
+}
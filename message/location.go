@@ -0,0 +1,107 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SetLocationPath stores the given path within Location-Path opts.
+//
+// Existing Location-Path options are replaced and the internal buffer is
+// expanded when needed, like SetPath.
+func (r *Message) SetLocationPath(p string) error {
+	opts, used, err := r.msg.Opts.SetLocationPath(r.valueBuffer, p)
+	if errors.Is(err, secoapcore.ErrTooSmall) {
+		expandBy, errSize := secoapcore.GetPathBufferSize(p)
+		if errSize != nil {
+			return fmt.Errorf("cannot calculate buffer size for location path: %w", errSize)
+		}
+		r.valueBuffer = append(r.valueBuffer, make([]byte, expandBy)...)
+		opts, used, err = r.msg.Opts.SetLocationPath(r.valueBuffer, p)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set location path: %w", err)
+	}
+	r.msg.Opts = opts
+	r.valueBuffer = r.valueBuffer[used:]
+	r.isModified = true
+	return nil
+}
+
+// LocationPath joins the Location-Path options by '/'.
+func (r *Message) LocationPath() (string, error) {
+	return r.msg.Opts.LocationPath()
+}
+
+// AddLocationQuery appends a Location-Query option, like AddQuery.
+func (r *Message) AddLocationQuery(query string) {
+	r.AddOptstring(secoapcore.LocationQuery, query)
+}
+
+// LocationQueries returns the values of all Location-Query options.
+func (r *Message) LocationQueries() ([]string, error) {
+	return r.msg.Opts.LocationQueries()
+}
+
+// SetLocationPathAndQuery replaces the Location-Path and Location-Query options.
+//
+// Every query is checked before the message is modified, ErrInvalidValueLength
+// is returned when one of them exceeds the option length limit.
+func (r *Message) SetLocationPathAndQuery(path string, queries ...string) error {
+	for _, q := range queries {
+		if !secoapcore.VerifyOptLen(secoapcore.OptionDefs(), secoapcore.LocationQuery, len(q)) {
+			return fmt.Errorf("%w: location query has %d bytes", secoapcore.ErrInvalidValueLength, len(q))
+		}
+	}
+	if err := r.SetLocationPath(path); err != nil {
+		return err
+	}
+	r.Remove(secoapcore.LocationQuery)
+	for _, q := range queries {
+		r.AddLocationQuery(q)
+	}
+	return nil
+}
+
+// LocationURI combines the Location-Path and Location-Query options into a
+// relative URI such as "/sensors/1?rt=temp&if=core.s".
+//
+// The path defaults to "/" when only Location-Query options are present,
+// ErrOptionNotFound is returned when neither is present.
+func (r *Message) LocationURI() (string, error) {
+	path, err := r.LocationPath()
+	if err != nil && !errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return "", err
+	}
+	queries, err := r.LocationQueries()
+	if err != nil && !errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return "", err
+	}
+	if path == "" && len(queries) == 0 {
+		return "", secoapcore.ErrOptionNotFound
+	}
+	if path == "" {
+		path = "/"
+	}
+	if len(queries) == 0 {
+		return path, nil
+	}
+	return path + "?" + strings.Join(queries, "&"), nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import "context"
+
+// Logger 记录消息事件, event 如 "marshal", "unmarshal"
+type Logger interface {
+	LogMessage(ctx context.Context, event string, msg *Message)
+}
+
+// NopLogger 丢弃所有事件的 Logger
+type NopLogger struct{}
+
+func (NopLogger) LogMessage(context.Context, string, *Message) {}
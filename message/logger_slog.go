@@ -0,0 +1,70 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package message
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger 使用 log/slog 以结构化键值对记录消息事件
+type SlogLogger struct {
+	Logger *slog.Logger // nil 时使用 slog.Default()
+	Level  slog.Level   // 日志级别, 默认为 slog.LevelInfo
+}
+
+func NewSlogLogger(l *slog.Logger, level slog.Level) *SlogLogger {
+	return &SlogLogger{Logger: l, Level: level}
+}
+
+func (l *SlogLogger) LogMessage(ctx context.Context, event string, msg *Message) {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !logger.Enabled(ctx, l.Level) {
+		return
+	}
+	if msg == nil {
+		logger.LogAttrs(ctx, l.Level, event)
+		return
+	}
+	path, _ := msg.Path()
+	contentFormat := -1
+	if cf, err := msg.ContentFormat(); err == nil {
+		contentFormat = int(cf)
+	}
+	payloadLen, _ := msg.BodySize()
+	logger.LogAttrs(ctx, l.Level, event,
+		slog.String("version", msg.Version().String()),
+		slog.String("code", msg.Code().String()),
+		slog.String("type", msg.Type().String()),
+		slog.Int("messageID", int(msg.MessageID())),
+		slog.String("token", msg.Token().String()),
+		slog.String("path", path),
+		slog.Int("contentFormat", contentFormat),
+		slog.Int64("payloadLen", payloadLen),
+		slog.Int("encoderID", int(msg.EncoderID())),
+		slog.Int("encoderType", int(msg.EncoderType())),
+		slog.Uint64("sequence", msg.Sequence()),
+		slog.Bool("isModified", msg.IsModified()),
+		slog.Bool("isHijacked", msg.IsHijacked()),
+	)
+}
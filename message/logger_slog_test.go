@@ -0,0 +1,66 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), slog.LevelInfo)
+
+	msg := NewMessage(context.Background())
+	msg.SetVersion(secoapcore.Version2)
+	msg.SetCode(secoapcore.POST)
+	msg.SetType(secoapcore.Confirmable)
+	msg.SetMessageID(0x1234)
+	msg.SetToken(secoapcore.Token{0x01, 0x02})
+	require.NoError(t, msg.SetPath("/a/b"))
+	msg.SetContentFormat(secoapcore.AppJSON)
+	require.NoError(t, msg.SetPayloadBytes([]byte("{}")))
+	msg.SetSequence(7)
+	l.LogMessage(context.Background(), "marshal", msg)
+
+	var rec map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	require.Equal(t, "marshal", rec["msg"])
+	require.Equal(t, secoapcore.Version2.String(), rec["version"])
+	require.Equal(t, "POST", rec["code"])
+	require.Equal(t, secoapcore.Confirmable.String(), rec["type"])
+	require.Equal(t, float64(0x1234), rec["messageID"])
+	require.Equal(t, secoapcore.Token{0x01, 0x02}.String(), rec["token"])
+	require.Equal(t, "/a/b", rec["path"])
+	require.Equal(t, float64(secoapcore.AppJSON), rec["contentFormat"])
+	require.Equal(t, float64(2), rec["payloadLen"])
+	require.Equal(t, float64(7), rec["sequence"])
+	for _, key := range []string{"encoderID", "encoderType", "isModified", "isHijacked"} {
+		require.Contains(t, rec, key)
+	}
+
+	// disabled level
+	buf.Reset()
+	NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), slog.LevelDebug).LogMessage(context.Background(), "marshal", msg)
+	require.Zero(t, buf.Len())
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"github.com/GiterLab/go-secoap/lwm2m"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SetLwM2MTLVBody 将记录编码为 LwM2M TLV 作为消息体, 并设置 ContentFormat 为 AppLwm2mTLV
+func (r *Message) SetLwM2MTLVBody(records []lwm2m.TLVRecord) error {
+	data, err := lwm2m.EncodeTLV(records)
+	if err != nil {
+		return err
+	}
+	r.SetContentFormat(secoapcore.AppLwm2mTLV)
+	return r.SetPayloadBytes(data)
+}
+
+// LwM2MTLVBody 解码 LwM2M TLV 消息体
+func (r *Message) LwM2MTLVBody() ([]lwm2m.TLVRecord, error) {
+	body, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	return lwm2m.DecodeTLV(body)
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// DefaultMaxAge MaxAge 选项不存在时响应的缓存时间 (秒), RFC 7252 §5.10.5
+const DefaultMaxAge uint32 = 60
+
+// SetMaxAge 设置响应可被缓存的秒数, 0 表示不可缓存
+func (r *Message) SetMaxAge(seconds uint32) {
+	r.SetOptionUint32(secoapcore.MaxAge, seconds)
+}
+
+// MaxAge 返回 MaxAge 选项, 不存在时返回 ErrOptionNotFound
+func (r *Message) MaxAge() (uint32, error) {
+	return r.GetOptionUint32(secoapcore.MaxAge)
+}
+
+// ExpiresAt 返回在 receivedAt 收到的响应的过期时间
+//
+// MaxAge 选项不存在时返回 receivedAt + DefaultMaxAge 和 false
+func (r *Message) ExpiresAt(receivedAt time.Time) (time.Time, bool) {
+	maxAge, err := r.MaxAge()
+	if err != nil {
+		return receivedAt.Add(time.Duration(DefaultMaxAge) * time.Second), false
+	}
+	return receivedAt.Add(time.Duration(maxAge) * time.Second), true
+}
+
+// IsFresh 返回在 receivedAt 收到的响应在 now 时是否仍可使用, MaxAge 为0时总是过期
+func (r *Message) IsFresh(receivedAt, now time.Time) bool {
+	expires, _ := r.ExpiresAt(receivedAt)
+	return now.Before(expires)
+}
@@ -20,7 +20,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/GiterLab/go-secoap/secoapcore"
 	"github.com/hashicorp/go-multierror"
@@ -35,7 +37,16 @@ type Decoder interface {
 	Decode(buf []byte, m *secoapcore.Message) (int, error)
 }
 
+// Coder is implemented by every protocol version coder (coderv0, coderv1, coderv2).
+type Coder interface {
+	Encoder
+	Decoder
+}
+
 type Message struct {
+	// CreatedAt is set by NewMessage, it is used for the TTL of pending messages.
+	CreatedAt time.Time
+
 	// Context context of request.
 	ctx             context.Context
 	msg             secoapcore.Message
@@ -54,11 +65,20 @@ type Message struct {
 const valueBufferSize = 256
 
 func NewMessage(ctx context.Context) *Message {
-	valueBuffer := make([]byte, valueBufferSize)
+	return NewMessageWithBufferSize(ctx, valueBufferSize)
+}
+
+// NewMessageWithBufferSize 创建消息, size 为选项值缓冲区的初始大小, 缓冲区不足时会自动扩容
+func NewMessageWithBufferSize(ctx context.Context, size int) *Message {
+	if size <= 0 {
+		size = valueBufferSize
+	}
+	valueBuffer := make([]byte, size)
 	return &Message{
-		ctx: ctx,
+		CreatedAt: time.Now(),
+		ctx:       ctx,
 		msg: secoapcore.Message{
-			Opts:      make(secoapcore.Options, 0, 16),
+			Opts:      secoapcore.NewOptions(secoapcore.DefaultOptionsCapacity),
 			MessageID: -1,
 			Type:      secoapcore.Unset,
 		},
@@ -204,6 +224,8 @@ func (r *Message) Remove(opt secoapcore.OptionID) {
 	r.isModified = true
 }
 
+// Token returns a copy of the token, the copy is always independent of the
+// message and stays valid after the message is modified, reset or reused.
 func (r *Message) Token() secoapcore.Token {
 	if r.msg.Token == nil {
 		return nil
@@ -268,6 +290,29 @@ func (r *Message) SetPath(p string) error {
 	return nil
 }
 
+// SetQuery stores the given query ("a=1&b=2") within URI-Query opts.
+//
+// Existing URI-Query options are replaced and the internal buffer is
+// expanded when needed, like SetPath.
+func (r *Message) SetQuery(q string) error {
+	opts, used, err := r.msg.Opts.SetQuery(r.valueBuffer, q)
+	if errors.Is(err, secoapcore.ErrTooSmall) {
+		expandBy, errSize := secoapcore.GetQueryBufferSize(q)
+		if errSize != nil {
+			return fmt.Errorf("cannot calculate buffer size for query: %w", errSize)
+		}
+		r.valueBuffer = append(r.valueBuffer, make([]byte, expandBy)...)
+		opts, used, err = r.msg.Opts.SetQuery(r.valueBuffer, q)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set query: %w", err)
+	}
+	r.msg.Opts = opts
+	r.valueBuffer = r.valueBuffer[used:]
+	r.isModified = true
+	return nil
+}
+
 // MustSetPath calls SetPath and panics if it returns an error.
 func (r *Message) MustSetPath(p string) {
 	if err := r.SetPath(p); err != nil {
@@ -309,6 +354,23 @@ func (r *Message) SetETag(value []byte) error {
 	return nil
 }
 
+// SetOSCORE inserts/replaces the OSCORE option (RFC 8613).
+//
+// Option definition:
+// - format: opaque, length: 0-255, not repeatable
+func (r *Message) SetOSCORE(value []byte) error {
+	if !secoapcore.VerifyOptLen(secoapcore.CoapOptionDefs, secoapcore.OSCORE, len(value)) {
+		return secoapcore.ErrInvalidValueLength
+	}
+	r.SetOptionBytes(secoapcore.OSCORE, value)
+	return nil
+}
+
+// OSCORE returns the OSCORE option value.
+func (r *Message) OSCORE() ([]byte, error) {
+	return r.GetOptionBytes(secoapcore.OSCORE)
+}
+
 // ETag returns first ETag value
 func (r *Message) ETag() ([]byte, error) {
 	return r.GetOptionBytes(secoapcore.ETag)
@@ -438,6 +500,43 @@ func (r *Message) Observe() (uint32, error) {
 	return r.GetOptionUint32(secoapcore.Observe)
 }
 
+// SetBlock1 sets the Block1 option, szx must be 0-6.
+func (r *Message) SetBlock1(num uint32, more bool, szx uint8) error {
+	return r.setBlock(secoapcore.Block1, num, more, szx)
+}
+
+// Block1 gets the Block1 option.
+func (r *Message) Block1() (num, szx uint32, more bool, err error) {
+	return r.block(secoapcore.Block1)
+}
+
+// SetBlock2 sets the Block2 option, szx must be 0-6.
+func (r *Message) SetBlock2(num uint32, more bool, szx uint8) error {
+	return r.setBlock(secoapcore.Block2, num, more, szx)
+}
+
+// Block2 gets the Block2 option.
+func (r *Message) Block2() (num, szx uint32, more bool, err error) {
+	return r.block(secoapcore.Block2)
+}
+
+func (r *Message) setBlock(id secoapcore.OptionID, num uint32, more bool, szx uint8) error {
+	val, err := secoapcore.EncodeBlock(num, more, szx)
+	if err != nil {
+		return err
+	}
+	r.SetOptionUint32(id, val)
+	return nil
+}
+
+func (r *Message) block(id secoapcore.OptionID) (num, szx uint32, more bool, err error) {
+	val, err := r.GetOptionUint32(id)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return secoapcore.DecodeBlock(val)
+}
+
 // SetAccept set's accept option.
 func (r *Message) SetAccept(contentFormat secoapcore.MediaType) {
 	r.SetOptionUint32(secoapcore.Accept, uint32(contentFormat))
@@ -477,6 +576,16 @@ func (r *Message) SetBody(s io.ReadSeeker) {
 	r.isModified = true
 }
 
+// SetPayloadBytes sets the body from a byte slice, nil clears the body.
+func (r *Message) SetPayloadBytes(b []byte) error {
+	if b == nil {
+		r.SetBody(nil)
+		return nil
+	}
+	r.SetBody(bytes.NewReader(b))
+	return nil
+}
+
 func (r *Message) Body() io.ReadSeeker {
 	return r.body
 }
@@ -509,8 +618,89 @@ func (r *Message) String() string {
 	return r.msg.String()
 }
 
+// Analyse 协议分析, 分析前读取消息体以填充 Payload, 读取位置保持不变
 func (r *Message) Analyse() string {
-	return r.msg.Analyse()
+	var sb strings.Builder
+	if err := r.AnalyseWriter(&sb); err != nil {
+		return r.msg.Analyse()
+	}
+	return sb.String()
+}
+
+// AnalyseWriter 同 Analyse, 但将结果写入 w, 适用于较大的消息
+func (r *Message) AnalyseWriter(w io.Writer) error {
+	body, err := r.PeekBody()
+	if err != nil {
+		return err
+	}
+	m := r.msg
+	m.Payload = body
+	return m.AnalyseTo(w)
+}
+
+// DumpHex 以 Wireshark 兼容的格式输出消息的十六进制数据(每行16字节, 附带ASCII)
+//
+// The message is encoded into a scratch buffer by the coder registered for
+// r.Version() (see secoapcore.RegisterEncoder), the marshal buffer and the body
+// read position are not changed. If no coder is registered or encoding fails
+// the bytes of the last successful marshal are dumped.
+func (r *Message) DumpHex() string {
+	if encoder, ok := secoapcore.LookupEncoder(r.Version()); ok {
+		if data, err := r.encodeScratch(encoder); err == nil {
+			return dumpHex(data)
+		}
+	}
+	return dumpHex(r.bufferMarshal)
+}
+
+// encodeScratch encodes the message into a newly allocated buffer without reusing bufferMarshal.
+func (r *Message) encodeScratch(encoder Encoder) ([]byte, error) {
+	body, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	m := r.msg
+	m.Payload = body
+	size, err := encoder.Size(m)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := encoder.Encode(m, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// dumpHex formats data as "offset  hex bytes   ascii", 16 bytes per line.
+func dumpHex(data []byte) string {
+	var sb strings.Builder
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[offset:end]
+		fmt.Fprintf(&sb, "%04x  ", offset)
+		for i := 0; i < 16; i++ {
+			if i < len(line) {
+				fmt.Fprintf(&sb, "%02x ", line[i])
+			} else {
+				sb.WriteString("   ")
+			}
+		}
+		sb.WriteString("  ")
+		for _, b := range line {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
 }
 
 func (r *Message) ReadBody() ([]byte, error) {
@@ -542,6 +732,25 @@ func (r *Message) ReadBody() ([]byte, error) {
 	return payload[:n], nil
 }
 
+// PeekBody 同 ReadBody, 但读取后恢复消息体原来的读取位置
+func (r *Message) PeekBody() ([]byte, error) {
+	if r.Body() == nil {
+		return nil, nil
+	}
+	orig, err := r.Body().Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	body, err := r.ReadBody()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Body().Seek(orig, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
 func (r *Message) toMessage() (secoapcore.Message, error) {
 	payload, err := r.ReadBody()
 	if err != nil {
@@ -562,6 +771,44 @@ func (r *Message) ToSecoapCoreMessage() (secoapcore.Message, error) {
 	return m, nil
 }
 
+// MarshalJSON encodes the message to JSON, see secoapcore.Message.MarshalJSON.
+func (r *Message) MarshalJSON() ([]byte, error) {
+	msg, err := r.ToSecoapCoreMessage()
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalJSON()
+}
+
+// UnmarshalJSON decodes the message from JSON written by MarshalJSON.
+func (r *Message) UnmarshalJSON(data []byte) error {
+	var msg secoapcore.Message
+	if err := msg.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	r.SetMessage(msg)
+	return nil
+}
+
+// MarshalCBOR encodes the message to CBOR, see secoapcore.Message.MarshalCBOR.
+func (r *Message) MarshalCBOR() ([]byte, error) {
+	msg, err := r.ToSecoapCoreMessage()
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalCBOR()
+}
+
+// UnmarshalCBOR decodes the message from CBOR written by MarshalCBOR.
+func (r *Message) UnmarshalCBOR(data []byte) error {
+	var msg secoapcore.Message
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		return err
+	}
+	r.SetMessage(msg)
+	return nil
+}
+
 func (r *Message) MarshalWithEncoder(encoder Encoder) ([]byte, error) {
 	msg, err := r.toMessage()
 	if err != nil {
@@ -582,20 +829,32 @@ func (r *Message) MarshalWithEncoder(encoder Encoder) ([]byte, error) {
 	return r.bufferMarshal, nil
 }
 
+// MarshalSizeWithEncoder returns the number of bytes MarshalWithEncoder would produce.
+func (r *Message) MarshalSizeWithEncoder(encoder Encoder) (int, error) {
+	msg, err := r.toMessage()
+	if err != nil {
+		return 0, err
+	}
+	return encoder.Size(msg)
+}
+
 func (r *Message) decode(decoder Decoder) (int, error) {
 	var n int
 	var err error
+	// options of a previously decoded message must not be kept
+	r.msg.Opts = r.msg.Opts[:0]
 	for {
 		n, err = decoder.Decode(r.bufferUnmarshal, &r.msg)
 		if errors.Is(err, secoapcore.ErrOptionsTooSmall) {
 			// increase buffer size and try again
-			r.msg.Opts = make(secoapcore.Options, 0, len(r.msg.Opts)*2)
+			r.msg.Opts = secoapcore.NewOptions(len(r.msg.Opts) * 2)
 			continue
 		}
 		return n, err
 	}
 }
 
+// UnmarshalWithDecoder decodes data to the message, a CheckCRC32 option is verified against the body.
 func (r *Message) UnmarshalWithDecoder(decoder Decoder, data []byte) (int, error) {
 	if len(r.bufferUnmarshal) < len(data) {
 		r.bufferUnmarshal = append(r.bufferUnmarshal, make([]byte, len(data)-len(r.bufferUnmarshal))...)
@@ -610,7 +869,67 @@ func (r *Message) UnmarshalWithDecoder(decoder Decoder, data []byte) (int, error
 	if len(r.msg.Payload) > 0 {
 		r.body = bytes.NewReader(r.msg.Payload)
 	}
-	return n, err
+	if r.HasCheckCRC32() {
+		if err := r.VerifyCheckCRC32(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Equal reports whether both messages have the same header fields, options and body.
+func (r *Message) Equal(other *Message) bool {
+	return r.Diff(other) == ""
+}
+
+// Diff returns a human-readable description of the differences, empty when the messages are equal.
+func (r *Message) Diff(other *Message) string {
+	if r == nil || other == nil {
+		if r == other {
+			return ""
+		}
+		return fmt.Sprintf("Message: %v != %v", r, other)
+	}
+	var diffs []string
+	add := func(field string, a, b interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%s: %v != %v", field, a, b))
+	}
+	if r.Version() != other.Version() {
+		add("Version", r.Version(), other.Version())
+	}
+	if r.Type() != other.Type() {
+		add("Type", r.Type(), other.Type())
+	}
+	if r.Code() != other.Code() {
+		add("Code", r.Code(), other.Code())
+	}
+	if r.MessageID() != other.MessageID() {
+		add("MessageID", r.MessageID(), other.MessageID())
+	}
+	if !bytes.Equal(r.msg.Token, other.msg.Token) {
+		add("Token", r.msg.Token, other.msg.Token)
+	}
+	if r.EncoderID() != other.EncoderID() {
+		add("EncoderID", r.EncoderID(), other.EncoderID())
+	}
+	if r.EncoderType() != other.EncoderType() {
+		add("EncoderType", r.EncoderType(), other.EncoderType())
+	}
+	if !r.msg.Opts.Equal(other.msg.Opts) {
+		add("Options", "["+r.msg.Opts.String(", ")+"]", "["+other.msg.Opts.String(", ")+"]")
+	}
+	body, err := r.PeekBody()
+	if err != nil {
+		add("Body", err, "")
+	}
+	otherBody, err := other.PeekBody()
+	if err != nil {
+		add("Body", "", err)
+	}
+	if !bytes.Equal(body, otherBody) {
+		add("Body", fmt.Sprintf("% 02X", body), fmt.Sprintf("% 02X", otherBody))
+	}
+	return strings.Join(diffs, "\n")
 }
 
 func (r *Message) IsSeparateMessage() bool {
@@ -654,10 +973,54 @@ func (r *Message) SetupDelete(path string, token secoapcore.Token, opts ...secoa
 	return r.setupCommon(secoapcore.DELETE, path, token, opts...)
 }
 
+// SetupFetch 设置 FETCH 请求 (RFC 8132), payload 为查询条件
+func (r *Message) SetupFetch(path string, token secoapcore.Token, contentFormat secoapcore.MediaType, payload io.ReadSeeker, opts ...secoapcore.Option) error {
+	if err := r.setupCommon(secoapcore.FETCH, path, token, opts...); err != nil {
+		return err
+	}
+	if payload != nil {
+		r.SetContentFormat(contentFormat)
+		r.SetBody(payload)
+	}
+	return nil
+}
+
+// SetupPatch 设置 PATCH 请求 (RFC 8132)
+//
+// payload 不为 nil 时 contentFormat 必须为 AppJSONPatch 或 AppJSONMergePatch, 否则返回 ErrUnsupportedMediaType
+func (r *Message) SetupPatch(path string, token secoapcore.Token, contentFormat secoapcore.MediaType, payload io.ReadSeeker, opts ...secoapcore.Option) error {
+	return r.setupPatch(secoapcore.PATCH, path, token, contentFormat, payload, opts...)
+}
+
+// SetupIPatch 设置幂等的 iPATCH 请求 (RFC 8132), contentFormat 的要求同 SetupPatch
+func (r *Message) SetupIPatch(path string, token secoapcore.Token, contentFormat secoapcore.MediaType, payload io.ReadSeeker, opts ...secoapcore.Option) error {
+	return r.setupPatch(secoapcore.IPATCH, path, token, contentFormat, payload, opts...)
+}
+
+func (r *Message) setupPatch(code secoapcore.Code, path string, token secoapcore.Token, contentFormat secoapcore.MediaType, payload io.ReadSeeker, opts ...secoapcore.Option) error {
+	if err := r.setupCommon(code, path, token, opts...); err != nil {
+		return err
+	}
+	if payload != nil {
+		r.SetContentFormat(contentFormat)
+		if err := ValidatePatchContentFormat(r); err != nil {
+			return err
+		}
+		r.SetBody(payload)
+	}
+	return nil
+}
+
+// Clone copies the message to msg, the options and the body of msg do not share memory with r.
 func (r *Message) Clone(msg *Message) error {
 	msg.SetCode(r.Code())
 	msg.SetToken(r.Token())
-	msg.ResetOptsTo(r.Opts())
+	if len(r.msg.Opts) > 0 {
+		msg.msg.Opts = r.msg.Opts.DeepCopy()
+		msg.isModified = true
+	} else {
+		msg.msg.Opts = msg.msg.Opts[:0]
+	}
 	msg.SetType(r.Type())
 	msg.SetMessageID(r.MessageID())
 
@@ -0,0 +1,479 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/coder/coderv0"
+	"github.com/GiterLab/go-secoap/coder/coderv1"
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/linkformat"
+	"github.com/GiterLab/go-secoap/lwm2m"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/GiterLab/go-secoap/senml"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMessage(t *testing.T) *Message {
+	m := NewMessage(context.Background())
+	m.SetType(secoapcore.Confirmable)
+	m.SetMessageID(0x1234)
+	m.SetToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, m.SetupPost("/iotda/v3/device/status", m.Token(), secoapcore.AppJSON, bytes.NewReader([]byte(`{"a":1}`))))
+	return m
+}
+
+func TestUnmarshalWithDecoderVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		ver   secoapcore.Ver
+		coder Coder
+	}{
+		{
+			name:  "v0",
+			ver:   secoapcore.Version0,
+			coder: coderv0.DefaultCoder,
+		},
+		{
+			name:  "v1",
+			ver:   secoapcore.Version1,
+			coder: coderv1.DefaultCoder,
+		},
+		{
+			name:  "v2",
+			ver:   secoapcore.Version2,
+			coder: coderv2.DefaultCoder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := newTestMessage(t).MarshalWithEncoder(tt.coder)
+			require.NoError(t, err)
+
+			m := NewMessage(context.Background())
+			// a stale version must be overwritten by the decoder
+			m.SetVersion(secoapcore.Version1)
+			if tt.ver == secoapcore.Version1 {
+				m.SetVersion(secoapcore.Version2)
+			}
+			n, err := m.UnmarshalWithDecoder(tt.coder, data)
+			require.NoError(t, err)
+			require.Equal(t, len(data), n)
+			require.Equal(t, tt.ver, m.Version())
+		})
+	}
+}
+
+func TestSetPathAndQueryExpandBuffer(t *testing.T) {
+	segment := strings.Repeat("p", 200)
+	path := "/" + segment + "/" + segment + "/" + segment
+	query := strings.Repeat("a", 200) + "=1&" + strings.Repeat("b", 200) + "=2"
+
+	m := NewMessage(context.Background())
+	require.NoError(t, m.SetPath(path))
+	require.NoError(t, m.SetQuery("?"+query))
+
+	got, err := m.Path()
+	require.NoError(t, err)
+	require.Equal(t, path, got)
+	queries, err := m.Queries()
+	require.NoError(t, err)
+	require.Equal(t, strings.Split(query, "&"), queries)
+
+	// replace the queries
+	require.NoError(t, m.SetQuery("c=3"))
+	queries, err = m.Queries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"c=3"}, queries)
+
+	err = m.SetQuery(strings.Repeat("x", 256))
+	require.ErrorIs(t, err, secoapcore.ErrInvalidValueLength)
+}
+
+func TestLocationPathAndQuery(t *testing.T) {
+	m := NewMessage(context.Background())
+	_, err := m.LocationURI()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	// longer than a single option, split across segments
+	segment := strings.Repeat("l", 150)
+	path := "/" + segment + "/" + segment
+	require.NoError(t, m.SetLocationPath(path))
+	got, err := m.LocationPath()
+	require.NoError(t, err)
+	require.Equal(t, path, got)
+	require.False(t, m.HasOption(secoapcore.URIPath))
+
+	// path and queries together exceed the initial value buffer
+	queries := []string{strings.Repeat("q", 200) + "=1", "rt=temp"}
+	require.NoError(t, m.SetLocationPathAndQuery(path, queries...))
+	got, err = m.LocationPath()
+	require.NoError(t, err)
+	require.Equal(t, path, got)
+	gotQueries, err := m.LocationQueries()
+	require.NoError(t, err)
+	require.Equal(t, queries, gotQueries)
+	uri, err := m.LocationURI()
+	require.NoError(t, err)
+	require.Equal(t, path+"?"+strings.Join(queries, "&"), uri)
+
+	// queries are replaced, not appended
+	require.NoError(t, m.SetLocationPathAndQuery("/a/b", "if=core.s"))
+	uri, err = m.LocationURI()
+	require.NoError(t, err)
+	require.Equal(t, "/a/b?if=core.s", uri)
+
+	m.AddLocationQuery("ct=0")
+	gotQueries, err = m.LocationQueries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"if=core.s", "ct=0"}, gotQueries)
+
+	err = m.SetLocationPathAndQuery("/c", strings.Repeat("x", 256))
+	require.ErrorIs(t, err, secoapcore.ErrInvalidValueLength)
+	got, err = m.LocationPath()
+	require.NoError(t, err)
+	require.Equal(t, "/a/b", got)
+
+	m.Remove(secoapcore.LocationPath)
+	uri, err = m.LocationURI()
+	require.NoError(t, err)
+	require.Equal(t, "/?if=core.s&ct=0", uri)
+}
+
+func TestValidateCode(t *testing.T) {
+	r := newTestMessage(t)
+	require.Empty(t, r.Validate(secoapcore.Version1))
+
+	r.SetCode(secoapcore.Code(70))
+	errs := r.Validate(secoapcore.Version1)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], secoapcore.ErrMessageInvalidCode)
+}
+
+func TestBlockOptions(t *testing.T) {
+	r := newTestMessage(t)
+	_, _, _, err := r.Block2()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	require.NoError(t, r.SetBlock2(3, true, 6))
+	require.NoError(t, r.SetBlock1(0x12345, false, 2))
+	require.ErrorIs(t, r.SetBlock1(1, false, 7), secoapcore.ErrInvalidValueLength)
+
+	num, szx, more, err := r.Block2()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{uint32(3), uint32(6), true}, []interface{}{num, szx, more})
+	num, szx, more, err = r.Block1()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{uint32(0x12345), uint32(2), false}, []interface{}{num, szx, more})
+}
+
+func TestMessageJSON(t *testing.T) {
+	r := newTestMessage(t)
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	got := NewMessage(context.Background())
+	require.NoError(t, json.Unmarshal(data, got))
+	require.True(t, r.Equal(got), r.Diff(got))
+}
+
+func TestMessageCBOR(t *testing.T) {
+	for _, coder := range []Coder{coderv0.DefaultCoder, coderv1.DefaultCoder, coderv2.DefaultCoder} {
+		data, err := newTestMessage(t).MarshalWithEncoder(coder)
+		require.NoError(t, err)
+		r := NewMessage(context.Background())
+		_, err = r.UnmarshalWithDecoder(coder, data)
+		require.NoError(t, err)
+
+		b, err := r.MarshalCBOR()
+		require.NoError(t, err)
+		got := NewMessage(context.Background())
+		require.NoError(t, got.UnmarshalCBOR(b))
+		require.True(t, r.Equal(got), "%v: %s", r.Version(), r.Diff(got))
+
+		// the decoded message still encodes to the same wire bytes
+		wire, err := got.MarshalWithEncoder(coder)
+		require.NoError(t, err)
+		require.Equal(t, data, wire)
+	}
+}
+
+func TestMessageCloneIndependent(t *testing.T) {
+	msg := NewMessage(context.Background())
+	msg.SetCode(secoapcore.PUT)
+	require.NoError(t, msg.SetPath("/devices/d1"))
+	require.NoError(t, msg.SetETag([]byte{0x01, 0x02}))
+	require.NoError(t, msg.SetPayloadBytes([]byte("body")))
+
+	clone := NewMessage(context.Background())
+	require.NoError(t, msg.Clone(clone))
+
+	// overwrite the original option values and its value buffer
+	for _, o := range msg.Opts() {
+		if b, ok := o.Value.([]byte); ok {
+			for i := range b {
+				b[i] = 'x'
+			}
+		}
+	}
+	require.NoError(t, msg.SetPath("/other/path"))
+	msg.SetCode(secoapcore.GET)
+
+	path, err := clone.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/devices/d1", path)
+	etag, err := clone.ETag()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02}, etag)
+	require.Equal(t, secoapcore.PUT, clone.Code())
+	body, err := clone.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, []byte("body"), body)
+}
+
+func TestMessageTTL(t *testing.T) {
+	before := time.Now()
+	msg := NewMessage(context.Background())
+	require.False(t, msg.CreatedAt.Before(before))
+
+	now := msg.CreatedAt.Add(3 * time.Second)
+	require.False(t, msg.IsExpired(now, 5*time.Second))
+	require.Equal(t, 2*time.Second, msg.RemainingTTL(now, 5*time.Second))
+	require.True(t, msg.IsExpired(now, 2*time.Second))
+	require.Equal(t, -time.Second, msg.RemainingTTL(now, 2*time.Second))
+}
+
+func TestLinkFormatBody(t *testing.T) {
+	m := NewMessage(context.Background())
+	resources := []linkformat.Resource{
+		{URI: "/sensors/temp", Attributes: map[string][]string{"rt": {"temperature-c"}, "obs": {""}}},
+	}
+	m.SetLinkFormatBody(resources)
+	cf, err := m.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppLinkFormat, cf)
+	got, err := ParseLinkFormatBody(m)
+	require.NoError(t, err)
+	require.Equal(t, resources, got)
+
+	m.SetContentFormat(secoapcore.AppJSON)
+	_, err = ParseLinkFormatBody(m)
+	require.ErrorIs(t, err, linkformat.ErrInvalidLinkFormat)
+}
+
+func TestSenMLBody(t *testing.T) {
+	m := NewMessage(context.Background())
+	v := 23.5
+	records := []senml.Record{{BaseName: "urn:dev:mac:0024befffe804ff1:", Name: "temp", Unit: "Cel", Value: &v}}
+	require.NoError(t, m.SetSenMLBody(records))
+	cf, err := m.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppSenmlJSON, cf)
+	got, err := m.SenMLBody()
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+}
+
+func TestNegotiateContentFormat(t *testing.T) {
+	supported := []secoapcore.MediaType{secoapcore.AppJSON, secoapcore.AppCBOR, secoapcore.TextPlain}
+
+	m := NewMessage(context.Background())
+	cf, err := m.NegotiateContentFormat(supported)
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSON, cf)
+	require.True(t, m.SupportsContentFormat(secoapcore.AppXML))
+
+	m.SetAccept(secoapcore.AppCBOR)
+	cf, err = m.NegotiateContentFormat(supported)
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppCBOR, cf)
+	require.True(t, m.SupportsContentFormat(secoapcore.AppCBOR))
+	require.False(t, m.SupportsContentFormat(secoapcore.AppJSON))
+
+	// text/plain 的值为0, 编码为空选项
+	m.SetAccept(secoapcore.TextPlain)
+	cf, err = m.NegotiateContentFormat(supported)
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.TextPlain, cf)
+
+	m.SetAccept(secoapcore.AppXML)
+	_, err = m.NegotiateContentFormat(supported)
+	require.ErrorIs(t, err, ErrNotAcceptable)
+	_, err = m.NegotiateContentFormat(nil)
+	require.ErrorIs(t, err, ErrNotAcceptable)
+}
+
+func TestLwM2MTLVBody(t *testing.T) {
+	m := NewMessage(context.Background())
+	records := []lwm2m.TLVRecord{{Type: lwm2m.TLVResource, ID: 9, Value: []byte{100}}}
+	require.NoError(t, m.SetLwM2MTLVBody(records))
+	cf, err := m.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppLwm2mTLV, cf)
+	got, err := m.LwM2MTLVBody()
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+
+	require.Error(t, m.SetLwM2MTLVBody([]lwm2m.TLVRecord{{Type: 7}}))
+}
+
+func TestSetupPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		cf      secoapcore.MediaType
+		wantErr bool
+	}{
+		{name: "json patch", cf: secoapcore.AppJSONPatch},
+		{name: "merge patch", cf: secoapcore.AppJSONMergePatch},
+		{name: "json", cf: secoapcore.AppJSON, wantErr: true},
+		{name: "cbor", cf: secoapcore.AppCBOR, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `[{"op":"replace","path":"/a","value":1}]`
+			m := NewMessage(context.Background())
+			err := m.SetupPatch("/config", secoapcore.Token{0x01}, tt.cf, strings.NewReader(payload))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnsupportedMediaType)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, secoapcore.PATCH, m.Code())
+			cf, err := m.ContentFormat()
+			require.NoError(t, err)
+			require.Equal(t, tt.cf, cf)
+			body, err := m.ReadBody()
+			require.NoError(t, err)
+			require.Equal(t, payload, string(body))
+		})
+	}
+
+	// 没有负载时不设置 ContentFormat
+	m := NewMessage(context.Background())
+	require.NoError(t, m.SetupPatch("/config", nil, secoapcore.AppJSON, nil))
+	require.False(t, m.HasOption(secoapcore.ContentFormat))
+
+	m = NewMessage(context.Background())
+	require.NoError(t, m.SetupFetch("/sensors", nil, secoapcore.AppJSON, strings.NewReader(`{"q":1}`)))
+	require.Equal(t, secoapcore.FETCH, m.Code())
+	cf, err := m.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSON, cf)
+}
+
+func TestOSCOREOption(t *testing.T) {
+	m := newTestMessage(t)
+	_, err := m.OSCORE()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+	require.ErrorIs(t, m.SetOSCORE(make([]byte, 256)), secoapcore.ErrInvalidValueLength)
+	require.NoError(t, m.SetOSCORE([]byte{0x09, 0x14, 0x00}))
+
+	data, err := m.MarshalWithEncoder(coderv1.DefaultCoder)
+	require.NoError(t, err)
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalWithDecoder(coderv1.DefaultCoder, data)
+	require.NoError(t, err)
+	v, err := got.OSCORE()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x09, 0x14, 0x00}, v)
+	path, err := got.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/iotda/v3/device/status", path)
+}
+
+func TestMaxAge(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	m := NewMessage(context.Background())
+	_, err := m.MaxAge()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+	expires, ok := m.ExpiresAt(received)
+	require.False(t, ok)
+	require.Equal(t, received.Add(60*time.Second), expires)
+	require.True(t, m.IsFresh(received, received.Add(59*time.Second)))
+	require.False(t, m.IsFresh(received, received.Add(60*time.Second)))
+
+	m.SetMaxAge(0)
+	maxAge, err := m.MaxAge()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), maxAge)
+	expires, ok = m.ExpiresAt(received)
+	require.True(t, ok)
+	require.Equal(t, received, expires)
+	require.False(t, m.IsFresh(received, received))
+
+	m.SetMaxAge(3600)
+	expires, ok = m.ExpiresAt(received)
+	require.True(t, ok)
+	require.Equal(t, received.Add(time.Hour), expires)
+	require.True(t, m.IsFresh(received, received.Add(30*time.Minute)))
+	require.False(t, m.IsFresh(received, received.Add(2*time.Hour)))
+}
+
+func TestMessageEqualKeepsBodyOffset(t *testing.T) {
+	r := newTestMessage(t)
+	other := newTestMessage(t)
+	buf := make([]byte, 2)
+	_, err := io.ReadFull(r.Body(), buf)
+	require.NoError(t, err)
+
+	require.True(t, r.Equal(other), r.Diff(other))
+	require.Empty(t, r.Diff(other))
+
+	// 比较不改变两个消息体的读取位置
+	rest, err := io.ReadAll(r.Body())
+	require.NoError(t, err)
+	require.Equal(t, `a":1}`, string(rest))
+	all, err := io.ReadAll(other.Body())
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(all))
+}
+
+func TestMessageDumpHex(t *testing.T) {
+	r := newTestMessage(t)
+	data, err := r.MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	marshalled := append([]byte(nil), data...)
+
+	// 按消息自身的版本选择编码器
+	r.SetVersion(secoapcore.Version1)
+	dump := r.DumpHex()
+	v1, err := r.encodeScratch(coderv1.DefaultCoder)
+	require.NoError(t, err)
+	require.Equal(t, dumpHex(v1), dump)
+	require.True(t, strings.HasPrefix(dump, fmt.Sprintf("0000  %02x ", v1[0])))
+
+	r.SetVersion(secoapcore.Version2)
+	v2, err := r.encodeScratch(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	require.Equal(t, dumpHex(v2), r.DumpHex())
+
+	// 输出不修改编码缓冲区和消息体的读取位置
+	require.Equal(t, marshalled, data)
+	body, err := io.ReadAll(r.Body())
+	require.NoError(t, err)
+	require.Empty(t, body)
+
+	// 没有注册编码器的版本输出最近一次编码的数据
+	r.SetVersion(secoapcore.Version3)
+	require.Equal(t, dumpHex(marshalled), r.DumpHex())
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrNotAcceptable 请求的 Accept 选项与支持的格式都不匹配, 应答 secoapcore.NotAcceptable (4.06)
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// NegotiateContentFormat 根据请求的 Accept 选项选择响应格式, 返回 supportedFormats 中
+// 第一个被接受的格式, 请求没有 Accept 选项时返回 supportedFormats[0]
+func (r *Message) NegotiateContentFormat(supportedFormats []secoapcore.MediaType) (secoapcore.MediaType, error) {
+	if len(supportedFormats) == 0 {
+		return 0, fmt.Errorf("%w: no supported content format", ErrNotAcceptable)
+	}
+	accepts, err := r.accepts()
+	if err != nil {
+		return 0, err
+	}
+	if len(accepts) == 0 {
+		return supportedFormats[0], nil
+	}
+	for _, cf := range supportedFormats {
+		for _, a := range accepts {
+			if cf == a {
+				return cf, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: accept %v", ErrNotAcceptable, accepts)
+}
+
+// SupportsContentFormat 返回请求是否接受 cf 格式的响应, 没有 Accept 选项时接受任意格式
+func (r *Message) SupportsContentFormat(cf secoapcore.MediaType) bool {
+	accepts, err := r.accepts()
+	if err != nil {
+		return false
+	}
+	if len(accepts) == 0 {
+		return true
+	}
+	for _, a := range accepts {
+		if a == cf {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts 返回所有 Accept 选项的值, Accept 不可重复, 但对端可能发送多个
+func (r *Message) accepts() ([]secoapcore.MediaType, error) {
+	var accepts []secoapcore.MediaType
+	for _, o := range r.msg.Opts {
+		if o.ID != secoapcore.Accept {
+			continue
+		}
+		v, _, err := secoapcore.DecodeUint32(o.ToBytes())
+		if err != nil {
+			return nil, err
+		}
+		accepts = append(accepts, secoapcore.MediaType(v))
+	}
+	return accepts, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// NoResponse 选项的位掩码, 置位表示不需要对应类别的响应 (RFC 7967 §2.1)
+const (
+	NoResponseSuppressSuccess     uint8 = 0x02 // 2.xx
+	NoResponseSuppressClientError uint8 = 0x08 // 4.xx
+	NoResponseSuppressServerError uint8 = 0x10 // 5.xx
+	NoResponseSuppressAll         uint8 = NoResponseSuppressSuccess | NoResponseSuppressClientError | NoResponseSuppressServerError
+)
+
+// SetNoResponse 设置 NoResponse 选项, suppressCodes 为 NoResponseSuppress* 的组合, 0 表示需要所有响应
+func (r *Message) SetNoResponse(suppressCodes uint8) {
+	r.SetOptionUint32(secoapcore.NoResponse, uint32(suppressCodes))
+}
+
+// NoResponse 返回 NoResponse 选项的位掩码, 不存在时返回 ErrOptionNotFound
+func (r *Message) NoResponse() (uint8, error) {
+	v, err := r.GetOptionUint32(secoapcore.NoResponse)
+	if err != nil {
+		return 0, err
+	}
+	if v > 0xFF {
+		return 0, fmt.Errorf("%w: no-response value %d", secoapcore.ErrInvalidValueLength, v)
+	}
+	return uint8(v), nil
+}
+
+// IsNoResponseSet 判断是否设置了 NoResponse 选项
+func (r *Message) IsNoResponseSet() bool {
+	return r.HasOption(secoapcore.NoResponse)
+}
+
+// ShouldSuppressResponse 判断请求方是否不需要响应码为 code 的响应
+//
+// 响应类别 c 对应位掩码的第 c-1 位, GiterLab 的 6.xx 和 7.xx 对应 0x20 和 0x40.
+// 未设置 NoResponse 选项, 以及空消息和请求方法总是返回 false.
+func (r *Message) ShouldSuppressResponse(code secoapcore.Code) bool {
+	mask, err := r.NoResponse()
+	if err != nil {
+		return false
+	}
+	class := uint8(code) >> 5
+	if class == 0 {
+		return false
+	}
+	return mask&(1<<(class-1)) != 0
+}
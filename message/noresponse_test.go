@@ -0,0 +1,94 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoResponse(t *testing.T) {
+	m := NewMessage(context.Background())
+	require.False(t, m.IsNoResponseSet())
+	_, err := m.NoResponse()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+	require.False(t, m.ShouldSuppressResponse(secoapcore.Content))
+
+	m.SetNoResponse(NoResponseSuppressAll)
+	require.True(t, m.IsNoResponseSet())
+	v, err := m.NoResponse()
+	require.NoError(t, err)
+	require.Equal(t, uint8(0x1A), v)
+
+	// the option survives encoding
+	m.SetCode(secoapcore.POST)
+	m.SetMessageID(1)
+	m.SetType(secoapcore.NonConfirmable)
+	m.SetEncoderID(0)
+	m.SetEncoderType(0)
+	data, err := m.MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalWithDecoder(coderv2.DefaultCoder, data)
+	require.NoError(t, err)
+	v, err = got.NoResponse()
+	require.NoError(t, err)
+	require.Equal(t, NoResponseSuppressAll, v)
+}
+
+func TestShouldSuppressResponse(t *testing.T) {
+	success := []secoapcore.Code{secoapcore.Created, secoapcore.Changed, secoapcore.Content}
+	clientError := []secoapcore.Code{secoapcore.BadRequest, secoapcore.NotFound, secoapcore.TooManyRequests}
+	serverError := []secoapcore.Code{secoapcore.InternalServerError, secoapcore.ServiceUnavailable}
+	// never suppressed by the RFC 7967 bits
+	others := []secoapcore.Code{secoapcore.Empty, secoapcore.GET, secoapcore.GiterlabErrnoOk, secoapcore.GiterlabErrnoIllegalKey}
+
+	for mask := uint8(0); mask < 8; mask++ {
+		var suppress uint8
+		if mask&1 != 0 {
+			suppress |= NoResponseSuppressSuccess
+		}
+		if mask&2 != 0 {
+			suppress |= NoResponseSuppressClientError
+		}
+		if mask&4 != 0 {
+			suppress |= NoResponseSuppressServerError
+		}
+		m := NewMessage(context.Background())
+		m.SetNoResponse(suppress)
+		for _, code := range success {
+			require.Equal(t, mask&1 != 0, m.ShouldSuppressResponse(code), "mask 0x%02X, %v", suppress, code)
+		}
+		for _, code := range clientError {
+			require.Equal(t, mask&2 != 0, m.ShouldSuppressResponse(code), "mask 0x%02X, %v", suppress, code)
+		}
+		for _, code := range serverError {
+			require.Equal(t, mask&4 != 0, m.ShouldSuppressResponse(code), "mask 0x%02X, %v", suppress, code)
+		}
+		for _, code := range others {
+			require.False(t, m.ShouldSuppressResponse(code), "mask 0x%02X, %v", suppress, code)
+		}
+	}
+
+	// GiterLab classes follow the same bit rule
+	m := NewMessage(context.Background())
+	m.SetNoResponse(0x40)
+	require.True(t, m.ShouldSuppressResponse(secoapcore.GiterlabErrnoIllegalKey))
+	require.False(t, m.ShouldSuppressResponse(secoapcore.GiterlabErrnoOk))
+}
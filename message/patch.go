@@ -0,0 +1,51 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrUnsupportedMediaType PATCH/iPATCH 请求的负载格式不受支持, 对应响应码 secoapcore.UnsupportedMediaType (4.15)
+//
+// 可用 errors.Is 判断, secoapcore.CodeFromError 返回 secoapcore.UnsupportedMediaType.
+var ErrUnsupportedMediaType error = &secoapcore.CodeError{
+	Code:    secoapcore.UnsupportedMediaType,
+	Message: secoapcore.UnsupportedMediaType.String(),
+}
+
+// ValidatePatchContentFormat 检查 PATCH/iPATCH 请求的 ContentFormat 是否为 AppJSONPatch 或 AppJSONMergePatch
+//
+// 其他请求方法返回 nil, 缺少 ContentFormat 或格式不受支持时返回包装 ErrUnsupportedMediaType 的错误.
+func ValidatePatchContentFormat(msg *Message) error {
+	code := msg.Code()
+	if code != secoapcore.PATCH && code != secoapcore.IPATCH {
+		return nil
+	}
+	cf, err := msg.ContentFormat()
+	if errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return fmt.Errorf("%w: missing content format", ErrUnsupportedMediaType)
+	}
+	if err != nil {
+		return err
+	}
+	if cf != secoapcore.AppJSONPatch && cf != secoapcore.AppJSONMergePatch {
+		return fmt.Errorf("%w: %v", ErrUnsupportedMediaType, cf)
+	}
+	return nil
+}
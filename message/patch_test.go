@@ -0,0 +1,65 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupIPatch(t *testing.T) {
+	m := NewMessage(context.Background())
+	require.NoError(t, m.SetupIPatch("/config", secoapcore.Token{0x01}, secoapcore.AppJSONMergePatch, strings.NewReader(`{"a":1}`)))
+	require.Equal(t, secoapcore.IPATCH, m.Code())
+	cf, err := m.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.AppJSONMergePatch, cf)
+	body, err := m.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(body))
+
+	m = NewMessage(context.Background())
+	err = m.SetupIPatch("/config", nil, secoapcore.AppJSON, strings.NewReader(`{"a":1}`))
+	require.ErrorIs(t, err, ErrUnsupportedMediaType)
+	code, ok := secoapcore.CodeFromError(err)
+	require.True(t, ok)
+	require.Equal(t, secoapcore.UnsupportedMediaType, code)
+
+	// 没有负载时不设置 ContentFormat
+	m = NewMessage(context.Background())
+	require.NoError(t, m.SetupIPatch("/config", nil, secoapcore.AppJSON, nil))
+	require.False(t, m.HasOption(secoapcore.ContentFormat))
+}
+
+func TestValidatePatchContentFormat(t *testing.T) {
+	m := NewMessage(context.Background())
+	m.SetCode(secoapcore.POST)
+	require.NoError(t, ValidatePatchContentFormat(m))
+
+	m.SetCode(secoapcore.PATCH)
+	require.ErrorIs(t, ValidatePatchContentFormat(m), ErrUnsupportedMediaType)
+	m.SetContentFormat(secoapcore.TextPlain)
+	require.ErrorIs(t, ValidatePatchContentFormat(m), ErrUnsupportedMediaType)
+	m.SetContentFormat(secoapcore.AppJSONPatch)
+	require.NoError(t, ValidatePatchContentFormat(m))
+
+	m.SetCode(secoapcore.IPATCH)
+	m.SetContentFormat(secoapcore.AppJSONMergePatch)
+	require.NoError(t, ValidatePatchContentFormat(m))
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MessagePool recycles messages to reduce allocations on busy gateways.
+type MessagePool struct {
+	pool sync.Pool
+}
+
+// DefaultPool is used by GetMessage and PutMessage.
+var DefaultPool = NewMessagePool()
+
+func NewMessagePool() *MessagePool {
+	return &MessagePool{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return NewMessage(context.Background())
+			},
+		},
+	}
+}
+
+// Get returns a message from the pool or allocates a new one.
+func (p *MessagePool) Get(ctx context.Context) *Message {
+	r := p.pool.Get().(*Message)
+	r.SetContext(ctx)
+	r.CreatedAt = time.Now()
+	return r
+}
+
+// Put resets the message and returns it to the pool.
+//
+// Hijacked messages are still owned by the caller, so they are dropped.
+func (p *MessagePool) Put(r *Message) {
+	if r == nil || r.IsHijacked() {
+		return
+	}
+	r.Reset()
+	// fields kept by Reset, a recycled message must look like a new one
+	r.msg.Ver = 0
+	r.msg.EncoderID = 0
+	r.msg.EncoderType = 0
+	r.msg.Crc16 = 0
+	r.msg.Rsum8 = 0
+	r.valueBuffer = r.origValueBuffer[:cap(r.origValueBuffer)]
+	r.sequence = 0
+	r.ctx = nil
+	p.pool.Put(r)
+}
+
+// GetMessage returns a message from DefaultPool.
+func GetMessage(ctx context.Context) *Message {
+	return DefaultPool.Get(ctx)
+}
+
+// PutMessage returns a message to DefaultPool.
+func PutMessage(r *Message) {
+	DefaultPool.Put(r)
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagePool(t *testing.T) {
+	p := NewMessagePool()
+	ctx := context.Background()
+
+	r := p.Get(ctx)
+	r.SetVersion(secoapcore.Version2)
+	r.SetCode(secoapcore.POST)
+	r.SetToken(secoapcore.Token{0x01, 0x02})
+	r.SetMessageID(7)
+	r.SetEncoderID(1)
+	require.NoError(t, r.SetPath("/"+strings.Repeat("a", 200)+"/"+strings.Repeat("b", 200)))
+	require.NoError(t, r.SetPayloadBytes([]byte("stale")))
+	r.bufferMarshal = make([]byte, 2048)
+	p.Put(r)
+
+	// sync.Pool may drop objects, so check the reset state directly as well
+	for _, m := range []*Message{r, p.Get(ctx)} {
+		require.Nil(t, m.Token())
+		require.Equal(t, secoapcore.Empty, m.Code())
+		require.Empty(t, m.Opts())
+		require.Nil(t, m.Body())
+		require.Equal(t, secoapcore.Version0, m.Version())
+		require.Equal(t, int32(0), m.EncoderID())
+		require.Len(t, m.valueBuffer, valueBufferSize)
+		require.LessOrEqual(t, cap(m.bufferMarshal), 1024)
+	}
+
+	h := p.Get(ctx)
+	h.Hijack()
+	h.SetCode(secoapcore.GET)
+	p.Put(h)
+	require.Equal(t, secoapcore.GET, h.Code())
+}
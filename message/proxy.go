@@ -0,0 +1,119 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+var errProxyURINotAbsolute = errors.New("proxy uri is not an absolute uri")
+
+// ParseProxyURI parses the Proxy-Uri option.
+func (r *Message) ParseProxyURI() (*url.URL, error) {
+	s, err := r.msg.Opts.GetString(secoapcore.ProxyURI)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(s)
+}
+
+// SetProxyURIFromParts builds an absolute URI and sets it as the Proxy-Uri option.
+//
+// port 0 is omitted, the queries are sorted by key. ErrInvalidValueLength is
+// returned when the URI exceeds the option length limit.
+func (r *Message) SetProxyURIFromParts(scheme, host string, port uint16, path string, queries map[string]string) error {
+	if scheme == "" || host == "" {
+		return fmt.Errorf("%w: scheme and host are required", errProxyURINotAbsolute)
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path,
+	}
+	if port != 0 {
+		u.Host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		u.Path = "/" + path
+	}
+	if len(queries) > 0 {
+		values := make(url.Values, len(queries))
+		for k, v := range queries {
+			values.Set(k, v)
+		}
+		u.RawQuery = values.Encode()
+	}
+	s := u.String()
+	if !secoapcore.VerifyOptLen(secoapcore.CoapOptionDefs, secoapcore.ProxyURI, len(s)) {
+		return fmt.Errorf("%w: proxy uri has %d bytes", secoapcore.ErrInvalidValueLength, len(s))
+	}
+	r.SetOptstring(secoapcore.ProxyURI, s)
+	return nil
+}
+
+// ProxyURIHost returns the host of the Proxy-Uri option without the port, like url.URL.Hostname.
+func (r *Message) ProxyURIHost() (string, error) {
+	authority, _, err := r.splitProxyURI()
+	if err != nil {
+		return "", err
+	}
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		authority = authority[i+1:]
+	}
+	if strings.HasPrefix(authority, "[") {
+		if i := strings.IndexByte(authority, ']'); i > 0 {
+			return authority[1:i], nil
+		}
+		return "", fmt.Errorf("%w: invalid host %q", errProxyURINotAbsolute, authority)
+	}
+	if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		authority = authority[:i]
+	}
+	return authority, nil
+}
+
+// ProxyURIPath returns the escaped path of the Proxy-Uri option.
+func (r *Message) ProxyURIPath() (string, error) {
+	_, path, err := r.splitProxyURI()
+	return path, err
+}
+
+// splitProxyURI splits scheme://authority/path?query#fragment without a full parse.
+func (r *Message) splitProxyURI() (authority, path string, err error) {
+	s, err := r.msg.Opts.GetString(secoapcore.ProxyURI)
+	if err != nil {
+		return "", "", err
+	}
+	i := strings.Index(s, "://")
+	if i <= 0 {
+		return "", "", errProxyURINotAbsolute
+	}
+	rest := s[i+3:]
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	authority = rest
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	return authority, path, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyURI(t *testing.T) {
+	msg := NewMessage(context.Background())
+	_, err := msg.ParseProxyURI()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+	_, err = msg.ProxyURIHost()
+	require.ErrorIs(t, err, secoapcore.ErrOptionNotFound)
+
+	require.NoError(t, msg.SetProxyURIFromParts("coap", "example.com", 5683, "sensors/temp",
+		map[string]string{"unit": "C", "a b": "1"}))
+	s, err := msg.msg.Opts.GetString(secoapcore.ProxyURI)
+	require.NoError(t, err)
+	require.Equal(t, "coap://example.com:5683/sensors/temp?a+b=1&unit=C", s)
+
+	u, err := msg.ParseProxyURI()
+	require.NoError(t, err)
+	require.Equal(t, "coap", u.Scheme)
+	require.Equal(t, "example.com", u.Hostname())
+	require.Equal(t, "5683", u.Port())
+	require.Equal(t, "/sensors/temp", u.Path)
+	require.Equal(t, "C", u.Query().Get("unit"))
+
+	host, err := msg.ProxyURIHost()
+	require.NoError(t, err)
+	require.Equal(t, "example.com", host)
+	path, err := msg.ProxyURIPath()
+	require.NoError(t, err)
+	require.Equal(t, "/sensors/temp", path)
+}
+
+func TestProxyURIAccessors(t *testing.T) {
+	tests := []struct {
+		uri  string
+		host string
+		path string
+	}{
+		{"coap://[2001:db8::1]:5683/a/b", "2001:db8::1", "/a/b"},
+		{"coaps://user@host", "host", ""},
+		{"http://host:80?x=/y", "host", ""},
+		{"coap://host/a%20b#frag", "host", "/a%20b"},
+	}
+	for _, tt := range tests {
+		msg := NewMessage(context.Background())
+		msg.SetOptstring(secoapcore.ProxyURI, tt.uri)
+		host, err := msg.ProxyURIHost()
+		require.NoError(t, err, tt.uri)
+		require.Equal(t, tt.host, host, tt.uri)
+		path, err := msg.ProxyURIPath()
+		require.NoError(t, err, tt.uri)
+		require.Equal(t, tt.path, path, tt.uri)
+
+		u, err := msg.ParseProxyURI()
+		require.NoError(t, err)
+		require.Equal(t, u.Hostname(), host)
+		require.Equal(t, u.EscapedPath(), path)
+	}
+
+	msg := NewMessage(context.Background())
+	msg.SetOptstring(secoapcore.ProxyURI, "/relative")
+	_, err := msg.ProxyURIHost()
+	require.Error(t, err)
+}
+
+func TestSetProxyURIFromPartsTooLong(t *testing.T) {
+	msg := NewMessage(context.Background())
+	err := msg.SetProxyURIFromParts("coap", "example.com", 0, "/"+strings.Repeat("a", 1034), nil)
+	require.ErrorIs(t, err, secoapcore.ErrInvalidValueLength)
+	require.False(t, msg.HasOption(secoapcore.ProxyURI))
+
+	require.Error(t, msg.SetProxyURIFromParts("", "example.com", 0, "/", nil))
+	require.NoError(t, msg.SetProxyURIFromParts("coap", "example.com", 0, "", nil))
+	u, err := msg.ParseProxyURI()
+	require.NoError(t, err)
+	require.Equal(t, "coap://example.com", u.String())
+}
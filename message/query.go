@@ -0,0 +1,92 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// QueryParams parses the URI-Query options as key=value pairs.
+//
+// Each option is split on the first '=' and both parts are decoded like
+// url.ParseQuery does, a bare key has an empty value. No query options
+// results in an empty map.
+func (r *Message) QueryParams() (map[string][]string, error) {
+	params := make(map[string][]string)
+	queries, err := r.Queries()
+	if errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return params, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queries {
+		key, value, err := parseQueryParam(q)
+		if err != nil {
+			return nil, err
+		}
+		params[key] = append(params[key], value)
+	}
+	return params, nil
+}
+
+// QueryParam returns the first value of the query parameter key.
+func (r *Message) QueryParam(key string) (string, bool) {
+	queries, err := r.Queries()
+	if err != nil {
+		return "", false
+	}
+	for _, q := range queries {
+		k, v, err := parseQueryParam(q)
+		if err == nil && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SetQueryParam replaces all query parameters named key with key=value.
+func (r *Message) SetQueryParam(key, value string) {
+	queries, err := r.Queries()
+	if err == nil {
+		r.Remove(secoapcore.URIQuery)
+		for _, q := range queries {
+			if k, _, err := parseQueryParam(q); err != nil || k != key {
+				r.AddQuery(q)
+			}
+		}
+	}
+	r.AddQueryParam(key, value)
+}
+
+// AddQueryParam appends the query parameter key=value, both parts are escaped.
+func (r *Message) AddQueryParam(key, value string) {
+	r.AddQuery(url.QueryEscape(key) + "=" + url.QueryEscape(value))
+}
+
+func parseQueryParam(q string) (key, value string, err error) {
+	key, value, _ = strings.Cut(q, "=")
+	if key, err = url.QueryUnescape(key); err != nil {
+		return "", "", err
+	}
+	if value, err = url.QueryUnescape(value); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryParams(t *testing.T) {
+	tests := [][]string{
+		nil,
+		{"a=1"},
+		{"a=1", "b=2", "a=3"},
+		{"flag"},
+		{"empty="},
+		{"=value"},
+		{"k%3Dx=v"},
+		{"name=a%20b+c", "x=%E4%BD%A0"},
+		{"eq=a=b"},
+	}
+	for _, queries := range tests {
+		t.Run(strings.Join(queries, "&"), func(t *testing.T) {
+			msg := NewMessage(context.Background())
+			for _, q := range queries {
+				msg.AddQuery(q)
+			}
+			got, err := msg.QueryParams()
+			require.NoError(t, err)
+			want, err := url.ParseQuery(strings.Join(queries, "&"))
+			require.NoError(t, err)
+			require.Equal(t, map[string][]string(want), got)
+		})
+	}
+
+	msg := NewMessage(context.Background())
+	msg.AddQuery("bad=%zz")
+	_, err := msg.QueryParams()
+	require.Error(t, err)
+}
+
+func TestQueryParam(t *testing.T) {
+	msg := NewMessage(context.Background())
+	_, ok := msg.QueryParam("a")
+	require.False(t, ok)
+
+	msg.AddQuery("a=1")
+	msg.AddQuery("flag")
+	msg.AddQueryParam("a", "2")
+	msg.AddQueryParam("k=x", "v&w")
+
+	v, ok := msg.QueryParam("a")
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+	v, ok = msg.QueryParam("flag")
+	require.True(t, ok)
+	require.Equal(t, "", v)
+	v, ok = msg.QueryParam("k=x")
+	require.True(t, ok)
+	require.Equal(t, "v&w", v)
+
+	msg.SetQueryParam("a", "3")
+	params, err := msg.QueryParams()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"a":    {"3"},
+		"flag": {""},
+		"k=x":  {"v&w"},
+	}, params)
+
+	empty := NewMessage(context.Background())
+	empty.SetQueryParam("a", "")
+	queries, err := empty.Queries()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a="}, queries)
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// FrameReader is implemented by readers which know the frame boundaries of
+// their transport, UnmarshalFromReader uses it instead of the length prefix.
+type FrameReader interface {
+	ReadFrame() ([]byte, error)
+}
+
+// framePrefixSize 流传输时每个消息前的长度前缀大小 (大端)
+const framePrefixSize = 2
+
+// headerSize returns the fixed header size of the version.
+func headerSize(ver secoapcore.Ver) (int, error) {
+	switch ver {
+	case secoapcore.Version0, secoapcore.Version1:
+		return 4, nil
+	case secoapcore.Version2:
+		return 8, nil
+	}
+	return -1, secoapcore.ErrMessageInvalidVersion
+}
+
+// ReadFrame reads one frame of the given version from a stream and returns it without decoding.
+//
+// The secoap headers carry no length field, so on stream transports every
+// frame is preceded by a 2-byte big-endian length. The fixed header is read
+// and checked first, then the rest of the frame is read at once.
+func ReadFrame(r io.Reader, ver secoapcore.Ver) ([]byte, error) {
+	return readFrame(r, &ver)
+}
+
+// ReadFrameAutoDetect is like ReadFrame, but the version is taken from the frame header.
+func ReadFrameAutoDetect(r io.Reader) ([]byte, error) {
+	return readFrame(r, nil)
+}
+
+// readFrame reads one length-prefixed frame, want is the expected version or nil to accept any version.
+func readFrame(r io.Reader, want *secoapcore.Ver) ([]byte, error) {
+	var prefix [framePrefixSize]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint16(prefix[:]))
+	minSize := 1
+	if want != nil {
+		hdrSize, err := headerSize(*want)
+		if err != nil {
+			return nil, err
+		}
+		minSize = hdrSize
+	}
+	if size < minSize {
+		return nil, secoapcore.ErrMessageTruncated
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame[:1]); err != nil {
+		return nil, err
+	}
+	ver := secoapcore.Ver(frame[0] >> 6)
+	if want != nil && ver != *want {
+		return nil, secoapcore.ErrMessageInvalidVersion
+	}
+	hdrSize, err := headerSize(ver)
+	if err != nil {
+		return nil, err
+	}
+	if size < hdrSize {
+		return nil, secoapcore.ErrMessageTruncated
+	}
+	if _, err := io.ReadFull(r, frame[1:hdrSize]); err != nil {
+		return nil, err
+	}
+	tokenLen := 0
+	switch ver {
+	case secoapcore.Version1:
+		tokenLen = int(frame[0] & 0xf)
+	case secoapcore.Version2:
+		tokenLen = int((frame[0] >> 2) & 0xf)
+	}
+	if tokenLen > secoapcore.MaxTokenSize {
+		return nil, secoapcore.ErrTokenTooLong
+	}
+	if size < hdrSize+tokenLen {
+		return nil, secoapcore.ErrMessageTruncated
+	}
+	if _, err := io.ReadFull(r, frame[hdrSize:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// WriteFrame writes data to a stream with the length prefix expected by ReadFrame.
+func WriteFrame(w io.Writer, data []byte) (int, error) {
+	if len(data) > 0xffff {
+		return 0, fmt.Errorf("message too large for stream framing: %d", len(data))
+	}
+	frame := make([]byte, framePrefixSize+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[framePrefixSize:], data)
+	return w.Write(frame)
+}
+
+// readDeadliner is implemented by net.Conn and other readers whose blocked reads can be interrupted.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// UnmarshalFromReader reads one frame from a stream transport and decodes it.
+//
+// If rd implements FrameReader its framing is used (required for V0 streams
+// not framed by WriteFrame), otherwise the frame is read by ReadFrame with the
+// version taken from the message.
+//
+// ctx is checked before reading. If rd has a SetReadDeadline method (such as
+// net.Conn), a blocked read is interrupted when ctx is done by setting the
+// read deadline to now, and the deadline is cleared before returning. A frame
+// may be partially consumed by then, so the stream should be closed after a
+// cancellation. Readers without SetReadDeadline are not interrupted, ctx is
+// only checked between frames.
+func (r *Message) UnmarshalFromReader(ctx context.Context, decoder Decoder, rd io.Reader) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	if d, ok := rd.(readDeadliner); ok && ctx.Done() != nil {
+		stop := make(chan struct{})
+		stopped := make(chan bool, 1)
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = d.SetReadDeadline(time.Now())
+				stopped <- true
+			case <-stop:
+				stopped <- false
+			}
+		}()
+		defer func() {
+			close(stop)
+			if <-stopped {
+				_ = d.SetReadDeadline(time.Time{})
+			}
+		}()
+	}
+
+	var data []byte
+	var err error
+	if fr, ok := rd.(FrameReader); ok {
+		data, err = fr.ReadFrame()
+	} else {
+		data, err = ReadFrame(rd, r.Version())
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return -1, ctxErr
+		}
+		return -1, err
+	}
+	return r.UnmarshalWithDecoder(decoder, data)
+}
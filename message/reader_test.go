@@ -0,0 +1,169 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/coder/coderv0"
+	"github.com/GiterLab/go-secoap/coder/coderv1"
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkReader returns at most one byte per Read, like a slow stream.
+type chunkReader struct {
+	r io.Reader
+}
+
+func (c chunkReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return c.r.Read(p)
+}
+
+func TestUnmarshalFromReader(t *testing.T) {
+	tests := []struct {
+		ver   secoapcore.Ver
+		coder Coder
+	}{
+		{ver: secoapcore.Version1, coder: coderv1.DefaultCoder},
+		{ver: secoapcore.Version2, coder: coderv2.DefaultCoder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ver.String(), func(t *testing.T) {
+			r := newTestMessage(t)
+			var stream bytes.Buffer
+			for i := 0; i < 2; i++ {
+				data, err := r.MarshalWithEncoder(tt.coder)
+				require.NoError(t, err)
+				_, err = WriteFrame(&stream, data)
+				require.NoError(t, err)
+			}
+
+			rd := chunkReader{&stream}
+			for i := 0; i < 2; i++ {
+				got := NewMessage(context.Background())
+				got.SetVersion(tt.ver)
+				_, err := got.UnmarshalFromReader(context.Background(), tt.coder, rd)
+				require.NoError(t, err)
+				body, err := got.ReadBody()
+				require.NoError(t, err)
+				require.Equal(t, `{"a":1}`, string(body))
+			}
+			_, err := ReadFrame(rd, tt.ver)
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+type v0FrameReader struct {
+	r io.Reader
+}
+
+func (f v0FrameReader) Read(p []byte) (int, error) { return f.r.Read(p) }
+
+func (f v0FrameReader) ReadFrame() ([]byte, error) {
+	return coderv0.DefaultStreamCoder.ReadFrame(f.r)
+}
+
+func TestUnmarshalFromReaderFrameReader(t *testing.T) {
+	m := secoapcore.Message{Type: secoapcore.NonConfirmable, Payload: []byte("v0")}
+	buf := make([]byte, 64)
+	n, err := coderv0.DefaultStreamCoder.Encode(m, buf)
+	require.NoError(t, err)
+
+	got := NewMessage(context.Background())
+	_, err = got.UnmarshalFromReader(context.Background(), coderv0.DefaultCoder, v0FrameReader{bytes.NewReader(buf[:n])})
+	require.NoError(t, err)
+	body, err := got.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, "v0", string(body))
+}
+
+func TestUnmarshalFromReaderCancel(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := NewMessage(context.Background())
+	r.SetVersion(secoapcore.Version2)
+	_, err := r.UnmarshalFromReader(ctx, coderv2.DefaultCoder, server)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the blocked read is interrupted by the read deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = r.UnmarshalFromReader(ctx, coderv2.DefaultCoder, server)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// no reader is left behind, the next frame goes to the next caller
+	data, err := newTestMessage(t).MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	go func() {
+		_, _ = WriteFrame(client, data)
+	}()
+	got := NewMessage(context.Background())
+	got.SetVersion(secoapcore.Version2)
+	_, err = got.UnmarshalFromReader(context.Background(), coderv2.DefaultCoder, server)
+	require.NoError(t, err)
+	body, err := got.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(body))
+}
+
+func TestReadFrameAutoDetect(t *testing.T) {
+	var stream bytes.Buffer
+	v1, err := newTestMessage(t).MarshalWithEncoder(coderv1.DefaultCoder)
+	require.NoError(t, err)
+	v2, err := newTestMessage(t).MarshalWithEncoder(coderv2.DefaultCoder)
+	require.NoError(t, err)
+	for _, data := range [][]byte{v1, v2} {
+		_, err = WriteFrame(&stream, data)
+		require.NoError(t, err)
+	}
+
+	got, err := ReadFrameAutoDetect(&stream)
+	require.NoError(t, err)
+	require.Equal(t, v1, got)
+	got, err = ReadFrameAutoDetect(&stream)
+	require.NoError(t, err)
+	require.Equal(t, v2, got)
+
+	_, err = ReadFrameAutoDetect(bytes.NewReader([]byte{0x00, 0x04, 0xC0, 0x00, 0x00, 0x00}))
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+	_, err = ReadFrameAutoDetect(bytes.NewReader([]byte{0x00, 0x00}))
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+}
+
+func TestReadFrameInvalid(t *testing.T) {
+	_, err := ReadFrame(bytes.NewReader([]byte{0x00, 0x02, 0x80, 0x00}), secoapcore.Version2)
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+	_, err = ReadFrame(bytes.NewReader([]byte{0x00, 0x04, 0x40, 0x01, 0x00, 0x01}), secoapcore.Version2)
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+	_, err = ReadFrame(bytes.NewReader([]byte{0x00, 0x04, 0x80, 0x01, 0x00, 0x01}), secoapcore.Version1)
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+	_, err = ReadFrame(bytes.NewReader([]byte{0x00, 0x04, 0x42, 0x01, 0x00, 0x01}), secoapcore.Version1)
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+}
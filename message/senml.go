@@ -0,0 +1,39 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/GiterLab/go-secoap/senml"
+)
+
+// SetSenMLBody 将记录编码为 SenML JSON 作为消息体, 并设置 ContentFormat 为 AppSenmlJSON
+func (r *Message) SetSenMLBody(records []senml.Record) error {
+	data, err := senml.EncodeJSON(records)
+	if err != nil {
+		return err
+	}
+	r.SetContentFormat(secoapcore.AppSenmlJSON)
+	return r.SetPayloadBytes(data)
+}
+
+// SenMLBody 解码 SenML JSON 消息体, 返回的记录未经 senml.Resolve 解析
+func (r *Message) SenMLBody() ([]senml.Record, error) {
+	body, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	return senml.DecodeJSON(body)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// MessageSnapshot is an immutable copy of a Message, it shares no memory with the
+// message it was taken from and can be passed between goroutines freely.
+//
+// The fields are exported for reading only, a snapshot must not be modified.
+type MessageSnapshot struct {
+	Ver         secoapcore.Ver
+	Type        secoapcore.Type
+	Code        secoapcore.Code
+	MessageID   int32
+	EncoderID   int32
+	EncoderType int32
+	Token       secoapcore.Token
+	Options     secoapcore.Options // values are stored as []byte in one backing buffer
+	Payload     []byte
+	Sequence    uint64
+	CreatedAt   time.Time
+}
+
+// Snapshot copies the message into an immutable MessageSnapshot.
+//
+// The seek position of the body is restored after the payload is copied.
+func (r *Message) Snapshot() (*MessageSnapshot, error) {
+	payload, err := r.PeekBody()
+	if err != nil {
+		return nil, err
+	}
+	var token secoapcore.Token
+	if len(r.msg.Token) > 0 {
+		token = append(secoapcore.Token(nil), r.msg.Token...)
+	}
+	return &MessageSnapshot{
+		Ver:         r.msg.Ver,
+		Type:        r.msg.Type,
+		Code:        r.msg.Code,
+		MessageID:   r.msg.MessageID,
+		EncoderID:   r.msg.EncoderID,
+		EncoderType: r.msg.EncoderType,
+		Token:       token,
+		Options:     r.msg.Opts.DeepCopy(),
+		Payload:     payload,
+		Sequence:    r.sequence,
+		CreatedAt:   r.CreatedAt,
+	}, nil
+}
+
+// ToMessage creates a new mutable message from the snapshot, the message shares
+// no memory with the snapshot.
+func (s *MessageSnapshot) ToMessage(ctx context.Context) (*Message, error) {
+	msg := NewMessage(ctx)
+	m := s.toCoreMessage()
+	m.Opts = s.Options.DeepCopy()
+	if len(s.Token) > 0 {
+		m.Token = append(secoapcore.Token(nil), s.Token...)
+	}
+	if len(s.Payload) > 0 {
+		m.Payload = append([]byte(nil), s.Payload...)
+	}
+	msg.SetMessage(m)
+	msg.SetSequence(s.Sequence)
+	msg.CreatedAt = s.CreatedAt
+	return msg, nil
+}
+
+// MarshalJSON encodes the snapshot to JSON, see secoapcore.Message.MarshalJSON.
+func (s *MessageSnapshot) MarshalJSON() ([]byte, error) {
+	m := s.toCoreMessage()
+	return m.MarshalJSON()
+}
+
+// toCoreMessage returns a secoapcore.Message sharing memory with the snapshot.
+func (s *MessageSnapshot) toCoreMessage() secoapcore.Message {
+	return secoapcore.Message{
+		Ver:         s.Ver,
+		Type:        s.Type,
+		Code:        s.Code,
+		MessageID:   s.MessageID,
+		EncoderID:   s.EncoderID,
+		EncoderType: s.EncoderType,
+		Token:       s.Token,
+		Opts:        s.Options,
+		Payload:     s.Payload,
+	}
+}
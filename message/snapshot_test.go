@@ -0,0 +1,96 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageSnapshot(t *testing.T) {
+	m := newTestMessage(t)
+	m.SetSequence(7)
+	// 快照不应改变消息体的读取位置
+	_, err := m.Body().Seek(2, 0)
+	require.NoError(t, err)
+
+	s, err := m.Snapshot()
+	require.NoError(t, err)
+	pos, err := m.Body().Seek(0, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), pos)
+
+	require.Equal(t, secoapcore.POST, s.Code)
+	require.Equal(t, int32(0x1234), s.MessageID)
+	require.Equal(t, uint64(7), s.Sequence)
+	require.Equal(t, []byte(`{"a":1}`), s.Payload)
+	path, err := s.Options.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/iotda/v3/device/status", path)
+
+	// 修改原消息不影响快照
+	m.Token()[0] = 0xff
+	m.MustSetPath("/other")
+	require.NoError(t, m.SetPayloadBytes([]byte("changed")))
+	require.Equal(t, secoapcore.Token{0x01, 0x02, 0x03, 0x04}, s.Token)
+	path, err = s.Options.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/iotda/v3/device/status", path)
+	require.Equal(t, []byte(`{"a":1}`), s.Payload)
+
+	// 由快照重建的消息与快照相互独立
+	got, err := s.ToMessage(context.Background())
+	require.NoError(t, err)
+	got.MustSetPath("/rebuilt")
+	got.Token()[1] = 0xff
+	require.Equal(t, secoapcore.Token{0x01, 0x02, 0x03, 0x04}, s.Token)
+	path, err = s.Options.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/iotda/v3/device/status", path)
+	require.Equal(t, uint64(7), got.Sequence())
+	require.Equal(t, s.CreatedAt, got.CreatedAt)
+	body, err := got.ReadBody()
+	require.NoError(t, err)
+	require.Equal(t, s.Payload, body)
+}
+
+func TestMessageSnapshotJSON(t *testing.T) {
+	m := newTestMessage(t)
+	want, err := json.Marshal(m)
+	require.NoError(t, err)
+	s, err := m.Snapshot()
+	require.NoError(t, err)
+
+	results := make([][]byte, 4)
+	errs := make([]error, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = json.Marshal(s)
+		}(i)
+	}
+	wg.Wait()
+	for i, data := range results {
+		require.NoError(t, errs[i])
+		require.JSONEq(t, string(want), string(data))
+	}
+}
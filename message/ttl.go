@@ -0,0 +1,27 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import "time"
+
+// IsExpired reports whether more than ttl has passed since CreatedAt.
+func (r *Message) IsExpired(now time.Time, ttl time.Duration) bool {
+	return now.Sub(r.CreatedAt) > ttl
+}
+
+// RemainingTTL returns the time left until the message expires, it is negative once expired.
+func (r *Message) RemainingTTL(now time.Time, ttl time.Duration) time.Duration {
+	return ttl - now.Sub(r.CreatedAt)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ValidationError 描述消息中不满足发送条件的字段
+//
+// Err 为对应的 secoapcore 错误(如 ErrMessageInvalidCode), 可用 errors.Is 判断.
+type ValidationError struct {
+	Field  string
+	Reason string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// requiredOptions 请求方法携带负载时必须携带的选项, FETCH/PATCH/iPATCH 的负载格式由 Content-Format 指定 (RFC 8132)
+var requiredOptions = map[secoapcore.Code][]secoapcore.OptionID{
+	secoapcore.FETCH:  {secoapcore.ContentFormat},
+	secoapcore.PATCH:  {secoapcore.ContentFormat},
+	secoapcore.IPATCH: {secoapcore.ContentFormat},
+}
+
+// Validate 按协议版本 ver 检查消息是否可以发送, 返回发现的所有错误, 元素类型为 *ValidationError
+//
+// 只检查该版本编码的字段: V0 只有 Type, EncoderID, EncoderType 和消息体, V1 没有
+// EncoderID 和 EncoderType. Type 为 Unset 时视为合法, 发送前由 UpsertType 填充.
+// 不会分配或修改编码缓冲区.
+func (r *Message) Validate(ver secoapcore.Ver) []error {
+	var errs []error
+	add := func(field string, err error, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...), Err: err})
+	}
+
+	if ver < secoapcore.Version0 || ver > secoapcore.Version2 {
+		add("Version", secoapcore.ErrMessageInvalidVersion, "unsupported version %v", ver)
+		return errs
+	}
+	// 三个版本的 Type 都只有 2 位, Unset 留给发送时填充
+	if typ := r.msg.Type; typ != secoapcore.Unset && (typ < secoapcore.Confirmable || typ > secoapcore.Reset) {
+		add("Type", nil, "invalid type %d", typ)
+	}
+	if ver != secoapcore.Version1 {
+		if !secoapcore.ValidateEID(r.msg.EncoderID) {
+			add("EncoderID", nil, "encoder id %d out of range 0-15", r.msg.EncoderID)
+		}
+		if !secoapcore.ValidateETP(r.msg.EncoderType) {
+			add("EncoderType", nil, "encoder type %d out of range 0-15", r.msg.EncoderType)
+		}
+	}
+	if ver == secoapcore.Version0 {
+		return errs
+	}
+
+	if !secoapcore.ValidateMID(r.msg.MessageID) {
+		add("MessageID", nil, "message id %d out of range 0-65535", r.msg.MessageID)
+	}
+	if err := secoapcore.ValidateToken(r.msg.Token, false); err != nil {
+		add("Token", err, "token has %d bytes, at most %d", len(r.msg.Token), secoapcore.MaxTokenSize)
+	}
+	if !secoapcore.ValidateCode(r.msg.Code) {
+		add("Code", secoapcore.ErrMessageInvalidCode, "invalid code %v", r.msg.Code)
+	}
+	for _, err := range r.msg.Opts.Validate(secoapcore.OptionDefs()) {
+		add("Options", err, "%v", err)
+	}
+	// 没有负载时不需要 Content-Format
+	if size, err := r.BodySize(); err == nil && size > 0 {
+		for _, id := range requiredOptions[r.msg.Code] {
+			if !r.msg.Opts.HasOption(id) {
+				add("Options", secoapcore.ErrOptionNotFound, "%v requires option %v", r.msg.Code, id)
+			}
+		}
+	}
+	if r.msg.Code == secoapcore.Empty {
+		// 空消息只有头部 (RFC 7252 section 4.1)
+		if len(r.msg.Token) > 0 {
+			add("Token", nil, "empty message must not have a token")
+		}
+		if len(r.msg.Opts) > 0 {
+			add("Options", nil, "empty message must not have options")
+		}
+		if size, err := r.BodySize(); err != nil {
+			add("Body", err, "cannot get body size: %v", err)
+		} else if size > 0 {
+			add("Body", nil, "empty message must not have a payload")
+		}
+	}
+	return errs
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func validationFields(t *testing.T, errs []error) []string {
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		var ve *ValidationError
+		require.ErrorAs(t, err, &ve)
+		require.NotEmpty(t, ve.Reason)
+		fields = append(fields, ve.Field)
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	newValid := func() *Message {
+		m := newTestMessage(t)
+		m.SetEncoderID(0)
+		m.SetEncoderType(6)
+		return m
+	}
+	for _, ver := range []secoapcore.Ver{secoapcore.Version0, secoapcore.Version1, secoapcore.Version2} {
+		require.Empty(t, newValid().Validate(ver), "%v", ver)
+	}
+
+	tests := []struct {
+		name   string
+		ver    secoapcore.Ver
+		modify func(m *Message)
+		fields []string
+	}{
+		{
+			name:   "negative message id",
+			ver:    secoapcore.Version2,
+			modify: func(m *Message) { m.SetMessageID(-1) },
+			fields: []string{"MessageID"},
+		},
+		{
+			name:   "message id is not encoded in version 0",
+			ver:    secoapcore.Version0,
+			modify: func(m *Message) { m.SetMessageID(-1) },
+		},
+		{
+			name:   "token too long",
+			ver:    secoapcore.Version1,
+			modify: func(m *Message) { m.msg.Token = make(secoapcore.Token, 9) },
+			fields: []string{"Token"},
+		},
+		{
+			name:   "unset type",
+			ver:    secoapcore.Version0,
+			modify: func(m *Message) { m.SetType(secoapcore.Unset) },
+		},
+		{
+			name:   "invalid type",
+			ver:    secoapcore.Version0,
+			modify: func(m *Message) { m.SetType(secoapcore.Reset + 1) },
+			fields: []string{"Type"},
+		},
+		{
+			name: "encoder out of range",
+			ver:  secoapcore.Version2,
+			modify: func(m *Message) {
+				m.SetEncoderID(16)
+				m.SetEncoderType(-1)
+			},
+			fields: []string{"EncoderID", "EncoderType"},
+		},
+		{
+			name: "encoder is not encoded in version 1",
+			ver:  secoapcore.Version1,
+			modify: func(m *Message) {
+				m.SetEncoderID(16)
+				m.SetEncoderType(-1)
+			},
+		},
+		{
+			name:   "invalid code",
+			ver:    secoapcore.Version2,
+			modify: func(m *Message) { m.SetCode(secoapcore.Code(70)) },
+			fields: []string{"Code"},
+		},
+		{
+			name: "option value exceeds max length",
+			ver:  secoapcore.Version2,
+			modify: func(m *Message) {
+				m.msg.Opts = m.msg.Opts.Add(secoapcore.Option{ID: secoapcore.URIHost, Value: strings.Repeat("h", 256)})
+			},
+			fields: []string{"Options"},
+		},
+		{
+			name: "fetch without content format",
+			ver:  secoapcore.Version1,
+			modify: func(m *Message) {
+				m.SetCode(secoapcore.FETCH)
+				m.Remove(secoapcore.ContentFormat)
+			},
+			fields: []string{"Options"},
+		},
+		{
+			name: "fetch without payload",
+			ver:  secoapcore.Version1,
+			modify: func(m *Message) {
+				m.SetCode(secoapcore.FETCH)
+				m.Remove(secoapcore.ContentFormat)
+				m.SetBody(nil)
+			},
+		},
+		{
+			name: "empty message with token and payload",
+			ver:  secoapcore.Version2,
+			modify: func(m *Message) {
+				m.SetCode(secoapcore.Empty)
+				m.msg.Opts = m.msg.Opts[:0]
+				m.SetBody(bytes.NewReader([]byte("x")))
+			},
+			fields: []string{"Token", "Body"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newValid()
+			tt.modify(m)
+			fields := validationFields(t, m.Validate(tt.ver))
+			if len(tt.fields) == 0 {
+				require.Empty(t, fields)
+				return
+			}
+			require.Equal(t, tt.fields, fields)
+		})
+	}
+
+	errs := newValid().Validate(secoapcore.Version3)
+	require.Equal(t, []string{"Version"}, validationFields(t, errs))
+	require.ErrorIs(t, errs[0], secoapcore.ErrMessageInvalidVersion)
+
+	m := newValid()
+	m.msg.Token = make(secoapcore.Token, 9)
+	errs = m.Validate(secoapcore.Version2)
+	require.ErrorIs(t, errs[0], secoapcore.ErrTokenTooLong)
+	require.EqualError(t, errs[0], "Token: token has 9 bytes, at most 8")
+}
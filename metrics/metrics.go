@@ -0,0 +1,179 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics 统计消息编解码的次数、错误和消息长度
+//
+// PrometheusCollector 将指标导出到 prometheus, MemoryCollector 在内存中统计,
+// 适用于测试. 其他监控系统可以自行实现 Collector.
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SizeBuckets 消息长度直方图的分桶上限 (字节), 覆盖常见的物联网消息长度
+var SizeBuckets = []float64{32, 64, 128, 256, 512, 1024, 4096}
+
+// Collector 收集编解码指标, 实现必须可并发调用
+type Collector interface {
+	IncEncoded(ver secoapcore.Ver, code secoapcore.Code)
+	IncDecoded(ver secoapcore.Ver, code secoapcore.Code)
+	IncError(ver secoapcore.Ver, errType string)
+	ObserveMessageSize(ver secoapcore.Ver, size int)
+}
+
+// NopCollector 丢弃所有指标的 Collector
+type NopCollector struct{}
+
+func (NopCollector) IncEncoded(secoapcore.Ver, secoapcore.Code) {}
+func (NopCollector) IncDecoded(secoapcore.Ver, secoapcore.Code) {}
+func (NopCollector) IncError(secoapcore.Ver, string)            {}
+func (NopCollector) ObserveMessageSize(secoapcore.Ver, int)     {}
+
+// ErrorType 将编解码错误归类为 IncError 使用的错误类型
+func ErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, secoapcore.ErrInvalidRCRC16):
+		return "crc16"
+	case errors.Is(err, secoapcore.ErrMessageInvalidRSUM8):
+		return "rsum8"
+	case errors.Is(err, secoapcore.ErrMessageTruncated):
+		return "truncated"
+	case errors.Is(err, secoapcore.ErrMessageInvalidVersion):
+		return "version"
+	case errors.Is(err, secoapcore.ErrMessageTooLarge),
+		errors.Is(err, secoapcore.ErrPayloadTooLarge):
+		return "too_large"
+	case errors.Is(err, secoapcore.ErrInvalidTokenLen):
+		return "token"
+	case errors.Is(err, secoapcore.ErrOptionTruncated),
+		errors.Is(err, secoapcore.ErrOptionUnexpectedExtendMarker),
+		errors.Is(err, secoapcore.ErrInvalidOptionHeaderExt),
+		errors.Is(err, secoapcore.ErrOptionTooLong):
+		return "option"
+	}
+	return "other"
+}
+
+// Key 计数器的标签
+type Key struct {
+	Ver  secoapcore.Ver
+	Code secoapcore.Code
+}
+
+// ErrorKey 错误计数器的标签
+type ErrorKey struct {
+	Ver     secoapcore.Ver
+	ErrType string
+}
+
+// Histogram 消息长度直方图, Counts[i] 为长度不超过 SizeBuckets[i] 的消息数 (累计)
+type Histogram struct {
+	Counts []uint64
+	Count  uint64
+	Sum    uint64
+}
+
+// MemoryCollector 在内存中统计指标的 Collector, 适用于测试和简单的状态输出
+type MemoryCollector struct {
+	mu      sync.Mutex
+	encoded map[Key]uint64
+	decoded map[Key]uint64
+	errs    map[ErrorKey]uint64
+	sizes   map[secoapcore.Ver]*Histogram
+}
+
+func NewMemoryCollector() *MemoryCollector {
+	return &MemoryCollector{
+		encoded: make(map[Key]uint64),
+		decoded: make(map[Key]uint64),
+		errs:    make(map[ErrorKey]uint64),
+		sizes:   make(map[secoapcore.Ver]*Histogram),
+	}
+}
+
+func (c *MemoryCollector) IncEncoded(ver secoapcore.Ver, code secoapcore.Code) {
+	c.mu.Lock()
+	c.encoded[Key{ver, code}]++
+	c.mu.Unlock()
+}
+
+func (c *MemoryCollector) IncDecoded(ver secoapcore.Ver, code secoapcore.Code) {
+	c.mu.Lock()
+	c.decoded[Key{ver, code}]++
+	c.mu.Unlock()
+}
+
+func (c *MemoryCollector) IncError(ver secoapcore.Ver, errType string) {
+	c.mu.Lock()
+	c.errs[ErrorKey{ver, errType}]++
+	c.mu.Unlock()
+}
+
+func (c *MemoryCollector) ObserveMessageSize(ver secoapcore.Ver, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.sizes[ver]
+	if !ok {
+		h = &Histogram{Counts: make([]uint64, len(SizeBuckets))}
+		c.sizes[ver] = h
+	}
+	for i := sort.SearchFloat64s(SizeBuckets, float64(size)); i < len(h.Counts); i++ {
+		h.Counts[i]++
+	}
+	h.Count++
+	h.Sum += uint64(size)
+}
+
+// Encoded 返回编码计数
+func (c *MemoryCollector) Encoded(ver secoapcore.Ver, code secoapcore.Code) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.encoded[Key{ver, code}]
+}
+
+// Decoded 返回解码计数
+func (c *MemoryCollector) Decoded(ver secoapcore.Ver, code secoapcore.Code) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.decoded[Key{ver, code}]
+}
+
+// Errors 返回错误计数
+func (c *MemoryCollector) Errors(ver secoapcore.Ver, errType string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errs[ErrorKey{ver, errType}]
+}
+
+// MessageSize 返回消息长度直方图的副本
+func (c *MemoryCollector) MessageSize(ver secoapcore.Ver) Histogram {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.sizes[ver]
+	if !ok {
+		return Histogram{Counts: make([]uint64, len(SizeBuckets))}
+	}
+	return Histogram{
+		Counts: append([]uint64(nil), h.Counts...),
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCollector(t *testing.T) {
+	c := NewMemoryCollector()
+	c.IncEncoded(secoapcore.Version2, secoapcore.POST)
+	c.IncEncoded(secoapcore.Version2, secoapcore.POST)
+	c.IncDecoded(secoapcore.Version1, secoapcore.Content)
+	c.IncError(secoapcore.Version2, "crc16")
+	for _, size := range []int{10, 32, 33, 300, 5000} {
+		c.ObserveMessageSize(secoapcore.Version2, size)
+	}
+
+	require.Equal(t, uint64(2), c.Encoded(secoapcore.Version2, secoapcore.POST))
+	require.Zero(t, c.Encoded(secoapcore.Version1, secoapcore.POST))
+	require.Equal(t, uint64(1), c.Decoded(secoapcore.Version1, secoapcore.Content))
+	require.Equal(t, uint64(1), c.Errors(secoapcore.Version2, "crc16"))
+
+	h := c.MessageSize(secoapcore.Version2)
+	require.Equal(t, []uint64{2, 3, 3, 3, 4, 4, 4}, h.Counts)
+	require.Equal(t, uint64(5), h.Count)
+	require.Equal(t, uint64(10+32+33+300+5000), h.Sum)
+	require.Equal(t, make([]uint64, len(SizeBuckets)), c.MessageSize(secoapcore.Version0).Counts)
+}
+
+func TestErrorType(t *testing.T) {
+	require.Equal(t, "", ErrorType(nil))
+	require.Equal(t, "crc16", ErrorType(secoapcore.ErrInvalidRCRC16))
+	require.Equal(t, "rsum8", ErrorType(fmt.Errorf("decode: %w", secoapcore.ErrMessageInvalidRSUM8)))
+	require.Equal(t, "truncated", ErrorType(secoapcore.ErrMessageTruncated))
+	require.Equal(t, "token", ErrorType(secoapcore.ErrTokenTooLong))
+	require.Equal(t, "option", ErrorType(secoapcore.ErrOptionTruncated))
+	require.Equal(t, "other", ErrorType(errors.New("boom")))
+}
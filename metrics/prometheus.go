@@ -0,0 +1,101 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusCollector 使用 prometheus 计数器和直方图实现的 Collector
+//
+// 编解码计数的标签为 {version, code}, 错误计数的标签为 {version, type},
+// 消息长度直方图的标签为 {version}, 分桶使用 SizeBuckets.
+// PrometheusCollector 同时实现了 prometheus.Collector, 需要注册到 Registerer 后才会被采集:
+//
+//	c := metrics.NewPrometheusCollector("secoap")
+//	prometheus.MustRegister(c)
+//	s := secoap.NewSecoap(secoap.Version2, secoap.WithMetricsCollector(c))
+type PrometheusCollector struct {
+	encoded *prometheus.CounterVec
+	decoded *prometheus.CounterVec
+	errs    *prometheus.CounterVec
+	sizes   *prometheus.HistogramVec
+}
+
+// NewPrometheusCollector 创建 PrometheusCollector, namespace 为指标名称的前缀, 可以为空
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		encoded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_encoded_total",
+			Help:      "Number of encoded messages.",
+		}, []string{"version", "code"}),
+		decoded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_decoded_total",
+			Help:      "Number of decoded messages.",
+		}, []string{"version", "code"}),
+		errs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Number of encode and decode errors by error type.",
+		}, []string{"version", "type"}),
+		sizes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "message_size_bytes",
+			Help:      "Size of encoded and decoded messages in bytes.",
+			Buckets:   SizeBuckets,
+		}, []string{"version"}),
+	}
+}
+
+func versionLabel(ver secoapcore.Ver) string {
+	return strconv.Itoa(int(ver))
+}
+
+func (c *PrometheusCollector) IncEncoded(ver secoapcore.Ver, code secoapcore.Code) {
+	c.encoded.WithLabelValues(versionLabel(ver), code.String()).Inc()
+}
+
+func (c *PrometheusCollector) IncDecoded(ver secoapcore.Ver, code secoapcore.Code) {
+	c.decoded.WithLabelValues(versionLabel(ver), code.String()).Inc()
+}
+
+func (c *PrometheusCollector) IncError(ver secoapcore.Ver, errType string) {
+	c.errs.WithLabelValues(versionLabel(ver), errType).Inc()
+}
+
+func (c *PrometheusCollector) ObserveMessageSize(ver secoapcore.Ver, size int) {
+	c.sizes.WithLabelValues(versionLabel(ver)).Observe(float64(size))
+}
+
+// Describe 实现 prometheus.Collector
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.encoded.Describe(ch)
+	c.decoded.Describe(ch)
+	c.errs.Describe(ch)
+	c.sizes.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.encoded.Collect(ch)
+	c.decoded.Collect(ch)
+	c.errs.Collect(ch)
+	c.sizes.Collect(ch)
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Collector = (*PrometheusCollector)(nil)
+
+func gather(t *testing.T, reg *prometheus.Registry) map[string]*dto.MetricFamily {
+	t.Helper()
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	got := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		got[mf.GetName()] = mf
+	}
+	return got
+}
+
+func labels(m *dto.Metric) map[string]string {
+	got := make(map[string]string)
+	for _, l := range m.GetLabel() {
+		got[l.GetName()] = l.GetValue()
+	}
+	return got
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	c := NewPrometheusCollector("secoap")
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(c))
+
+	c.IncEncoded(secoapcore.Version2, secoapcore.POST)
+	c.IncEncoded(secoapcore.Version2, secoapcore.POST)
+	c.IncDecoded(secoapcore.Version1, secoapcore.Content)
+	c.IncError(secoapcore.Version2, "crc16")
+	for _, size := range []int{10, 32, 33, 300, 5000} {
+		c.ObserveMessageSize(secoapcore.Version2, size)
+	}
+
+	mfs := gather(t, reg)
+	encoded := mfs["secoap_messages_encoded_total"].GetMetric()
+	require.Len(t, encoded, 1)
+	require.Equal(t, map[string]string{"version": "2", "code": "POST"}, labels(encoded[0]))
+	require.Equal(t, 2.0, encoded[0].GetCounter().GetValue())
+
+	decoded := mfs["secoap_messages_decoded_total"].GetMetric()
+	require.Len(t, decoded, 1)
+	require.Equal(t, map[string]string{"version": "1", "code": "Content"}, labels(decoded[0]))
+	require.Equal(t, 1.0, decoded[0].GetCounter().GetValue())
+
+	errs := mfs["secoap_errors_total"].GetMetric()
+	require.Len(t, errs, 1)
+	require.Equal(t, map[string]string{"version": "2", "type": "crc16"}, labels(errs[0]))
+
+	sizes := mfs["secoap_message_size_bytes"].GetMetric()
+	require.Len(t, sizes, 1)
+	h := sizes[0].GetHistogram()
+	require.Equal(t, uint64(5), h.GetSampleCount())
+	require.Equal(t, float64(10+32+33+300+5000), h.GetSampleSum())
+	var bounds []float64
+	var counts []uint64
+	for _, b := range h.GetBucket() {
+		bounds = append(bounds, b.GetUpperBound())
+		counts = append(counts, b.GetCumulativeCount())
+	}
+	require.Equal(t, SizeBuckets, bounds)
+	require.Equal(t, []uint64{2, 3, 3, 3, 4, 4, 4}, counts)
+}
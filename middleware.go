@@ -0,0 +1,120 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+)
+
+// HandlerFunc 处理一条消息
+type HandlerFunc func(ctx context.Context, msg *message.Message) error
+
+// Middleware 包装 HandlerFunc, 在编码或解码前后执行日志, 鉴权等通用逻辑
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type operationKey struct{}
+
+// Operation 返回 MarshalWithMiddleware 和 UnmarshalWithMiddleware 传给中间件的 ctx 中的操作,
+// 为 "marshal" 或 "unmarshal", 不存在时返回空字符串
+func Operation(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// Use 添加中间件, 先添加的中间件在外层
+func (s *Secoap) Use(m ...Middleware) {
+	s.middlewares = append(s.middlewares, m...)
+}
+
+// chain 用中间件包装 h
+func (s *Secoap) chain(h HandlerFunc) HandlerFunc {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		h = s.middlewares[i](h)
+	}
+	return h
+}
+
+// MarshalWithMiddleware 经过中间件链编码消息, next 之前的逻辑在编码前执行, 之后的在编码后执行
+func (s *Secoap) MarshalWithMiddleware(ctx context.Context) ([]byte, error) {
+	var data []byte
+	h := s.chain(func(ctx context.Context, msg *message.Message) error {
+		var err error
+		data, err = s.Marshal()
+		return err
+	})
+	if err := h(context.WithValue(ctx, operationKey{}, "marshal"), s.Message); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UnmarshalWithMiddleware 经过中间件链解码消息, next 返回后消息已解码
+func (s *Secoap) UnmarshalWithMiddleware(ctx context.Context, data []byte) (int, error) {
+	var n int
+	h := s.chain(func(ctx context.Context, msg *message.Message) error {
+		var err error
+		n, err = s.Unmarshal(data)
+		return err
+	})
+	err := h(context.WithValue(ctx, operationKey{}, "unmarshal"), s.Message)
+	return n, err
+}
+
+// LoggingMiddleware 在编码或解码成功后记录消息, 事件为 Operation(ctx)
+func LoggingMiddleware(logger message.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *message.Message) error {
+			if err := next(ctx, msg); err != nil {
+				return err
+			}
+			logger.LogMessage(ctx, Operation(ctx), msg)
+			return nil
+		}
+	}
+}
+
+// AuthMiddleware 在 next 之后调用 verify 校验消息, 解码时校验的是收到的消息,
+// 编码时校验失败则不返回编码结果
+func AuthMiddleware(verify func(*message.Message) error) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *message.Message) error {
+			if err := next(ctx, msg); err != nil {
+				return err
+			}
+			return verify(msg)
+		}
+	}
+}
+
+// TimeoutMiddleware 为后续的处理设置超时, 编解码本身不可中断,
+// 超时发生在 next 之前或期间时返回 ctx 的错误
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *message.Message) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := next(ctx, msg); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	s := newTestSecoap(t)
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, msg *message.Message) error {
+				calls = append(calls, name+" before "+Operation(ctx))
+				err := next(ctx, msg)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	s.Use(trace("a"), trace("b"))
+
+	data, err := s.MarshalWithMiddleware(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, mustMarshal(t, s), data)
+	require.Equal(t, []string{"a before marshal", "b before marshal", "b after", "a after"}, calls)
+
+	calls = nil
+	r := NewSecoap(Version2)
+	r.Use(trace("a"))
+	n, err := r.UnmarshalWithMiddleware(context.Background(), data)
+	require.NoError(t, err)
+	require.Equal(t, len(data), n)
+	require.Equal(t, []string{"a before unmarshal", "a after"}, calls)
+	require.True(t, s.Equal(r))
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	s := newTestSecoap(t)
+	l := &recordingLogger{}
+	s.Use(LoggingMiddleware(l))
+	data, err := s.MarshalWithMiddleware(context.Background())
+	require.NoError(t, err)
+	_, err = s.UnmarshalWithMiddleware(context.Background(), data)
+	require.NoError(t, err)
+	_, err = s.UnmarshalWithMiddleware(context.Background(), data[:3])
+	require.Error(t, err)
+	require.Equal(t, []string{"marshal", "unmarshal"}, l.events)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	s := newTestSecoap(t)
+	data := mustMarshal(t, s)
+
+	errDenied := errors.New("denied")
+	r := NewSecoap(Version2)
+	r.Use(AuthMiddleware(func(msg *message.Message) error {
+		if msg.MessageID() != 0x1234 {
+			return errDenied
+		}
+		return nil
+	}))
+	_, err := r.UnmarshalWithMiddleware(context.Background(), data)
+	require.NoError(t, err)
+
+	s.SetMessageID(0x4321)
+	data = mustMarshal(t, s)
+	_, err = r.UnmarshalWithMiddleware(context.Background(), data)
+	require.ErrorIs(t, err, errDenied)
+
+	s.Use(AuthMiddleware(func(*message.Message) error { return errDenied }))
+	data, err = s.MarshalWithMiddleware(context.Background())
+	require.ErrorIs(t, err, errDenied)
+	require.Nil(t, data)
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	s := newTestSecoap(t)
+	var deadline bool
+	s.Use(TimeoutMiddleware(time.Second), func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *message.Message) error {
+			_, deadline = ctx.Deadline()
+			return next(ctx, msg)
+		}
+	})
+	_, err := s.MarshalWithMiddleware(context.Background())
+	require.NoError(t, err)
+	require.True(t, deadline)
+
+	slow := newTestSecoap(t)
+	slow.Use(TimeoutMiddleware(time.Millisecond), func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *message.Message) error {
+			<-ctx.Done()
+			return next(ctx, msg)
+		}
+	})
+	_, err = slow.MarshalWithMiddleware(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
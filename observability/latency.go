@@ -0,0 +1,191 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package observability 按路径统计请求延迟
+//
+// LatencyTracker.ExportPrometheus 将样本导出为 prometheus summary, 标签为 {path},
+// 其他监控系统可以通过 LatencyTracker.Each 导出.
+package observability
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxSamples 每个路径默认保存的样本数量
+const DefaultMaxSamples = 1024
+
+type samples struct {
+	values []int64 // 环形缓冲区
+	next   int     // 缓冲区已满时下一个覆盖的位置
+}
+
+// LatencyTracker 按路径记录请求延迟, 每个路径只保存最近的 MaxSamples 个样本, 可并发使用
+type LatencyTracker struct {
+	// MaxSamples 每个路径保存的样本数量, <= 0 时使用 DefaultMaxSamples
+	MaxSamples int
+
+	mu    sync.Mutex
+	paths map[string]*samples
+	now   func() time.Time
+}
+
+func NewLatencyTracker(maxSamples int) *LatencyTracker {
+	return &LatencyTracker{
+		MaxSamples: maxSamples,
+		paths:      make(map[string]*samples),
+		now:        time.Now,
+	}
+}
+
+// Start 开始计时, 调用返回的函数时记录从 Start 开始经过的时间
+func (t *LatencyTracker) Start(path string) func() {
+	start := t.now()
+	return func() {
+		t.Record(path, t.now().Sub(start))
+	}
+}
+
+// Record 记录一个延迟样本
+func (t *LatencyTracker) Record(path string, d time.Duration) {
+	max := t.MaxSamples
+	if max <= 0 {
+		max = DefaultMaxSamples
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.paths[path]
+	if !ok {
+		s = &samples{}
+		t.paths[path] = s
+	}
+	if len(s.values) < max {
+		s.values = append(s.values, int64(d))
+		return
+	}
+	// MaxSamples 变小后丢弃多余的样本
+	if len(s.values) > max {
+		ordered := append(s.values[s.next:len(s.values):len(s.values)], s.values[:s.next]...)
+		s.values = ordered[len(ordered)-max:]
+		s.next = 0
+	}
+	s.values[s.next] = int64(d)
+	s.next = (s.next + 1) % max
+}
+
+// Histogram 返回路径的延迟样本 (纳秒), 升序排列
+func (t *LatencyTracker) Histogram(path string) []int64 {
+	t.mu.Lock()
+	s, ok := t.paths[path]
+	var values []int64
+	if ok {
+		values = append(values, s.values...)
+	}
+	t.mu.Unlock()
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+// Percentile 返回路径延迟的第 p 百分位数 (纳秒), p 取值 [0, 100], 使用最近秩方法,
+// 没有样本时返回0
+func (t *LatencyTracker) Percentile(path string, p float64) int64 {
+	return percentile(t.Histogram(path), p)
+}
+
+// percentile 返回升序样本 values 的第 p 百分位数, 没有样本时返回0
+func percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1]
+}
+
+// Reset 清除路径的所有样本
+func (t *LatencyTracker) Reset(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.paths, path)
+}
+
+// Paths 返回有样本的路径, 按字典序排列
+func (t *LatencyTracker) Paths() []string {
+	t.mu.Lock()
+	paths := make([]string, 0, len(t.paths))
+	for path := range t.paths {
+		paths = append(paths, path)
+	}
+	t.mu.Unlock()
+	sort.Strings(paths)
+	return paths
+}
+
+// Each 按路径顺序对每个路径的样本 (升序) 调用 fn, 用于导出到监控系统
+func (t *LatencyTracker) Each(fn func(path string, latencies []int64)) {
+	for _, path := range t.Paths() {
+		fn(path, t.Histogram(path))
+	}
+}
+
+// exportQuantiles ExportPrometheus 导出的分位数
+var exportQuantiles = []float64{0.5, 0.9, 0.99}
+
+// ExportPrometheus 将 t 注册到 reg, 每次采集时按路径导出 secoap_path_latency_seconds summary
+//
+// summary 由当前保存的样本计算, 包括 0.5, 0.9, 0.99 分位数, 样本数和样本总和 (秒),
+// 因此只反映最近的 MaxSamples 个样本. 同一个 t 重复注册时返回 reg.Register 的错误.
+func (t *LatencyTracker) ExportPrometheus(reg prometheus.Registerer) error {
+	return reg.Register(&latencyCollector{
+		tracker: t,
+		desc: prometheus.NewDesc("secoap_path_latency_seconds",
+			"Request latency by path over the most recent samples.", []string{"path"}, nil),
+	})
+}
+
+// latencyCollector 将 LatencyTracker 的样本导出为 prometheus summary
+type latencyCollector struct {
+	tracker *LatencyTracker
+	desc    *prometheus.Desc
+}
+
+func (c *latencyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *latencyCollector) Collect(ch chan<- prometheus.Metric) {
+	c.tracker.Each(func(path string, latencies []int64) {
+		if len(latencies) == 0 {
+			return
+		}
+		var sum float64
+		for _, v := range latencies {
+			sum += time.Duration(v).Seconds()
+		}
+		quantiles := make(map[float64]float64, len(exportQuantiles))
+		for _, q := range exportQuantiles {
+			quantiles[q] = time.Duration(percentile(latencies, q*100)).Seconds()
+		}
+		ch <- prometheus.MustNewConstSummary(c.desc, uint64(len(latencies)), sum, quantiles, path)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observability
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tr := NewLatencyTracker(0)
+	tr.now = func() time.Time { return now }
+
+	for i := 10; i >= 1; i-- {
+		done := tr.Start("/status")
+		now = now.Add(time.Duration(i) * time.Millisecond)
+		done()
+	}
+	tr.Record("/config", time.Second)
+
+	h := tr.Histogram("/status")
+	require.Len(t, h, 10)
+	require.Equal(t, int64(time.Millisecond), h[0])
+	require.Equal(t, int64(10*time.Millisecond), h[9])
+
+	require.Equal(t, int64(time.Millisecond), tr.Percentile("/status", 0))
+	require.Equal(t, int64(5*time.Millisecond), tr.Percentile("/status", 50))
+	require.Equal(t, int64(9*time.Millisecond), tr.Percentile("/status", 90))
+	require.Equal(t, int64(10*time.Millisecond), tr.Percentile("/status", 99))
+	require.Equal(t, int64(10*time.Millisecond), tr.Percentile("/status", 100))
+	require.Equal(t, int64(0), tr.Percentile("/unknown", 50))
+	require.Equal(t, []string{"/config", "/status"}, tr.Paths())
+
+	var exported []string
+	tr.Each(func(path string, latencies []int64) {
+		exported = append(exported, path)
+		require.NotEmpty(t, latencies)
+	})
+	require.Equal(t, []string{"/config", "/status"}, exported)
+
+	tr.Reset("/status")
+	require.Empty(t, tr.Histogram("/status"))
+	require.Equal(t, []string{"/config"}, tr.Paths())
+}
+
+func TestLatencyTrackerMaxSamples(t *testing.T) {
+	tr := NewLatencyTracker(3)
+	for i := 1; i <= 5; i++ {
+		tr.Record("/a", time.Duration(i))
+	}
+	// 只保留最近的3个样本
+	require.Equal(t, []int64{3, 4, 5}, tr.Histogram("/a"))
+
+	tr.MaxSamples = 2
+	tr.Record("/a", 6)
+	require.Equal(t, []int64{5, 6}, tr.Histogram("/a"))
+}
+
+func TestLatencyTrackerConcurrent(t *testing.T) {
+	tr := NewLatencyTracker(50)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				tr.Start("/status")()
+				_ = tr.Percentile("/status", 95)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, tr.Histogram("/status"), 50)
+}
+
+func TestLatencyTrackerExportPrometheus(t *testing.T) {
+	tr := NewLatencyTracker(0)
+	for i := 1; i <= 10; i++ {
+		tr.Record("/status", time.Duration(i)*time.Millisecond)
+	}
+	tr.Record("/config", time.Second)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, tr.ExportPrometheus(reg))
+	require.Error(t, tr.ExportPrometheus(reg))
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, mfs, 1)
+	require.Equal(t, "secoap_path_latency_seconds", mfs[0].GetName())
+	require.Equal(t, dto.MetricType_SUMMARY, mfs[0].GetType())
+
+	got := make(map[string]*dto.Summary)
+	for _, m := range mfs[0].GetMetric() {
+		require.Len(t, m.GetLabel(), 1)
+		got[m.GetLabel()[0].GetValue()] = m.GetSummary()
+	}
+	require.Len(t, got, 2)
+
+	s := got["/status"]
+	require.Equal(t, uint64(10), s.GetSampleCount())
+	require.InDelta(t, 0.055, s.GetSampleSum(), 1e-9)
+	quantiles := make(map[float64]float64)
+	for _, q := range s.GetQuantile() {
+		quantiles[q.GetQuantile()] = q.GetValue()
+	}
+	require.Equal(t, map[float64]float64{0.5: 0.005, 0.9: 0.009, 0.99: 0.01}, quantiles)
+	require.Equal(t, uint64(1), got["/config"].GetSampleCount())
+
+	// 重置后的路径不再导出
+	tr.Reset("/config")
+	mfs, err = reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, mfs[0].GetMetric(), 1)
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package observe 管理 Observe (RFC 7641) 订阅, 并校验通知的新旧顺序
+package observe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+const (
+	// seqRolloverThreshold 序号回绕判断阈值 2^23 (RFC 7641 §4.4)
+	seqRolloverThreshold = 1 << 23
+	// seqMask Observe 序号为 24 位
+	seqMask = 1<<24 - 1
+	// freshnessTimeout 超过该时间的通知总是视为更新 (RFC 7641 §4.4)
+	freshnessTimeout = 128 * time.Second
+)
+
+type registration struct {
+	seq     uint32
+	hasSeq  bool
+	seqTime time.Time
+	expires time.Time
+}
+
+// ObserveManager 管理 Observe 订阅, 订阅以 token 的 CRC64 为键
+type ObserveManager struct {
+	mu            sync.RWMutex
+	registrations map[uint64]*registration
+	now           func() time.Time
+}
+
+func NewObserveManager() *ObserveManager {
+	return &ObserveManager{
+		registrations: make(map[uint64]*registration),
+		now:           time.Now,
+	}
+}
+
+// Register 注册订阅, maxAge 为订阅的有效期 (秒), 已存在的订阅会被替换
+func (m *ObserveManager) Register(token secoapcore.Token, maxAge uint32) {
+	now := m.now()
+	m.mu.Lock()
+	m.registrations[token.Hash()] = &registration{
+		expires: expiresAt(now, maxAge),
+	}
+	m.mu.Unlock()
+}
+
+// Deregister 取消订阅
+func (m *ObserveManager) Deregister(token secoapcore.Token) {
+	m.mu.Lock()
+	delete(m.registrations, token.Hash())
+	m.mu.Unlock()
+}
+
+// ValidateSequence 判断通知是否比上一次收到的通知更新, 是则记录该序号
+//
+// 未注册的 token 返回 false
+func (m *ObserveManager) ValidateSequence(token secoapcore.Token, seq uint32) bool {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.registrations[token.Hash()]
+	if !ok {
+		return false
+	}
+	if r.hasSeq && !isFresh(r.seq, r.seqTime, seq, now) {
+		return false
+	}
+	r.seq = seq & seqMask
+	r.hasSeq = true
+	r.seqTime = now
+	return true
+}
+
+// Renew 记录通知的序号并延长订阅的有效期, 未注册的 token 会被忽略
+func (m *ObserveManager) Renew(token secoapcore.Token, seq uint32, maxAge uint32) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.registrations[token.Hash()]
+	if !ok {
+		return
+	}
+	r.seq = seq & seqMask
+	r.hasSeq = true
+	r.seqTime = now
+	r.expires = expiresAt(now, maxAge)
+}
+
+// PruneExpired 删除在 now 之前过期的订阅, 返回删除的数量
+func (m *ObserveManager) PruneExpired(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key, r := range m.registrations {
+		if !r.expires.After(now) {
+			delete(m.registrations, key)
+			n++
+		}
+	}
+	return n
+}
+
+// Len 返回订阅数量
+func (m *ObserveManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.registrations)
+}
+
+func expiresAt(now time.Time, maxAge uint32) time.Time {
+	return now.Add(time.Duration(maxAge) * time.Second)
+}
+
+// isFresh 实现 RFC 7641 §4.4 的新旧判断规则, v1/t1 为上一次通知, v2/t2 为本次通知
+func isFresh(v1 uint32, t1 time.Time, v2 uint32, t2 time.Time) bool {
+	v2 &= seqMask
+	return (v1 < v2 && v2-v1 < seqRolloverThreshold) ||
+		(v1 > v2 && v1-v2 > seqRolloverThreshold) ||
+		t2.After(t1.Add(freshnessTimeout))
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observe
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager() (*ObserveManager, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewObserveManager()
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestValidateSequence(t *testing.T) {
+	token := secoapcore.Token{0x01, 0x02}
+	tests := []struct {
+		name    string
+		seqs    []uint32
+		advance time.Duration
+		want    []bool
+	}{
+		{
+			name: "normal",
+			seqs: []uint32{1, 2, 5, 100},
+			want: []bool{true, true, true, true},
+		},
+		{
+			name: "reordered",
+			seqs: []uint32{5, 4, 5, 6},
+			want: []bool{true, false, false, true},
+		},
+		{
+			name: "rollover",
+			seqs: []uint32{16000000, 1, 2},
+			want: []bool{true, true, true},
+		},
+		{
+			name: "old after rollover",
+			seqs: []uint32{1, 16000000},
+			want: []bool{true, false},
+		},
+		{
+			name:    "stale accepted after 128 seconds",
+			seqs:    []uint32{5, 4},
+			advance: 129 * time.Second,
+			want:    []bool{true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, now := newTestManager()
+			m.Register(token, 60)
+			for i, seq := range tt.seqs {
+				if i > 0 {
+					*now = now.Add(tt.advance)
+				}
+				require.Equal(t, tt.want[i], m.ValidateSequence(token, seq), "seq %d", seq)
+			}
+		})
+	}
+
+	m, _ := newTestManager()
+	require.False(t, m.ValidateSequence(token, 1))
+}
+
+func TestRenewAndPrune(t *testing.T) {
+	m, now := newTestManager()
+	a := secoapcore.Token{0x0A}
+	b := secoapcore.Token{0x0B}
+	m.Register(a, 10)
+	m.Register(b, 10)
+
+	*now = now.Add(5 * time.Second)
+	m.Renew(a, 7, 60)
+	require.False(t, m.ValidateSequence(a, 7))
+	require.True(t, m.ValidateSequence(a, 8))
+
+	require.Equal(t, 1, m.PruneExpired(now.Add(10*time.Second)))
+	require.Equal(t, 1, m.Len())
+	require.False(t, m.ValidateSequence(b, 1))
+
+	m.Deregister(a)
+	require.Equal(t, 0, m.Len())
+}
+
+func TestConcurrentRegister(t *testing.T) {
+	m := NewObserveManager()
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				token := make(secoapcore.Token, 4)
+				binary.BigEndian.PutUint32(token, uint32(g<<16|i))
+				m.Register(token, 60)
+				m.ValidateSequence(token, uint32(i))
+				m.Renew(token, uint32(i+1), 60)
+				m.Deregister(token)
+				m.PruneExpired(time.Now())
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.Equal(t, 0, m.Len())
+}
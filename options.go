@@ -0,0 +1,139 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GiterLab/go-secoap/metrics"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// Option 配置 NewSecoap 创建的协议实例
+type Option func(*config) error
+
+type config struct {
+	ctx                    context.Context
+	initialValueBufferSize int
+	maxMarshalBufferSize   int
+	maxMessageSize         int
+	maxPayloadSize         int
+	tokenStore             *secoapcore.TokenStore
+	midManager             *secoapcore.MIDManager
+	checksum               secoapcore.ChecksumBackend
+	metrics                metrics.Collector
+}
+
+// WithContext 设置消息的上下文
+func WithContext(ctx context.Context) Option {
+	return func(c *config) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		c.ctx = ctx
+		return nil
+	}
+}
+
+// WithInitialValueBufferSize 设置选项值缓冲区的初始大小
+func WithInitialValueBufferSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid initial value buffer size: %d", size)
+		}
+		c.initialValueBufferSize = size
+		return nil
+	}
+}
+
+// WithMaxMarshalBufferSize 设置消息可复用的编码缓冲区的最大长度,
+// 超过该长度的消息编码到新分配的缓冲区中, 避免长期占用大块内存
+func WithMaxMarshalBufferSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid max marshal buffer size: %d", size)
+		}
+		c.maxMarshalBufferSize = size
+		return nil
+	}
+}
+
+// WithMaxMessageSize 设置编码后消息的最大长度, 超过时 Marshal 返回 ErrMessageTooLarge
+func WithMaxMessageSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid max message size: %d", size)
+		}
+		c.maxMessageSize = size
+		return nil
+	}
+}
+
+// WithMaxPayloadSize 设置消息体的最大长度, 超过时 Marshal 返回 ErrPayloadTooLarge
+func WithMaxPayloadSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid max payload size: %d", size)
+		}
+		c.maxPayloadSize = size
+		return nil
+	}
+}
+
+// WithTokenStore 使用 store 为消息分配令牌
+func WithTokenStore(store *secoapcore.TokenStore) Option {
+	return func(c *config) error {
+		if store == nil {
+			return errors.New("nil token store")
+		}
+		c.tokenStore = store
+		return nil
+	}
+}
+
+// WithMIDManager 使用 m 为消息分配消息ID
+func WithMIDManager(m *secoapcore.MIDManager) Option {
+	return func(c *config) error {
+		if m == nil {
+			return errors.New("nil message id manager")
+		}
+		c.midManager = m
+		return nil
+	}
+}
+
+// WithChecksum16Backend 设置 V0 和 V2 编解码器使用的CRC16实现, 不影响全局设置
+func WithChecksum16Backend(b secoapcore.ChecksumBackend) Option {
+	return func(c *config) error {
+		if b == nil {
+			return errors.New("nil checksum backend")
+		}
+		c.checksum = b
+		return nil
+	}
+}
+
+// WithMetricsCollector 设置 Marshal 和 Unmarshal 使用的指标收集器, 默认为 metrics.NopCollector
+func WithMetricsCollector(c metrics.Collector) Option {
+	return func(cfg *config) error {
+		if c == nil {
+			return errors.New("nil metrics collector")
+		}
+		cfg.metrics = c
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/metrics"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func newSecoapWithOptions(t *testing.T, opts ...Option) *Secoap {
+	t.Helper()
+	s, err := newSecoap(Version2, opts...)
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewSecoapOptions(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	store := secoapcore.NewTokenStore(0)
+	mids := secoapcore.NewMIDManager(0)
+	s := NewSecoap(Version2,
+		WithContext(ctx),
+		WithInitialValueBufferSize(16),
+		WithTokenStore(store),
+		WithMIDManager(mids),
+	)
+	require.NotNil(t, s)
+	require.Equal(t, "v", s.GetContext().Value(ctxKey{}))
+	require.Equal(t, "v", s.Message.Context().Value(ctxKey{}))
+	require.Equal(t, 1, store.Len())
+	require.NotEmpty(t, s.Message.Token())
+	require.GreaterOrEqual(t, s.Message.MessageID(), int32(0))
+	require.Same(t, store, s.TokenStore())
+	require.Same(t, mids, s.MIDManager())
+
+	// the acquired token goes back to the store once, even after SetToken
+	s.SetToken(secoapcore.Token{0x01})
+	s.ReleaseToken()
+	require.Zero(t, store.Len())
+	s.ReleaseToken()
+	require.Zero(t, store.Len())
+	NewSecoap(Version2).ReleaseToken()
+
+	// the value buffer grows beyond its initial size
+	s.SetCode(secoapcore.GET)
+	require.NoError(t, s.SetPath("/a/long/path/which/does/not/fit/into/sixteen/bytes"))
+	path, err := s.Message.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/a/long/path/which/does/not/fit/into/sixteen/bytes", path)
+}
+
+func TestNewSecoapInvalidOptions(t *testing.T) {
+	for _, opt := range []Option{
+		WithContext(nil),
+		WithInitialValueBufferSize(0),
+		WithMaxMarshalBufferSize(-1),
+		WithMaxMessageSize(0),
+		WithMaxPayloadSize(0),
+		WithTokenStore(nil),
+		WithMIDManager(nil),
+		WithChecksum16Backend(nil),
+		WithMetricsCollector(nil),
+	} {
+		s, err := newSecoap(Version2, opt)
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Nil(t, NewSecoap(Version2, opt))
+	}
+}
+
+func TestSecoapMaxMessageSize(t *testing.T) {
+	s := newSecoapWithOptions(t, WithMaxMessageSize(32))
+	s.SetCode(secoapcore.POST)
+	s.SetMessageID(1)
+	s.SetType(secoapcore.Confirmable)
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 16)))
+	_, err := s.Marshal()
+	require.NoError(t, err)
+
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 64)))
+	_, err = s.Marshal()
+	require.ErrorIs(t, err, secoapcore.ErrMessageTooLarge)
+}
+
+func TestSecoapMaxSizeLimits(t *testing.T) {
+	newMessage := func(opts ...Option) *Secoap {
+		s := newSecoapWithOptions(t, opts...)
+		s.SetCode(secoapcore.POST)
+		s.SetMessageID(1)
+		s.SetType(secoapcore.Confirmable)
+		return s
+	}
+
+	s := newMessage(WithMaxPayloadSize(16))
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 16)))
+	_, err := s.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 17)))
+	_, err = s.Marshal()
+	require.ErrorIs(t, err, secoapcore.ErrPayloadTooLarge)
+
+	// 8 bytes header, the payload separator and the payload
+	s = newMessage(WithMaxMessageSize(8 + 1 + 16))
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 16)))
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	require.Len(t, data, 8+1+16)
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 17)))
+	_, err = s.Marshal()
+	require.ErrorIs(t, err, secoapcore.ErrMessageTooLarge)
+
+	// without limits large messages are encoded
+	s = newMessage()
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 1<<16)))
+	_, err = s.Marshal()
+	require.NoError(t, err)
+}
+
+func TestSecoapMaxMarshalBufferSize(t *testing.T) {
+	s := newSecoapWithOptions(t, WithMaxMarshalBufferSize(32))
+	s.SetCode(secoapcore.POST)
+	s.SetMessageID(1)
+	s.SetType(secoapcore.Confirmable)
+	require.NoError(t, s.Message.SetPayloadBytes(make([]byte, 64)))
+	a, err := s.Marshal()
+	require.NoError(t, err)
+	b, err := s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+	require.NotSame(t, &a[0], &b[0])
+
+	got := NewSecoap(Version2)
+	_, err = got.Unmarshal(a)
+	require.NoError(t, err)
+	require.True(t, s.Equal(got))
+}
+
+func TestSecoapWithChecksum16Backend(t *testing.T) {
+	s := newSecoapWithOptions(t, WithChecksum16Backend(secoapcore.CRC16CCITTBackend))
+	s.SetCode(secoapcore.POST)
+	s.SetMessageID(1)
+	s.SetType(secoapcore.Confirmable)
+	payload := []byte("123456789")
+	require.NoError(t, s.Message.SetPayloadBytes(payload))
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.CRC16CCITTBackend.Checksum16(payload), binary.BigEndian.Uint16(data[2:4]))
+	require.Equal(t, secoapcore.CRC16ModbusBackend, secoapcore.GetCRC16Backend())
+
+	// the default decoder uses the global backend and rejects the frame
+	_, err = NewSecoap(Version2).Unmarshal(data)
+	require.ErrorIs(t, err, secoapcore.ErrInvalidRCRC16)
+	_, err = newSecoapWithOptions(t, WithChecksum16Backend(secoapcore.CRC16CCITTBackend)).Unmarshal(data)
+	require.NoError(t, err)
+
+	s.SetVersion(Version0)
+	data, err = s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, secoapcore.CRC16CCITTBackend.Checksum16(payload), binary.LittleEndian.Uint16(data[2:4]))
+}
+
+func TestSecoapWithMetricsCollector(t *testing.T) {
+	c := metrics.NewMemoryCollector()
+	s := newSecoapWithOptions(t, WithMetricsCollector(c))
+	s.SetCode(secoapcore.POST)
+	s.SetMessageID(1)
+	s.SetType(secoapcore.Confirmable)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), c.Encoded(Version2, secoapcore.POST))
+	require.Equal(t, uint64(1), c.MessageSize(Version2).Count)
+
+	got := newSecoapWithOptions(t, WithMetricsCollector(c))
+	_, err = got.Unmarshal(data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), c.Decoded(Version2, secoapcore.POST))
+
+	data[7]++
+	_, err = got.Unmarshal(data)
+	require.Error(t, err)
+	require.Equal(t, uint64(1), c.Errors(Version2, "rsum8"))
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type segment struct {
+	literal string // 已解码的固定路径段, name 为空时有效
+	name    string // 变量名
+	rest    bool   // {name...} 匹配剩余的所有路径段
+}
+
+// Pattern 路径模板, 如 /devices/{deviceID}/sensors/{sensorType}
+//
+// 变量必须占据一个完整的路径段, {name...} 只能位于最后, 匹配零个或多个路径段.
+// 路径末尾的 / 会被忽略, 固定路径段和路径中的百分号编码会先解码再比较.
+type Pattern struct {
+	raw      string
+	segments []segment
+}
+
+// Compile 解析路径模板, 变量名为空、重复或 {name...} 不在最后时返回错误
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{raw: pattern}
+	names := make(map[string]struct{})
+	parts := splitPath(pattern)
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("router: invalid segment %q in pattern %q", part, pattern)
+			}
+			literal, err := url.PathUnescape(part)
+			if err != nil {
+				return nil, fmt.Errorf("router: invalid segment %q in pattern %q: %w", part, pattern, err)
+			}
+			p.segments = append(p.segments, segment{literal: literal})
+			continue
+		}
+		name := part[1 : len(part)-1]
+		rest := strings.HasSuffix(name, "...")
+		if rest {
+			name = strings.TrimSuffix(name, "...")
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("router: %q must be the last segment in pattern %q", part, pattern)
+			}
+		}
+		if name == "" || strings.ContainsAny(name, "{}.") {
+			return nil, fmt.Errorf("router: invalid variable %q in pattern %q", part, pattern)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("router: duplicate variable %q in pattern %q", name, pattern)
+		}
+		names[name] = struct{}{}
+		p.segments = append(p.segments, segment{name: name, rest: rest})
+	}
+	return p, nil
+}
+
+// MustCompile 同 Compile, 出错时 panic
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Match 匹配路径, 成功时返回解码后的变量值
+func (p *Pattern) Match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	vars := make(map[string]string)
+	for i, seg := range p.segments {
+		if seg.rest {
+			rest := make([]string, 0, len(parts)-i)
+			for _, part := range parts[i:] {
+				v, err := url.PathUnescape(part)
+				if err != nil {
+					return nil, false
+				}
+				rest = append(rest, v)
+			}
+			vars[seg.name] = strings.Join(rest, "/")
+			return vars, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		v, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+		if seg.name == "" {
+			if v != seg.literal {
+				return nil, false
+			}
+			continue
+		}
+		if v == "" {
+			return nil, false
+		}
+		vars[seg.name] = v
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return vars, true
+}
+
+// splitPath 按 / 拆分路径, 忽略开头和末尾的 /
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package router 根据请求方法和 URI 路径模板将消息分发到处理函数
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrNoRoute 没有与消息匹配的处理函数
+var ErrNoRoute = errors.New("router: no matching route")
+
+// Handler 处理匹配的消息, vars 为路径模板中提取的变量
+type Handler func(ctx context.Context, msg *message.Message, vars map[string]string) error
+
+type route struct {
+	method  secoapcore.Code
+	pattern *Pattern
+	handler Handler
+}
+
+// Router 按注册顺序匹配路由, 调用第一个匹配的处理函数
+type Router struct {
+	mu     sync.RWMutex
+	routes []route
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle 注册处理函数, pattern 无效时返回错误
+func (r *Router) Handle(method secoapcore.Code, pattern string, h Handler) error {
+	if h == nil {
+		return fmt.Errorf("router: nil handler for pattern %q", pattern)
+	}
+	p, err := Compile(pattern)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.routes = append(r.routes, route{method: method, pattern: p, handler: h})
+	r.mu.Unlock()
+	return nil
+}
+
+// Dispatch 调用与消息代码和路径匹配的第一个处理函数, 没有匹配时返回 ErrNoRoute
+func (r *Router) Dispatch(msg *message.Message) error {
+	if msg == nil {
+		return secoapcore.ErrMessageNil
+	}
+	path, err := msg.Path()
+	if err != nil && !errors.Is(err, secoapcore.ErrOptionNotFound) {
+		return err
+	}
+	code := msg.Code()
+
+	h, vars := r.match(code, path)
+	if h == nil {
+		return fmt.Errorf("%w: %v %s", ErrNoRoute, code, path)
+	}
+	ctx := msg.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return h(ctx, msg, vars)
+}
+
+func (r *Router) match(code secoapcore.Code, path string) (Handler, map[string]string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rt := range r.routes {
+		if rt.method != code {
+			continue
+		}
+		if vars, ok := rt.pattern.Match(path); ok {
+			return rt.handler, vars
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		vars    map[string]string
+		ok      bool
+	}{
+		{"/devices/{deviceID}/sensors/{sensorType}", "/devices/d1/sensors/temp", map[string]string{"deviceID": "d1", "sensorType": "temp"}, true},
+		{"/devices/{deviceID}/sensors/{sensorType}", "/devices/d1/sensors", nil, false},
+		{"/devices/{deviceID}/sensors/{sensorType}", "/devices/d1/sensors/temp/x", nil, false},
+		{"/devices/{deviceID}", "/devices//", nil, false},
+		// trailing slash
+		{"/devices/{deviceID}", "/devices/d1/", map[string]string{"deviceID": "d1"}, true},
+		{"/devices/{deviceID}/", "/devices/d1", map[string]string{"deviceID": "d1"}, true},
+		{"/", "/", map[string]string{}, true},
+		{"/", "", map[string]string{}, true},
+		// percent-encoding
+		{"/devices/{deviceID}", "/devices/a%20b", map[string]string{"deviceID": "a b"}, true},
+		{"/dev%69ces/{deviceID}", "/devices/d1", map[string]string{"deviceID": "d1"}, true},
+		{"/devices/{deviceID}", "/devices/%zz", nil, false},
+		// multi-segment wildcards
+		{"/fw/{rest...}", "/fw/a/b/c", map[string]string{"rest": "a/b/c"}, true},
+		{"/fw/{rest...}", "/fw", map[string]string{"rest": ""}, true},
+		{"/fw/{id}/{rest...}", "/fw/1/a%2Fb/c", map[string]string{"id": "1", "rest": "a/b/c"}, true},
+		{"/fw/{rest...}", "/other/a", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			p, err := Compile(tt.pattern)
+			require.NoError(t, err)
+			vars, ok := p.Match(tt.path)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.vars, vars)
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, pattern := range []string{
+		"/devices/{id}/sensors/{id}",
+		"/devices/{id}/{id...}",
+		"/fw/{rest...}/x",
+		"/devices/{}",
+		"/devices/{...}",
+		"/devices/x{id}",
+		"/devices/{a.b}",
+		"/devices/%zz",
+	} {
+		_, err := Compile(pattern)
+		require.Error(t, err, pattern)
+	}
+	require.Panics(t, func() { MustCompile("/{id}/{id}") })
+}
+
+func TestRouterDispatch(t *testing.T) {
+	r := NewRouter()
+	var got string
+	var gotVars map[string]string
+	handler := func(name string) Handler {
+		return func(ctx context.Context, msg *message.Message, vars map[string]string) error {
+			got = name
+			gotVars = vars
+			return nil
+		}
+	}
+	require.NoError(t, r.Handle(secoapcore.GET, "/devices/{deviceID}", handler("get")))
+	require.NoError(t, r.Handle(secoapcore.PUT, "/devices/{deviceID}", handler("put")))
+	require.NoError(t, r.Handle(secoapcore.GET, "/devices/{deviceID}/{rest...}", handler("rest")))
+	require.Error(t, r.Handle(secoapcore.GET, "/{id}/{id}", handler("dup")))
+	require.Error(t, r.Handle(secoapcore.GET, "/x", nil))
+
+	msg := message.NewMessage(context.Background())
+	msg.SetCode(secoapcore.PUT)
+	require.NoError(t, msg.SetPath("/devices/d1"))
+	require.NoError(t, r.Dispatch(msg))
+	require.Equal(t, "put", got)
+	require.Equal(t, map[string]string{"deviceID": "d1"}, gotVars)
+
+	msg.SetCode(secoapcore.GET)
+	require.NoError(t, msg.SetPath("/devices/d1/sensors/temp"))
+	require.NoError(t, r.Dispatch(msg))
+	require.Equal(t, "rest", got)
+	require.Equal(t, map[string]string{"deviceID": "d1", "rest": "sensors/temp"}, gotVars)
+
+	msg.SetCode(secoapcore.DELETE)
+	require.ErrorIs(t, r.Dispatch(msg), ErrNoRoute)
+	require.ErrorIs(t, r.Dispatch(nil), secoapcore.ErrMessageNil)
+
+	errHandler := errors.New("handler failed")
+	require.NoError(t, r.Handle(secoapcore.DELETE, "/devices/{deviceID}/{rest...}", func(context.Context, *message.Message, map[string]string) error {
+		return errHandler
+	}))
+	require.ErrorIs(t, r.Dispatch(msg), errHandler)
+}
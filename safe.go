@@ -0,0 +1,254 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// SafeSecoap 可并发使用的 Secoap, 所有导出的方法都由读写锁保护
+//
+// 读取消息体需要移动读取位置, 因此会读取消息体的方法 (如 Marshal, Equal) 也使用写锁.
+// 直接访问内嵌的 Secoap 字段 (Version, Message, TTL) 不受保护, GetMessage 返回的消息也不受保护.
+type SafeSecoap struct {
+	*Secoap
+	mu sync.RWMutex
+}
+
+// NewSafeSecoap 包装 s, 之后 s 只能通过返回的 SafeSecoap 访问
+func NewSafeSecoap(s *Secoap) *SafeSecoap {
+	return &SafeSecoap{Secoap: s}
+}
+
+func (s *SafeSecoap) SetContext(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetContext(ctx)
+}
+
+func (s *SafeSecoap) GetContext() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.GetContext()
+}
+
+func (s *SafeSecoap) SetVersion(ver secoapcore.Ver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetVersion(ver)
+}
+
+func (s *SafeSecoap) GetVersion() secoapcore.Ver {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.GetVersion()
+}
+
+func (s *SafeSecoap) SetMessage(msg *message.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetMessage(msg)
+}
+
+func (s *SafeSecoap) GetMessage() *message.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.GetMessage()
+}
+
+// CloneMessage 返回消息的独立副本, 可在锁外安全使用
+//
+// 复制消息体需要移动读取位置, 因此使用写锁
+func (s *SafeSecoap) CloneMessage() (*message.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Secoap.Message == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	msg := message.NewMessage(s.Secoap.GetContext())
+	if err := s.Secoap.Message.Clone(msg); err != nil {
+		return nil, err
+	}
+	msg.SetVersion(s.Secoap.Message.Version())
+	return msg, nil
+}
+
+func (s *SafeSecoap) SetPath(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.SetPath(path)
+}
+
+func (s *SafeSecoap) SetCode(code secoapcore.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetCode(code)
+}
+
+func (s *SafeSecoap) SetToken(token secoapcore.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetToken(token)
+}
+
+func (s *SafeSecoap) SetType(typ secoapcore.Type) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetType(typ)
+}
+
+func (s *SafeSecoap) SetMessageID(mid int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetMessageID(mid)
+}
+
+func (s *SafeSecoap) SetLogger(l message.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetLogger(l)
+}
+
+func (s *SafeSecoap) GetLogger() message.Logger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.GetLogger()
+}
+
+func (s *SafeSecoap) Coder() message.Coder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.Coder()
+}
+
+func (s *SafeSecoap) SetCoder(c message.Coder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.SetCoder(c)
+}
+
+func (s *SafeSecoap) Use(m ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.Use(m...)
+}
+
+// Marshal 见 Secoap.Marshal, 返回的数据是编码缓冲区的副本, 可在锁外使用
+func (s *SafeSecoap) Marshal() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneBytes(s.Secoap.Marshal())
+}
+
+func (s *SafeSecoap) MarshalSize() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.MarshalSize()
+}
+
+// MarshalWithMiddleware 见 Secoap.MarshalWithMiddleware, 返回的数据是编码缓冲区的副本
+func (s *SafeSecoap) MarshalWithMiddleware(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneBytes(s.Secoap.MarshalWithMiddleware(ctx))
+}
+
+// Validate 检查空消息时需要读取消息体长度, 因此使用写锁
+func (s *SafeSecoap) Validate() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.Validate()
+}
+
+// Broadcast 复制消息体需要移动读取位置, 因此使用写锁, send 在持有锁时调用
+func (s *SafeSecoap) Broadcast(b BroadcastMessage, send func(target string, data []byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.Broadcast(b, send)
+}
+
+func (s *SafeSecoap) ReleaseToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Secoap.ReleaseToken()
+}
+
+func (s *SafeSecoap) Unmarshal(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.Unmarshal(data)
+}
+
+func (s *SafeSecoap) UnmarshalAutoDetect(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.UnmarshalAutoDetect(data)
+}
+
+func (s *SafeSecoap) UnmarshalWithMiddleware(ctx context.Context, data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.UnmarshalWithMiddleware(ctx, data)
+}
+
+func (s *SafeSecoap) TokenStore() *secoapcore.TokenStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.TokenStore()
+}
+
+func (s *SafeSecoap) MIDManager() *secoapcore.MIDManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.MIDManager()
+}
+
+func (s *SafeSecoap) IsExpired(now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.IsExpired(now)
+}
+
+func (s *SafeSecoap) Fingerprint() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Secoap.Fingerprint()
+}
+
+// Equal 见 Secoap.Equal, other 在比较期间不能被其他协程修改
+func (s *SafeSecoap) Equal(other *Secoap) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.Equal(other)
+}
+
+// Diff 见 Secoap.Diff, other 在比较期间不能被其他协程修改
+func (s *SafeSecoap) Diff(other *Secoap) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Secoap.Diff(other)
+}
+
+func cloneBytes(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), data...), nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/internal/testutil"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeSecoapConcurrent(t *testing.T) {
+	s := NewSafeSecoap(newTestSecoap(t))
+	want, err := s.Marshal()
+	require.NoError(t, err)
+
+	errs := make(chan error, 50)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				var err error
+				switch (i + j) % 4 {
+				case 0:
+					var data []byte
+					if data, err = s.Marshal(); err == nil && !bytes.Equal(want, data) {
+						err = fmt.Errorf("unexpected data %x", data)
+					}
+				case 1:
+					_, err = s.Unmarshal(want)
+				case 2:
+					_, err = s.CloneMessage()
+				default:
+					_ = s.GetVersion()
+					_ = s.GetMessage()
+					_ = s.GetContext()
+					_ = s.Fingerprint()
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	got, err := s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestSafeSecoapCloneMessage(t *testing.T) {
+	s := NewSafeSecoap(newTestSecoap(t))
+	msg, err := s.CloneMessage()
+	require.NoError(t, err)
+	require.Empty(t, s.GetMessage().Diff(msg))
+
+	msg.SetCode(secoapcore.GET)
+	require.Equal(t, secoapcore.POST, s.GetMessage().Code())
+
+	s.SetMessage(nil)
+	_, err = s.CloneMessage()
+	require.ErrorIs(t, err, secoapcore.ErrMessageNil)
+}
+
+func newBenchSecoap() *Secoap {
+	s := NewSecoap(Version2)
+	s.SetMessage(testutil.NewV2Message(
+		testutil.WithCode(secoapcore.POST),
+		testutil.WithMessageID(0x1234),
+		testutil.WithToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04}),
+		testutil.WithPath("/iotda/v3/device/status"),
+	))
+	return s
+}
+
+func BenchmarkSecoapMarshal(b *testing.B) {
+	s := newBenchSecoap()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Marshal(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSafeSecoapMarshal(b *testing.B) {
+	s := NewSafeSecoap(newBenchSecoap())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Marshal(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSafeSecoapMarshalParallel(b *testing.B) {
+	s := NewSafeSecoap(newBenchSecoap())
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := s.Marshal(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkSecoapUnmarshal(b *testing.B) {
+	s := newBenchSecoap()
+	data, err := s.Marshal()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSafeSecoapUnmarshal(b *testing.B) {
+	s := NewSafeSecoap(newBenchSecoap())
+	data, err := s.Marshal()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
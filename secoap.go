@@ -16,11 +16,18 @@ package secoap
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
 
 	"github.com/GiterLab/go-secoap/coder/coderv0"
 	"github.com/GiterLab/go-secoap/coder/coderv1"
 	"github.com/GiterLab/go-secoap/coder/coderv2"
 	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/metrics"
 	"github.com/GiterLab/go-secoap/secoapcore"
 )
 
@@ -34,21 +41,70 @@ const (
 type Secoap struct {
 	Version secoapcore.Ver
 	Message *message.Message
+	TTL     time.Duration // 消息的有效期, 0 表示不过期
 
-	ctx *context.Context
+	coder       message.Coder
+	logger      message.Logger
+	middlewares []Middleware
+	ctx         *context.Context
+	cfg         config
+
+	acquiredToken secoapcore.Token // 从 cfg.tokenStore 取得, 尚未归还的令牌
+}
+
+// NewSecoap 创建一个Secoap协议实例, 不带选项时使用默认配置
+//
+// 所有选项在创建时校验, 任一选项无效时返回 nil. 设置了 WithTokenStore 或 WithMIDManager
+// 时, 消息的令牌和消息ID由其分配, 从令牌存储取得的令牌在请求完成后需要通过 ReleaseToken 归还
+func NewSecoap(ver secoapcore.Ver, opts ...Option) *Secoap {
+	s, err := newSecoap(ver, opts...)
+	if err != nil {
+		return nil
+	}
+	return s
 }
 
-// NewSecoap 创建一个Secoap协议实例
-func NewSecoap(ver secoapcore.Ver) *Secoap {
+// newSecoap 同 NewSecoap, 但返回选项校验的错误
+func newSecoap(ver secoapcore.Ver, opts ...Option) (*Secoap, error) {
 	if ver > 2 {
 		ver = Version2
 	}
-	ctx := context.Background()
-	return &Secoap{
-		Version: ver,
-		Message: message.NewMessage(ctx),
-		ctx:     &ctx,
+	cfg := config{ctx: context.Background()}
+	for _, o := range opts {
+		if err := o(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	ctx := cfg.ctx
+	msg := message.NewMessageWithBufferSize(ctx, cfg.initialValueBufferSize)
+	msg.SetVersion(ver)
+	if cfg.midManager != nil {
+		mid, err := cfg.midManager.Next()
+		if err != nil {
+			return nil, err
+		}
+		msg.SetMessageID(mid)
+	}
+	var token secoapcore.Token
+	if cfg.tokenStore != nil {
+		var err error
+		token, err = cfg.tokenStore.Acquire()
+		if err != nil {
+			return nil, err
+		}
+		msg.SetToken(token)
+	}
+	msg.SetModified(false)
+	s := &Secoap{
+		Version:       ver,
+		Message:       msg,
+		logger:        message.NopLogger{},
+		ctx:           &ctx,
+		cfg:           cfg,
+		acquiredToken: token,
 	}
+	s.coder = s.defaultCoder(ver)
+	return s, nil
 }
 
 func (s *Secoap) SetContext(ctx context.Context) {
@@ -59,8 +115,13 @@ func (s *Secoap) GetContext() context.Context {
 	return *s.ctx
 }
 
+// SetVersion 设置协议版本, 同时切换为该版本的默认编解码器
 func (s *Secoap) SetVersion(ver secoapcore.Ver) {
 	s.Version = ver
+	s.coder = s.defaultCoder(ver)
+	if s.Message != nil {
+		s.Message.SetVersion(ver)
+	}
 }
 
 func (s *Secoap) GetVersion() secoapcore.Ver {
@@ -75,42 +136,353 @@ func (s *Secoap) GetMessage() *message.Message {
 	return s.Message
 }
 
-func (s *Secoap) Marshal() ([]byte, error) {
-	var encoder message.Encoder
+// SetPath 设置消息路径, 等同于 s.Message.SetPath
+func (s *Secoap) SetPath(path string) error {
+	return s.Message.SetPath(path)
+}
 
-	if s.Message == nil {
-		return nil, secoapcore.ErrMessageNil
-	}
-	switch s.Version {
+// SetCode 设置消息代码, 等同于 s.Message.SetCode
+func (s *Secoap) SetCode(code secoapcore.Code) {
+	s.Message.SetCode(code)
+}
+
+// SetToken 设置消息令牌, 等同于 s.Message.SetToken
+func (s *Secoap) SetToken(token secoapcore.Token) {
+	s.Message.SetToken(token)
+}
+
+// SetType 设置消息类型, 等同于 s.Message.SetType
+func (s *Secoap) SetType(typ secoapcore.Type) {
+	s.Message.SetType(typ)
+}
+
+// SetMessageID 设置消息ID, 等同于 s.Message.SetMessageID
+func (s *Secoap) SetMessageID(mid int32) {
+	s.Message.SetMessageID(mid)
+}
+
+// versionCoder 返回协议版本对应的默认编解码器, 不支持的版本返回 nil
+func versionCoder(ver secoapcore.Ver) message.Coder {
+	switch ver {
 	case Version0:
-		encoder = coderv0.DefaultCoder
+		return coderv0.DefaultCoder
 	case Version1:
-		encoder = coderv1.DefaultCoder
+		return coderv1.DefaultCoder
 	case Version2:
-		encoder = coderv2.DefaultCoder
-	default:
+		return coderv2.DefaultCoder
+	}
+	return nil
+}
+
+// SetLogger 设置消息事件的日志记录器, nil 恢复为不记录的 NopLogger
+func (s *Secoap) SetLogger(l message.Logger) {
+	if l == nil {
+		l = message.NopLogger{}
+	}
+	s.logger = l
+}
+
+// GetLogger 返回消息事件的日志记录器
+func (s *Secoap) GetLogger() message.Logger {
+	if s.logger == nil {
+		return message.NopLogger{}
+	}
+	return s.logger
+}
+
+func (s *Secoap) metrics() metrics.Collector {
+	if s.cfg.metrics == nil {
+		return metrics.NopCollector{}
+	}
+	return s.cfg.metrics
+}
+
+// defaultCoder 返回协议版本对应的编解码器, 并应用 WithChecksum16Backend 设置的CRC16实现
+func (s *Secoap) defaultCoder(ver secoapcore.Ver) message.Coder {
+	if s.cfg.checksum != nil {
+		switch ver {
+		case Version0:
+			return &coderv0.Coder{Checksum: s.cfg.checksum}
+		case Version2:
+			return &coderv2.Coder{Checksum: s.cfg.checksum}
+		}
+	}
+	return versionCoder(ver)
+}
+
+// Coder 返回当前使用的编解码器
+func (s *Secoap) Coder() message.Coder {
+	if s.coder == nil {
+		return s.defaultCoder(s.Version)
+	}
+	return s.coder
+}
+
+// SetCoder 设置自定义编解码器, 之后 SetVersion 会恢复为该版本的默认编解码器
+func (s *Secoap) SetCoder(c message.Coder) {
+	s.coder = c
+}
+
+func (s *Secoap) Marshal() ([]byte, error) {
+	return s.marshalMessage(s.Message)
+}
+
+// marshalMessage 使用 s 的编解码器和长度限制编码 msg, 并记录指标和日志
+func (s *Secoap) marshalMessage(msg *message.Message) ([]byte, error) {
+	data, err := s.marshal(msg)
+	if err != nil {
+		s.metrics().IncError(s.Version, metrics.ErrorType(err))
+		return nil, err
+	}
+	s.metrics().IncEncoded(s.Version, msg.Code())
+	s.metrics().ObserveMessageSize(s.Version, len(data))
+	s.GetLogger().LogMessage(s.GetContext(), "marshal", msg)
+	return data, nil
+}
+
+func (s *Secoap) marshal(m *message.Message) ([]byte, error) {
+	if m == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	coder := s.Coder()
+	if coder == nil {
 		return nil, secoapcore.ErrMessageInvalidVersion
 	}
+	if s.cfg.maxPayloadSize > 0 {
+		size, err := m.BodySize()
+		if err != nil {
+			return nil, err
+		}
+		if size > int64(s.cfg.maxPayloadSize) {
+			return nil, fmt.Errorf("%w: %d > %d", secoapcore.ErrPayloadTooLarge, size, s.cfg.maxPayloadSize)
+		}
+	}
+	if s.cfg.maxMessageSize <= 0 && s.cfg.maxMarshalBufferSize <= 0 {
+		return m.MarshalWithEncoder(coder)
+	}
+
+	size, err := m.MarshalSizeWithEncoder(coder)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.maxMessageSize > 0 && size > s.cfg.maxMessageSize {
+		return nil, fmt.Errorf("%w: %d > %d", secoapcore.ErrMessageTooLarge, size, s.cfg.maxMessageSize)
+	}
+	if s.cfg.maxMarshalBufferSize <= 0 || size <= s.cfg.maxMarshalBufferSize {
+		return m.MarshalWithEncoder(coder)
+	}
+	// 超过可复用缓冲区的上限, 编码到新分配的缓冲区中
+	msg, err := m.ToSecoapCoreMessage()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := coder.Encode(msg, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// TokenStore 返回 WithTokenStore 设置的令牌存储, 未设置时返回 nil
+func (s *Secoap) TokenStore() *secoapcore.TokenStore {
+	return s.cfg.tokenStore
+}
+
+// ReleaseToken 将创建时从 WithTokenStore 取得的令牌归还到令牌存储, 之后该令牌可被其他请求使用
+//
+// 应在请求完成 (收到响应或超时) 后调用, 重复调用或未设置 WithTokenStore 时不做任何操作
+func (s *Secoap) ReleaseToken() {
+	if s.cfg.tokenStore == nil || s.acquiredToken == nil {
+		return
+	}
+	s.cfg.tokenStore.Release(s.acquiredToken)
+	s.acquiredToken = nil
+}
+
+// MIDManager 返回 WithMIDManager 设置的消息ID管理器, 未设置时返回 nil
+func (s *Secoap) MIDManager() *secoapcore.MIDManager {
+	return s.cfg.midManager
+}
+
+// MarshalSize 返回消息编码后的字节数, 可用于预分配发送缓冲区
+func (s *Secoap) MarshalSize() (int, error) {
+	if s.Message == nil {
+		return 0, secoapcore.ErrMessageNil
+	}
+	coder := s.Coder()
+	if coder == nil {
+		return 0, secoapcore.ErrMessageInvalidVersion
+	}
+
+	return s.Message.MarshalSizeWithEncoder(coder)
+}
 
-	return s.Message.MarshalWithEncoder(encoder)
+// Validate 按当前协议版本检查消息是否可以发送, 见 message.Message.Validate
+func (s *Secoap) Validate() []error {
+	if s.Message == nil {
+		return []error{&message.ValidationError{Field: "Message", Reason: "message is nil", Err: secoapcore.ErrMessageNil}}
+	}
+	return s.Message.Validate(s.Version)
 }
 
 func (s *Secoap) Unmarshal(data []byte) (int, error) {
-	var decoder message.Decoder
+	if s.Message == nil {
+		return 0, secoapcore.ErrMessageNil
+	}
+	coder := s.Coder()
+	if coder == nil {
+		return 0, secoapcore.ErrMessageInvalidVersion
+	}
+
+	n, err := s.Message.UnmarshalWithDecoder(coder, data)
+	if err != nil {
+		s.metrics().IncError(s.Version, metrics.ErrorType(err))
+		return n, err
+	}
+	s.metrics().IncDecoded(s.Version, s.Message.Code())
+	s.metrics().ObserveMessageSize(s.Version, len(data))
+	s.GetLogger().LogMessage(s.GetContext(), "unmarshal", s.Message)
+	return n, nil
+}
 
+// UnmarshalAutoDetect 根据数据包头部自动识别协议版本并解码
+//
+// 识别到的版本会设置到 s.Version 并切换为该版本的默认编解码器,
+// 不支持的版本返回 ErrMessageInvalidVersion, 此时 s 不会被修改
+func (s *Secoap) UnmarshalAutoDetect(data []byte) (int, error) {
 	if s.Message == nil {
 		return 0, secoapcore.ErrMessageNil
 	}
-	switch s.Version {
-	case Version0:
-		decoder = coderv0.DefaultCoder
-	case Version1:
-		decoder = coderv1.DefaultCoder
-	case Version2:
-		decoder = coderv2.DefaultCoder
-	default:
+	ver, err := secoapcore.GetVersion(data)
+	if err != nil {
+		return 0, err
+	}
+	if versionCoder(ver) == nil {
 		return 0, secoapcore.ErrMessageInvalidVersion
 	}
+	s.SetVersion(ver)
+
+	return s.Unmarshal(data)
+}
+
+// NewSecoapFromBytes 解码数据包并创建Secoap协议实例, 协议版本根据数据包自动识别
+func NewSecoapFromBytes(data []byte) (*Secoap, int, error) {
+	s := NewSecoap(Version2)
+	n, err := s.UnmarshalAutoDetect(data)
+	if err != nil {
+		return nil, n, err
+	}
+	return s, n, nil
+}
+
+// IsExpired 判断消息是否已超过 TTL, TTL 为 0 时总是返回 false
+func (s *Secoap) IsExpired(now time.Time) bool {
+	if s.TTL <= 0 || s.Message == nil {
+		return false
+	}
+	return s.Message.IsExpired(now, s.TTL)
+}
+
+// Fingerprint 返回消息的稳定指纹, 可用作去重缓存的键
+//
+// 使用 FNV-1a 哈希, 覆盖 Ver, Type, Code, MessageID, Token 和路径的前 4 个字节.
+func (s *Secoap) Fingerprint() uint64 {
+	if s.Message == nil {
+		return 0
+	}
+	var buf [8]byte
+	h := fnv.New64a()
+	buf[0] = byte(s.Version)
+	binary.BigEndian.PutUint16(buf[1:3], uint16(s.Message.Type()))
+	buf[3] = byte(s.Message.Code())
+	binary.BigEndian.PutUint32(buf[4:8], uint32(s.Message.MessageID()))
+	h.Write(buf[:])
+	h.Write(s.Message.Token())
+	path, _ := s.Message.Path()
+	if len(path) > 4 {
+		path = path[:4]
+	}
+	h.Write([]byte(path))
+	return h.Sum64()
+}
+
+// Equal 比较两个协议实例的版本和消息是否相同, 用于测试断言
+func (s *Secoap) Equal(other *Secoap) bool {
+	return s.Diff(other) == ""
+}
+
+// Diff 返回两个协议实例之间差异的可读描述, 相同时返回空字符串
+func (s *Secoap) Diff(other *Secoap) string {
+	if s == nil || other == nil {
+		if s == other {
+			return ""
+		}
+		return fmt.Sprintf("Secoap: %v != %v", s, other)
+	}
+	var diffs []string
+	if s.Version != other.Version {
+		diffs = append(diffs, fmt.Sprintf("Version: %v != %v", s.Version, other.Version))
+	}
+	if d := s.Message.Diff(other.Message); d != "" {
+		diffs = append(diffs, d)
+	}
+	return strings.Join(diffs, "\n")
+}
+
+// EncodeDecodeRoundTrip 使用指定版本的编解码器编码消息后立即解码, 返回解码得到的新消息
+//
+// 用于往返测试和协议校验, 返回的消息与 msg 不共享缓冲区
+func EncodeDecodeRoundTrip(ver secoapcore.Ver, msg *message.Message) (*message.Message, error) {
+	if msg == nil {
+		return nil, secoapcore.ErrMessageNil
+	}
+	coder := versionCoder(ver)
+	if coder == nil {
+		return nil, secoapcore.ErrMessageInvalidVersion
+	}
+	data, err := msg.MarshalWithEncoder(coder)
+	if err != nil {
+		return nil, err
+	}
+	out := message.NewMessage(msg.Context())
+	if _, err := out.UnmarshalWithDecoder(coder, data); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-	return s.Message.UnmarshalWithDecoder(decoder, data)
+// ParseVersion0 解析版本0的数据包, 返回的消息引用 data 中的数据
+func ParseVersion0(data []byte) (*secoapcore.Message, error) {
+	return parse(coderv0.DefaultCoder, data)
+}
+
+// ParseVersion1 解析版本1(标准COAP)的数据包, 返回的消息引用 data 中的数据
+func ParseVersion1(data []byte) (*secoapcore.Message, error) {
+	return parse(coderv1.DefaultCoder, data)
+}
+
+// ParseVersion2 解析版本2的数据包, 返回的消息引用 data 中的数据
+func ParseVersion2(data []byte) (*secoapcore.Message, error) {
+	return parse(coderv2.DefaultCoder, data)
+}
+
+func parse(decoder message.Decoder, data []byte) (*secoapcore.Message, error) {
+	msg := &secoapcore.Message{
+		Opts:      secoapcore.NewOptions(secoapcore.DefaultOptionsCapacity),
+		MessageID: -1,
+		Type:      secoapcore.Unset,
+	}
+	for {
+		_, err := decoder.Decode(data, msg)
+		if errors.Is(err, secoapcore.ErrOptionsTooSmall) {
+			// increase buffer size and try again
+			msg.Opts = secoapcore.NewOptions(cap(msg.Opts) * 2)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
 }
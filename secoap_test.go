@@ -0,0 +1,340 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/coder/coderv1"
+	"github.com/GiterLab/go-secoap/coder/coderv2"
+	"github.com/GiterLab/go-secoap/internal/testutil"
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSecoap(t *testing.T) *Secoap {
+	s := NewSecoap(Version2)
+	s.SetMessage(testutil.NewV2Message(
+		testutil.WithCode(secoapcore.POST),
+		testutil.WithMessageID(0x1234),
+		testutil.WithToken(secoapcore.Token{0x01, 0x02, 0x03, 0x04}),
+		testutil.WithPath("/iotda/v3/device/status"),
+	))
+	return s
+}
+
+func TestSecoapFingerprint(t *testing.T) {
+	fp := newTestSecoap(t).Fingerprint()
+	require.Equal(t, fp, newTestSecoap(t).Fingerprint())
+
+	tests := []struct {
+		name   string
+		modify func(s *Secoap)
+	}{
+		{
+			name:   "version",
+			modify: func(s *Secoap) { s.SetVersion(Version1) },
+		},
+		{
+			name:   "type",
+			modify: func(s *Secoap) { s.SetType(secoapcore.NonConfirmable) },
+		},
+		{
+			name:   "code",
+			modify: func(s *Secoap) { s.SetCode(secoapcore.PUT) },
+		},
+		{
+			name:   "message id",
+			modify: func(s *Secoap) { s.SetMessageID(0x1235) },
+		},
+		{
+			name:   "token",
+			modify: func(s *Secoap) { s.SetToken(secoapcore.Token{0x01, 0x02, 0x03, 0x05}) },
+		},
+		{
+			name:   "path",
+			modify: func(s *Secoap) { require.NoError(t, s.SetPath("/device")) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSecoap(t)
+			tt.modify(s)
+			require.NotEqual(t, fp, s.Fingerprint())
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		ver   secoapcore.Ver
+		parse func(data []byte) (*secoapcore.Message, error)
+	}{
+		{
+			name:  "v0",
+			ver:   Version0,
+			parse: ParseVersion0,
+		},
+		{
+			name:  "v1",
+			ver:   Version1,
+			parse: ParseVersion1,
+		},
+		{
+			name:  "v2",
+			ver:   Version2,
+			parse: ParseVersion2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSecoap(t)
+			s.SetVersion(tt.ver)
+			data, err := s.Marshal()
+			require.NoError(t, err)
+			msg, err := tt.parse(data)
+			require.NoError(t, err)
+			require.Equal(t, tt.ver, msg.Ver)
+			require.Equal(t, s.Message.Type(), msg.Type)
+			if tt.ver != Version0 {
+				require.Equal(t, s.Message.MessageID(), msg.MessageID)
+				require.Equal(t, "iotda/v3/device/status", msg.Opts.PathString())
+			}
+		})
+	}
+
+	_, err := ParseVersion2([]byte{0x40, 0x01})
+	require.ErrorIs(t, err, secoapcore.ErrMessageTruncated)
+}
+
+func TestSecoapEqual(t *testing.T) {
+	s := newTestSecoap(t)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+
+	other := NewSecoap(Version2)
+	_, err = other.Unmarshal(data)
+	require.NoError(t, err)
+	require.True(t, s.Equal(other), s.Diff(other))
+	require.Empty(t, s.Diff(other))
+
+	other.Message.SetCode(secoapcore.GET)
+	require.False(t, s.Equal(other))
+	require.Equal(t, "Code: POST != GET", s.Diff(other))
+
+	other.SetVersion(Version1)
+	require.Contains(t, s.Diff(other), "Version: Ver2 != Ver1")
+}
+
+func TestSecoapMarshalSize(t *testing.T) {
+	for _, ver := range []secoapcore.Ver{Version0, Version1, Version2} {
+		s := newTestSecoap(t)
+		s.SetVersion(ver)
+		size, err := s.MarshalSize()
+		require.NoError(t, err)
+		data, err := s.Marshal()
+		require.NoError(t, err)
+		require.Equal(t, len(data), size)
+
+		msg, err := s.Message.ToSecoapCoreMessage()
+		require.NoError(t, err)
+		size, err = msg.EncodedSize(ver)
+		require.NoError(t, err)
+		require.Equal(t, len(data), size)
+	}
+	s := newTestSecoap(t)
+	s.SetVersion(3)
+	msg, err := s.Message.ToSecoapCoreMessage()
+	require.NoError(t, err)
+	_, err = msg.EncodedSize(3)
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+	_, err = s.MarshalSize()
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+}
+
+type countingCoder struct {
+	message.Coder
+	encodes, decodes int
+}
+
+func (c *countingCoder) Encode(m secoapcore.Message, buf []byte) (int, error) {
+	c.encodes++
+	return c.Coder.Encode(m, buf)
+}
+
+func (c *countingCoder) Decode(data []byte, m *secoapcore.Message) (int, error) {
+	c.decodes++
+	return c.Coder.Decode(data, m)
+}
+
+func TestSecoapSetCoder(t *testing.T) {
+	s := newTestSecoap(t)
+	require.Equal(t, coderv2.DefaultCoder, s.Coder())
+
+	c := &countingCoder{Coder: s.Coder()}
+	s.SetCoder(c)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, 1, c.encodes)
+
+	r := NewSecoap(Version2)
+	r.SetCoder(c)
+	_, err = r.Unmarshal(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, c.decodes)
+	require.True(t, s.Equal(r))
+
+	s.SetVersion(Version1)
+	require.Equal(t, coderv1.DefaultCoder, s.Coder())
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	msg := testutil.NewV2Message(
+		testutil.WithCode(secoapcore.POST),
+		testutil.WithToken(secoapcore.Token{0x01, 0x02}),
+		testutil.WithPath("/a/b"),
+		testutil.WithPayload(secoapcore.AppJSON, []byte(`{"a":1}`)),
+	)
+	for _, ver := range []secoapcore.Ver{Version1, Version2} {
+		got, err := EncodeDecodeRoundTrip(ver, msg)
+		require.NoError(t, err)
+		require.NotSame(t, msg, got)
+		msg.SetVersion(ver)
+		require.True(t, msg.Equal(got), msg.Diff(got))
+	}
+
+	_, err := EncodeDecodeRoundTrip(3, msg)
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+	_, err = EncodeDecodeRoundTrip(Version2, nil)
+	require.ErrorIs(t, err, secoapcore.ErrMessageNil)
+}
+
+func TestUnmarshalAutoDetect(t *testing.T) {
+	tests := []struct {
+		ver   secoapcore.Ver
+		first byte
+	}{
+		{ver: Version0, first: 0x00},
+		{ver: Version1, first: 0x40},
+		{ver: Version2, first: 0x80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ver.String(), func(t *testing.T) {
+			s := newTestSecoap(t)
+			s.SetVersion(tt.ver)
+			data, err := s.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, tt.first, data[0]&0xC0)
+
+			r := NewSecoap(Version2)
+			if tt.ver == Version2 {
+				r.SetVersion(Version1)
+			}
+			n, err := r.UnmarshalAutoDetect(data)
+			require.NoError(t, err)
+			require.Equal(t, len(data), n)
+			require.Equal(t, tt.ver, r.Version)
+			require.Equal(t, data, mustMarshal(t, r))
+
+			r, n, err = NewSecoapFromBytes(data)
+			require.NoError(t, err)
+			require.Equal(t, len(data), n)
+			require.Equal(t, tt.ver, r.Version)
+		})
+	}
+
+	s := newTestSecoap(t)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	data[0] |= 0xC0
+	before := s.Fingerprint()
+	_, err = s.UnmarshalAutoDetect(data)
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+	require.Equal(t, Version2, s.Version)
+	require.Equal(t, before, s.Fingerprint())
+	_, _, err = NewSecoapFromBytes(data)
+	require.ErrorIs(t, err, secoapcore.ErrMessageInvalidVersion)
+}
+
+func mustMarshal(t *testing.T, s *Secoap) []byte {
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	return data
+}
+
+type recordingLogger struct {
+	events []string
+}
+
+func (l *recordingLogger) LogMessage(_ context.Context, event string, _ *message.Message) {
+	l.events = append(l.events, event)
+}
+
+func TestSecoapLogger(t *testing.T) {
+	s := newTestSecoap(t)
+	require.IsType(t, message.NopLogger{}, s.GetLogger())
+	l := &recordingLogger{}
+	s.SetLogger(l)
+	data, err := s.Marshal()
+	require.NoError(t, err)
+	_, err = s.Unmarshal(data)
+	require.NoError(t, err)
+	_, err = s.Unmarshal(data[:3])
+	require.Error(t, err)
+	require.Equal(t, []string{"marshal", "unmarshal"}, l.events)
+
+	s.SetLogger(nil)
+	require.IsType(t, message.NopLogger{}, s.GetLogger())
+}
+
+func TestSecoapValidate(t *testing.T) {
+	s := newTestSecoap(t)
+	require.Empty(t, s.Validate())
+
+	s.SetMessageID(-1)
+	errs := s.Validate()
+	require.Len(t, errs, 1)
+	var ve *message.ValidationError
+	require.ErrorAs(t, errs[0], &ve)
+	require.Equal(t, "MessageID", ve.Field)
+
+	s.Message = nil
+	errs = s.Validate()
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], secoapcore.ErrMessageNil)
+}
+
+func TestSecoapIsExpired(t *testing.T) {
+	s := newTestSecoap(t)
+	now := s.Message.CreatedAt.Add(time.Minute)
+	require.False(t, s.IsExpired(now))
+	s.TTL = time.Second
+	require.True(t, s.IsExpired(now))
+	s.TTL = time.Hour
+	require.False(t, s.IsExpired(now))
+}
+
+func TestRegisterMediaType(t *testing.T) {
+	const custom secoapcore.MediaType = 65100
+	require.NoError(t, RegisterMediaType(custom, "application/vnd.giterlab.test"))
+	defer UnregisterMediaType(custom)
+	require.Equal(t, "application/vnd.giterlab.test", custom.String())
+	require.Contains(t, RegisteredMediaTypes(), custom)
+	require.ErrorIs(t, RegisterMediaType(custom, "text/other"), secoapcore.ErrMediaTypeConflict)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CodeError 响应码对应的错误, 由 ErrFromCode 创建
+type CodeError struct {
+	Code    Code
+	Message string
+}
+
+func (e *CodeError) Error() string {
+	return fmt.Sprintf("response code %d: %s", uint8(e.Code), e.Message)
+}
+
+// Is 响应码相同的 CodeError 视为同一错误, 可用于 errors.Is(err, &CodeError{Code: NotFound})
+func (e *CodeError) Is(target error) bool {
+	t, ok := target.(*CodeError)
+	return ok && t.Code == e.Code
+}
+
+// ErrFromCode 将响应码转换为错误
+//
+// 空消息, 请求方法和成功响应(2.xx, 6.xx)返回 nil, 其他响应码返回 *CodeError,
+// Message 取自 Code.String().
+func ErrFromCode(code Code) error {
+	switch code.Class() {
+	case ClassEmpty, ClassRequest, ClassSuccess, ClassGiterlabSuccess:
+		return nil
+	}
+	return &CodeError{Code: code, Message: code.String()}
+}
+
+// CodeFromError 返回 err 链中 *CodeError 的响应码
+func CodeFromError(err error) (Code, bool) {
+	var ce *CodeError
+	if errors.As(err, &ce) {
+		return ce.Code, true
+	}
+	return 0, false
+}
+
+// IsRetryable 判断 err 是否为可重试的响应码错误, 如服务暂时不可用或请求超时
+func IsRetryable(err error) bool {
+	code, ok := CodeFromError(err)
+	if !ok {
+		return false
+	}
+	switch code {
+	case ServiceUnavailable, GatewayTimeout, GiterlabErrnoRequestTimeout, GiterlabErrnoCacheServiceErrors:
+		return true
+	}
+	return false
+}
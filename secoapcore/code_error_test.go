@@ -0,0 +1,67 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrFromCode(t *testing.T) {
+	for _, code := range []Code{Empty, GET, Content, Changed, GiterlabErrnoOk, GiterlabErrnoFirmwareUpdate} {
+		require.NoError(t, ErrFromCode(code), "%v", code)
+	}
+
+	err := ErrFromCode(NotFound)
+	require.EqualError(t, err, "response code 132: NotFound")
+	var ce *CodeError
+	require.ErrorAs(t, err, &ce)
+	require.Equal(t, NotFound, ce.Code)
+
+	err = ErrFromCode(GiterlabErrnoIllegalKey)
+	require.EqualError(t, err, "response code 224: GiterlabErrnoIllegalKey")
+	require.ErrorIs(t, err, &CodeError{Code: GiterlabErrnoIllegalKey})
+	require.False(t, errors.Is(err, &CodeError{Code: NotFound}))
+
+	// reserved codes are errors too
+	require.EqualError(t, ErrFromCode(Code(40)), "response code 40: Code(40)")
+}
+
+func TestCodeFromError(t *testing.T) {
+	_, ok := CodeFromError(nil)
+	require.False(t, ok)
+	_, ok = CodeFromError(ErrMessageTruncated)
+	require.False(t, ok)
+
+	wrapped := fmt.Errorf("request /a: %w", ErrFromCode(BadRequest))
+	code, ok := CodeFromError(wrapped)
+	require.True(t, ok)
+	require.Equal(t, BadRequest, code)
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, code := range []Code{ServiceUnavailable, GatewayTimeout, GiterlabErrnoRequestTimeout, GiterlabErrnoCacheServiceErrors} {
+		require.True(t, IsRetryable(ErrFromCode(code)), "%v", code)
+		require.True(t, IsRetryable(fmt.Errorf("wrapped: %w", ErrFromCode(code))), "%v", code)
+	}
+	for _, code := range []Code{NotFound, InternalServerError, GiterlabErrnoIllegalKey} {
+		require.False(t, IsRetryable(ErrFromCode(code)), "%v", code)
+	}
+	require.False(t, IsRetryable(nil))
+	require.False(t, IsRetryable(errors.New("timeout")))
+}
@@ -15,22 +15,84 @@
 package secoapcore
 
 import (
+	"crypto/subtle"
 	"hash/crc32"
+	"sync/atomic"
 
 	"github.com/GiterLab/crc16"
 )
 
-// crc16BytesModbus 对数据流进行CRC16校验（CRC16-MODBUS）
-func crc16BytesModbus(data []byte) uint16 {
-	table := crc16.MakeTable(crc16.CRC16_MODBUS)
-	h := crc16.New(table)
-	h.Write(data)
-	return h.Sum16()
+// ChecksumBackend 计算CRC16校验值, V0 和 V2 的编解码器使用当前设置的实现
+type ChecksumBackend interface {
+	Checksum16(data []byte) uint16
 }
 
-// CRC16Bytes 对数据流进行CRC16校验
+type crc16Backend struct {
+	name  string
+	table *crc16.Table
+}
+
+func (b crc16Backend) Checksum16(data []byte) uint16 {
+	return crc16.Checksum(data, b.table)
+}
+
+func (b crc16Backend) String() string {
+	return b.name
+}
+
+var (
+	// CRC16ModbusBackend CRC16-MODBUS, 默认实现
+	CRC16ModbusBackend ChecksumBackend = crc16Backend{"CRC16-MODBUS", crc16.MakeTable(crc16.CRC16_MODBUS)}
+	// CRC16CCITTBackend CRC16-CCITT (CCITT-FALSE)
+	CRC16CCITTBackend ChecksumBackend = crc16Backend{"CRC16-CCITT", crc16.MakeTable(crc16.CRC16_CCITT_FALSE)}
+	// CRC16IBMBackend CRC16-IBM (ARC)
+	CRC16IBMBackend ChecksumBackend = crc16Backend{"CRC16-IBM", crc16.MakeTable(crc16.CRC16_ARC)}
+)
+
+type checksumBackendHolder struct {
+	ChecksumBackend
+}
+
+var crc16backend atomic.Value
+
+func init() {
+	crc16backend.Store(checksumBackendHolder{CRC16ModbusBackend})
+}
+
+// SetCRC16Backend 设置CRC16的实现, nil 恢复为默认的 CRC16-MODBUS
+func SetCRC16Backend(b ChecksumBackend) {
+	if b == nil {
+		b = CRC16ModbusBackend
+	}
+	crc16backend.Store(checksumBackendHolder{b})
+}
+
+// GetCRC16Backend 返回当前的CRC16实现
+func GetCRC16Backend() ChecksumBackend {
+	return crc16backend.Load().(checksumBackendHolder).ChecksumBackend
+}
+
+// CRC16Bytes 对数据流进行CRC16校验, 算法由 SetCRC16Backend 设置, 默认为 CRC16-MODBUS
 func CRC16Bytes(data []byte) uint16 {
-	return crc16BytesModbus(data)
+	return GetCRC16Backend().Checksum16(data)
+}
+
+// CRC16Verify 校验数据流的CRC16值是否与 checksum 一致(常量时间比较)
+func CRC16Verify(data []byte, checksum uint16) bool {
+	return subtle.ConstantTimeEq(int32(CRC16Bytes(data)), int32(checksum)) == 1
+}
+
+// CRC16BytesWith 使用指定的实现计算CRC16校验值, b 为 nil 时使用当前设置的实现
+func CRC16BytesWith(b ChecksumBackend, data []byte) uint16 {
+	if b == nil {
+		b = GetCRC16Backend()
+	}
+	return b.Checksum16(data)
+}
+
+// CRC16VerifyWith 使用指定的实现校验CRC16值(常量时间比较), b 为 nil 时使用当前设置的实现
+func CRC16VerifyWith(b ChecksumBackend, data []byte, checksum uint16) bool {
+	return subtle.ConstantTimeEq(int32(CRC16BytesWith(b, data)), int32(checksum)) == 1
 }
 
 // CRC32Bytes 计算一个数据流的CRC32值
@@ -42,3 +104,8 @@ func CRC32Bytes(data []byte) uint32 {
 func CRC32String(str string) uint32 {
 	return crc32.ChecksumIEEE([]byte(str))
 }
+
+// CRC32Verify 校验数据流的CRC32值是否与 checksum 一致(常量时间比较)
+func CRC32Verify(data []byte, checksum uint32) bool {
+	return subtle.ConstantTimeEq(int32(CRC32Bytes(data)), int32(checksum)) == 1
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRCVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "bytes",
+			data: []byte{0x00, 0x01, 0x02, 0x03},
+		},
+		{
+			name: "string",
+			data: []byte("123456789"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.True(t, CRC16Verify(tt.data, CRC16Bytes(tt.data)))
+			require.False(t, CRC16Verify(tt.data, ^CRC16Bytes(tt.data)))
+			require.True(t, CRC32Verify(tt.data, CRC32Bytes(tt.data)))
+			require.False(t, CRC32Verify(tt.data, ^CRC32Bytes(tt.data)))
+		})
+	}
+}
+
+func TestCRC16Backend(t *testing.T) {
+	tests := []struct {
+		backend ChecksumBackend
+		data    string
+		want    uint16
+	}{
+		{backend: CRC16ModbusBackend, data: "123456789", want: 0x4B37},
+		{backend: CRC16ModbusBackend, data: "", want: 0xFFFF},
+		{backend: CRC16CCITTBackend, data: "123456789", want: 0x29B1},
+		{backend: CRC16CCITTBackend, data: "", want: 0xFFFF},
+		{backend: CRC16IBMBackend, data: "123456789", want: 0xBB3D},
+		{backend: CRC16IBMBackend, data: "", want: 0x0000},
+	}
+	t.Cleanup(func() { SetCRC16Backend(nil) })
+	require.Equal(t, CRC16ModbusBackend, GetCRC16Backend())
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%q", tt.backend, tt.data), func(t *testing.T) {
+			require.Equal(t, tt.want, tt.backend.Checksum16([]byte(tt.data)))
+			SetCRC16Backend(tt.backend)
+			require.Equal(t, tt.want, CRC16Bytes([]byte(tt.data)))
+			require.True(t, CRC16Verify([]byte(tt.data), tt.want))
+		})
+	}
+	SetCRC16Backend(nil)
+	require.Equal(t, CRC16ModbusBackend, GetCRC16Backend())
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// maxDeltaLen is the MaxLen of the ObserveDelta option.
+const maxDeltaLen = 9
+
+// DeltaEncoder encodes observed uint64 values as the signed difference to the
+// value last sent with the same token.
+//
+// A delta is stored varint encoded in the ObserveDelta option and the payload
+// is empty. The first value of a token, and a delta which does not fit into
+// the option, is sent as the full value: the payload holds the uvarint encoded
+// value and the ObserveDelta option is absent.
+type DeltaEncoder struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func NewDeltaEncoder() *DeltaEncoder {
+	return &DeltaEncoder{last: make(map[string]uint64)}
+}
+
+// Encode sets the payload and the ObserveDelta option of m for value, m.Token selects the stream.
+func (e *DeltaEncoder) Encode(m *Message, value uint64) {
+	e.mu.Lock()
+	last, ok := e.last[string(m.Token)]
+	e.last[string(m.Token)] = value
+	e.mu.Unlock()
+
+	if ok {
+		buf := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutVarint(buf, int64(value-last))
+		if n <= maxDeltaLen {
+			m.Opts = m.Opts.Set(Option{ID: ObserveDelta, Value: buf[:n]})
+			m.Payload = nil
+			return
+		}
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, value)
+	m.Opts = m.Opts.Remove(ObserveDelta)
+	m.Payload = buf[:n]
+}
+
+// Reset forgets the last value of token, the next value is sent in full.
+func (e *DeltaEncoder) Reset(token Token) {
+	e.mu.Lock()
+	delete(e.last, string(token))
+	e.mu.Unlock()
+}
+
+// DeltaDecoder reconstructs the values encoded by DeltaEncoder.
+type DeltaDecoder struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func NewDeltaDecoder() *DeltaDecoder {
+	return &DeltaDecoder{last: make(map[string]uint64)}
+}
+
+// Decode returns the absolute value carried by m.
+func (d *DeltaDecoder) Decode(m *Message) (uint64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	opt, ok := m.Opts.FindFirst(ObserveDelta)
+	if !ok {
+		value, n := binary.Uvarint(m.Payload)
+		if n <= 0 || n != len(m.Payload) {
+			return 0, fmt.Errorf("%w: invalid full value", ErrInvalidEncoding)
+		}
+		d.last[string(m.Token)] = value
+		return value, nil
+	}
+	last, ok := d.last[string(m.Token)]
+	if !ok {
+		return 0, fmt.Errorf("%w: delta without a previous value", ErrInvalidEncoding)
+	}
+	data := opt.ToBytes()
+	delta, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return 0, fmt.Errorf("%w: invalid delta", ErrInvalidEncoding)
+	}
+	value := last + uint64(delta)
+	d.last[string(m.Token)] = value
+	return value, nil
+}
+
+// Reset forgets the last value of token.
+func (d *DeltaDecoder) Reset(token Token) {
+	d.mu.Lock()
+	delete(d.last, string(token))
+	d.mu.Unlock()
+}
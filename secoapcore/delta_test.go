@@ -0,0 +1,107 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// bodySize returns the number of bytes of the options and the payload on the wire.
+func bodySize(t *testing.T, m Message) int {
+	n, err := m.Opts.Marshal(nil)
+	require.True(t, errors.Is(err, ErrTooSmall))
+	if len(m.Payload) > 0 {
+		n += 1 + len(m.Payload)
+	}
+	return n
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	enc := NewDeltaEncoder()
+	dec := NewDeltaDecoder()
+	values := []uint64{100, 101, 102, 200, 150, 0, math.MaxUint64, 1}
+	for _, tok := range []Token{{0x01}, {0x02, 0x03}} {
+		for i, v := range values {
+			m := Message{Token: tok, Opts: NewOptions(DefaultOptionsCapacity)}
+			enc.Encode(&m, v)
+			require.Equal(t, i == 0, !m.Opts.HasOption(ObserveDelta), "value %d", v)
+			got, err := dec.Decode(&m)
+			require.NoError(t, err)
+			require.Equal(t, v, got)
+		}
+	}
+
+	// after a reset the full value is sent again
+	enc.Reset(Token{0x01})
+	m := Message{Token: Token{0x01}}
+	enc.Encode(&m, 5)
+	require.False(t, m.Opts.HasOption(ObserveDelta))
+	require.Equal(t, []byte{5}, m.Payload)
+}
+
+func TestDeltaLargeDifference(t *testing.T) {
+	enc := NewDeltaEncoder()
+	dec := NewDeltaDecoder()
+	for _, v := range []uint64{0, 1 << 63, 3} {
+		m := Message{}
+		enc.Encode(&m, v)
+		for _, o := range m.Opts {
+			require.LessOrEqual(t, len(o.ToBytes()), 9)
+		}
+		got, err := dec.Decode(&m)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+}
+
+func TestDeltaDecodeErrors(t *testing.T) {
+	dec := NewDeltaDecoder()
+	m := Message{Token: Token{0x01}, Opts: Options{{ID: ObserveDelta, Value: []byte{0x02}}}}
+	_, err := dec.Decode(&m)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+
+	_, err = dec.Decode(&Message{Payload: []byte{0x80}})
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+
+	_, err = dec.Decode(&Message{Token: Token{0x01}, Payload: []byte{0x01}})
+	require.NoError(t, err)
+	m.Opts[0].Value = []byte{0x80}
+	_, err = dec.Decode(&m)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+	dec.Reset(Token{0x01})
+	m.Opts[0].Value = []byte{0x02}
+	_, err = dec.Decode(&m)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+}
+
+func TestDeltaSmallerThanRaw(t *testing.T) {
+	enc := NewDeltaEncoder()
+	raw, delta := 0, 0
+	for _, v := range []uint64{100, 101, 102, 200} {
+		m := Message{Token: Token{0x01, 0x02}, Opts: NewOptions(DefaultOptionsCapacity)}
+		enc.Encode(&m, v)
+		delta += bodySize(t, m)
+
+		full := Message{Payload: make([]byte, 8)}
+		binary.BigEndian.PutUint64(full.Payload, v)
+		raw += bodySize(t, full)
+	}
+	require.Less(t, delta, raw)
+}
@@ -16,6 +16,7 @@ package secoapcore
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -26,6 +27,14 @@ var (
 	ErrInvalidValueLength     = errors.New("invalid value length")
 	ErrInvalidEncoding        = errors.New("invalid encoding")
 
+	// ErrTokenTooLong and ErrTokenRequired wrap ErrInvalidTokenLen, so errors.Is(err, ErrInvalidTokenLen) still matches.
+	ErrTokenTooLong  = fmt.Errorf("%w: token exceeds %d bytes", ErrInvalidTokenLen, MaxTokenSize)
+	ErrTokenRequired = fmt.Errorf("%w: token is required", ErrInvalidTokenLen)
+
+	ErrTokenStoreFull = errors.New("token store is full")
+	ErrTokenCollision = errors.New("cannot generate unique token")
+	ErrMIDWindowFull  = errors.New("too many pending message ids")
+
 	ErrOptionTruncated              = errors.New("option truncated")
 	ErrOptionUnexpectedExtendMarker = errors.New("option unexpected extend marker")
 	ErrOptionsTooSmall              = errors.New("too small options buffer")
@@ -33,10 +42,20 @@ var (
 	ErrOptionGapTooLarge            = errors.New("option gap too large")
 	ErrOptionNotFound               = errors.New("option not found")
 	ErrOptionDuplicate              = errors.New("duplicated option")
+	ErrOptionIDOutOfRange           = errors.New("option id out of range")
+	ErrOptionConflict               = errors.New("option is already registered")
 
 	ErrMessageNil            = errors.New("message is nil")
 	ErrMessageTruncated      = errors.New("message is truncated")
 	ErrMessageInvalidVersion = errors.New("message has invalid version")
 	ErrMessageInvalidRSUM8   = errors.New("message has invalid rsum8")
 	ErrInvalidRCRC16         = errors.New("message has invalid crc16")
+	ErrCheckCRC32Mismatch    = errors.New("message body does not match CheckCRC32")
+	ErrMessageInvalidCode    = errors.New("message has invalid code")
+	ErrMessageTooLarge       = errors.New("message is too large")
+	ErrPayloadTooLarge       = errors.New("message payload is too large")
+
+	ErrMediaTypeConflict   = errors.New("media type is already registered")
+	ErrEncoderTypeConflict = errors.New("encoder type is already registered")
+	ErrNotImplemented      = errors.New("not implemented")
 )
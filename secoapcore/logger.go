@@ -0,0 +1,59 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"sync/atomic"
+)
+
+// Logger 调试日志接口, fields 为 key, value 交替的键值对
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+
+type loggerHolder struct {
+	Logger
+	enabled bool
+}
+
+var logger atomic.Value
+
+func init() {
+	logger.Store(loggerHolder{Logger: nopLogger{}})
+}
+
+// SetLogger sets the package logger, nil restores the default no-op logger.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	_, nop := l.(nopLogger)
+	logger.Store(loggerHolder{Logger: l, enabled: !nop})
+}
+
+// GetLogger returns the package logger.
+func GetLogger() Logger {
+	return logger.Load().(loggerHolder).Logger
+}
+
+// DebugEnabled reports whether a logger other than the default no-op logger is set.
+// Hot paths check it before calling Debug so the variadic fields are not allocated.
+func DebugEnabled() bool {
+	return logger.Load().(loggerHolder).enabled
+}
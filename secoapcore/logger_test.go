@@ -0,0 +1,94 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordLogger struct {
+	msgs []string
+}
+
+func (l *recordLogger) Debug(msg string, _ ...interface{}) {
+	l.msgs = append(l.msgs, msg)
+}
+
+func useRecordLogger(t *testing.T) *recordLogger {
+	l := &recordLogger{}
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(nil) })
+	return l
+}
+
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		logged  bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "plain",
+			payload: []byte("hello"),
+		},
+		{
+			name:    "leading 0xFF",
+			payload: []byte{0xFF, 0x01},
+			logged:  true,
+		},
+		{
+			name:    "0xFF at offset 3",
+			payload: []byte{0x01, 0x02, 0x03, 0xFF, 0x05},
+			logged:  true,
+		},
+		{
+			name:    "0xFF after offset 3",
+			payload: []byte{0x01, 0x02, 0x03, 0x04, 0xFF},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := useRecordLogger(t)
+			require.NoError(t, ValidatePayload(tt.payload))
+			require.Equal(t, tt.logged, len(l.msgs) > 0)
+		})
+	}
+}
+
+func TestOptionUnmarshalLogsSkipped(t *testing.T) {
+	l := useRecordLogger(t)
+	o := Option{}
+	n, err := o.Unmarshal(CoapOptionDefs, ETag, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Equal(t, []string{"skip option with illegal value length"}, l.msgs)
+
+	_, err = o.Unmarshal(CoapOptionDefs, 9999, nil)
+	require.Error(t, err)
+	require.Len(t, l.msgs, 2)
+}
+
+func TestDebugEnabled(t *testing.T) {
+	require.False(t, DebugEnabled())
+	useRecordLogger(t)
+	require.True(t, DebugEnabled())
+	SetLogger(nil)
+	require.False(t, DebugEnabled())
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MIDManager generates message ids and tracks the ones awaiting acknowledgement,
+// so a message id is not reused while it is pending.
+type MIDManager struct {
+	mu      sync.Mutex
+	next    uint16
+	window  int
+	pending map[int32]time.Time
+}
+
+// NewMIDManager creates a manager allowing at most windowSize pending message ids,
+// windowSize <= 0 allows all 65536 ids.
+func NewMIDManager(windowSize int) *MIDManager {
+	if windowSize <= 0 || windowSize > math.MaxUint16+1 {
+		windowSize = math.MaxUint16 + 1
+	}
+	return &MIDManager{
+		next:    uint16(RandMID()),
+		window:  windowSize,
+		pending: make(map[int32]time.Time),
+	}
+}
+
+// Next returns the next message id which is not pending, it wraps from 65535 to 0.
+func (m *MIDManager) Next() (int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) >= m.window {
+		return -1, ErrMIDWindowFull
+	}
+	for {
+		mid := int32(m.next)
+		m.next++
+		if _, ok := m.pending[mid]; !ok {
+			return mid, nil
+		}
+	}
+}
+
+// RegisterPending marks the message id as awaiting acknowledgement until deadline.
+func (m *MIDManager) RegisterPending(mid int32, deadline time.Time) {
+	if !ValidateMID(mid) {
+		return
+	}
+	m.mu.Lock()
+	m.pending[mid] = deadline
+	m.mu.Unlock()
+}
+
+// Acknowledge removes the message id from the pending set, it reports whether it was pending.
+func (m *MIDManager) Acknowledge(mid int32) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pending[mid]; !ok {
+		return false
+	}
+	delete(m.pending, mid)
+	return true
+}
+
+// Expire removes and returns the pending message ids whose deadline is not after now, in ascending order.
+func (m *MIDManager) Expire(now time.Time) []int32 {
+	m.mu.Lock()
+	var expired []int32
+	for mid, deadline := range m.pending {
+		if !deadline.After(now) {
+			expired = append(expired, mid)
+			delete(m.pending, mid)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(expired, func(i, j int) bool { return expired[i] < expired[j] })
+	return expired
+}
+
+// PendingCount returns the number of message ids awaiting acknowledgement.
+func (m *MIDManager) PendingCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMIDManagerNext(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	tests := []struct {
+		name    string
+		start   uint16
+		pending []int32
+		want    []int32
+	}{
+		{
+			name:  "rollover",
+			start: 65534,
+			want:  []int32{65534, 65535, 0, 1},
+		},
+		{
+			name:    "skip pending",
+			start:   65535,
+			pending: []int32{0, 2},
+			want:    []int32{65535, 1, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMIDManager(0)
+			m.next = tt.start
+			for _, mid := range tt.pending {
+				m.RegisterPending(mid, deadline)
+			}
+			for _, want := range tt.want {
+				mid, err := m.Next()
+				require.NoError(t, err)
+				require.Equal(t, want, mid)
+			}
+		})
+	}
+}
+
+func TestMIDManagerWindow(t *testing.T) {
+	m := NewMIDManager(2)
+	deadline := time.Now().Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		mid, err := m.Next()
+		require.NoError(t, err)
+		m.RegisterPending(mid, deadline)
+	}
+	_, err := m.Next()
+	require.ErrorIs(t, err, ErrMIDWindowFull)
+}
+
+func TestMIDManagerExpire(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		deadlines map[int32]time.Duration
+		ack       []int32
+		want      []int32
+		remaining int
+	}{
+		{
+			name:      "none expired",
+			deadlines: map[int32]time.Duration{1: time.Second, 2: time.Minute},
+			remaining: 2,
+		},
+		{
+			name:      "expired are removed",
+			deadlines: map[int32]time.Duration{3: -time.Second, 1: 0, 2: time.Second},
+			want:      []int32{1, 3},
+			remaining: 1,
+		},
+		{
+			name:      "acknowledged are not expired",
+			deadlines: map[int32]time.Duration{1: -time.Second, 2: -time.Second},
+			ack:       []int32{2},
+			want:      []int32{1},
+			remaining: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMIDManager(0)
+			for mid, d := range tt.deadlines {
+				m.RegisterPending(mid, now.Add(d))
+			}
+			for _, mid := range tt.ack {
+				require.True(t, m.Acknowledge(mid))
+				require.False(t, m.Acknowledge(mid))
+			}
+			require.Equal(t, tt.want, m.Expire(now))
+			require.Equal(t, tt.remaining, m.PendingCount())
+		})
+	}
+}
+
+func BenchmarkMIDManagerNext(b *testing.B) {
+	m := NewMIDManager(0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.Next(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
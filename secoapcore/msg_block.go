@@ -0,0 +1,77 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"fmt"
+)
+
+/*
+   Block1/Block2 option value (RFC 7959 §2.2), 0-3 bytes
+
+     0
+     0 1 2 3 4 5 6 7
+    +-+-+-+-+-+-+-+-+
+    |  NUM  |M| SZX |
+    +-+-+-+-+-+-+-+-+
+
+     0                   1
+     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+    |          NUM          |M| SZX |
+    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+     0                   1                   2
+     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3
+    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+    |                   NUM                 |M| SZX |
+    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+
+const (
+	// MaxBlockSZX is the largest block size exponent, the block size is 2**(SZX+4).
+	MaxBlockSZX = 6
+	// MaxBlockNum is the largest block number fitting in a 3 bytes option.
+	MaxBlockNum = 1<<20 - 1
+
+	maxBlockValue = 1<<24 - 1
+)
+
+// EncodeBlock encodes the block number, more flag and size exponent to a Block1/Block2 option value.
+func EncodeBlock(num uint32, more bool, szx uint8) (uint32, error) {
+	if szx > MaxBlockSZX {
+		return 0, fmt.Errorf("%w: block szx %d", ErrInvalidValueLength, szx)
+	}
+	if num > MaxBlockNum {
+		return 0, fmt.Errorf("%w: block num %d", ErrInvalidValueLength, num)
+	}
+	val := num<<4 | uint32(szx)
+	if more {
+		val |= 1 << 3
+	}
+	return val, nil
+}
+
+// DecodeBlock decodes a Block1/Block2 option value.
+func DecodeBlock(val uint32) (num, szx uint32, more bool, err error) {
+	if val > maxBlockValue {
+		return 0, 0, false, fmt.Errorf("%w: block value %d", ErrInvalidValueLength, val)
+	}
+	szx = val & 0x7
+	if szx > MaxBlockSZX {
+		return 0, 0, false, fmt.Errorf("%w: block szx %d", ErrInvalidValueLength, szx)
+	}
+	return val >> 4, szx, val&(1<<3) != 0, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockRoundTrip(t *testing.T) {
+	for num := uint32(0); num <= MaxBlockNum; num++ {
+		for szx := uint8(0); szx <= MaxBlockSZX; szx++ {
+			for _, more := range []bool{false, true} {
+				val, err := EncodeBlock(num, more, szx)
+				if err != nil {
+					t.Fatalf("EncodeBlock(%d, %v, %d): %v", num, more, szx, err)
+				}
+				gotNum, gotSZX, gotMore, err := DecodeBlock(val)
+				if err != nil || gotNum != num || gotSZX != uint32(szx) || gotMore != more {
+					t.Fatalf("DecodeBlock(EncodeBlock(%d, %v, %d)) = %d, %d, %v, %v", num, more, szx, gotNum, gotSZX, gotMore, err)
+				}
+			}
+		}
+	}
+}
+
+func TestBlockInvalid(t *testing.T) {
+	_, err := EncodeBlock(0, false, 7)
+	require.ErrorIs(t, err, ErrInvalidValueLength)
+	_, err = EncodeBlock(MaxBlockNum+1, false, 0)
+	require.ErrorIs(t, err, ErrInvalidValueLength)
+	_, _, _, err = DecodeBlock(0x07)
+	require.ErrorIs(t, err, ErrInvalidValueLength)
+	_, _, _, err = DecodeBlock(1 << 24)
+	require.ErrorIs(t, err, ErrInvalidValueLength)
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborOption is encoded as the array [id, value], value is a text string for
+// string options, an unsigned integer for uint options and a byte string otherwise.
+type cborOption struct {
+	_     struct{} `cbor:",toarray"`
+	ID    uint32
+	Value cbor.RawMessage
+}
+
+// cborMessage is a map with small integer keys to keep the encoding compact.
+type cborMessage struct {
+	Ver         Ver          `cbor:"1,keyasint,omitempty"`
+	Type        Type         `cbor:"2,keyasint,omitempty"`
+	Code        Code         `cbor:"3,keyasint,omitempty"`
+	MessageID   int32        `cbor:"4,keyasint,omitempty"`
+	Token       []byte       `cbor:"5,keyasint,omitempty"`
+	Options     []cborOption `cbor:"6,keyasint,omitempty"`
+	Payload     []byte       `cbor:"7,keyasint,omitempty"`
+	EncoderID   int32        `cbor:"8,keyasint,omitempty"`
+	EncoderType int32        `cbor:"9,keyasint,omitempty"`
+	Crc16       uint16       `cbor:"10,keyasint,omitempty"`
+	Rsum8       uint8        `cbor:"11,keyasint,omitempty"`
+}
+
+// MarshalCBOR encodes the message to CBOR.
+func (m *Message) MarshalCBOR() ([]byte, error) {
+	cm := cborMessage{
+		Ver:         m.Ver,
+		Type:        m.Type,
+		Code:        m.Code,
+		MessageID:   m.MessageID,
+		Token:       m.Token,
+		Payload:     m.Payload,
+		EncoderID:   m.EncoderID,
+		EncoderType: m.EncoderType,
+		Crc16:       m.Crc16,
+		Rsum8:       m.Rsum8,
+	}
+	for _, o := range m.Opts {
+		var value interface{}
+		switch def, _ := LookupOptionDef(o.ID); def.ValueFormat {
+		case ValueString:
+			value = string(o.ToBytes())
+		case ValueUint:
+			value = decodeInt(o.ToBytes())
+		default:
+			value = o.ToBytes()
+		}
+		raw, err := cbor.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		cm.Options = append(cm.Options, cborOption{ID: uint32(o.ID), Value: raw})
+	}
+	return cbor.Marshal(cm)
+}
+
+// UnmarshalCBOR decodes the message from CBOR written by MarshalCBOR.
+func (m *Message) UnmarshalCBOR(data []byte) error {
+	var cm cborMessage
+	if err := cbor.Unmarshal(data, &cm); err != nil {
+		return err
+	}
+	var opts Options
+	if len(cm.Options) > 0 {
+		opts = NewOptions(len(cm.Options))
+	}
+	for _, co := range cm.Options {
+		o := Option{ID: OptionID(co.ID)}
+		var err error
+		switch def, _ := LookupOptionDef(o.ID); def.ValueFormat {
+		case ValueString:
+			var v string
+			err = cbor.Unmarshal(co.Value, &v)
+			o.Value = v
+		case ValueUint:
+			var v uint32
+			err = cbor.Unmarshal(co.Value, &v)
+			o.Value = v
+		default:
+			var v []byte
+			err = cbor.Unmarshal(co.Value, &v)
+			o.Value = v
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value of option %v: %w", o.ID, err)
+		}
+		opts = append(opts, o)
+	}
+
+	*m = Message{
+		Ver:         cm.Ver,
+		Code:        cm.Code,
+		Opts:        opts,
+		Payload:     cm.Payload,
+		MessageID:   cm.MessageID,
+		Type:        cm.Type,
+		EncoderID:   cm.EncoderID,
+		EncoderType: cm.EncoderType,
+		Crc16:       cm.Crc16,
+		Rsum8:       cm.Rsum8,
+	}
+	if len(cm.Token) > 0 {
+		m.Token = cm.Token
+	}
+	return nil
+}
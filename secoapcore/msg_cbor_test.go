@@ -0,0 +1,66 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{
+			name: "full",
+			msg: Message{
+				Ver:   Version2,
+				Token: Token{0x01, 0x02, 0xAB},
+				Opts: Options{
+					{ID: ETag, Value: []byte{0xDE, 0xAD}},
+					{ID: URIPath, Value: "iotda"},
+					{ID: ContentFormat, Value: uint32(AppCBOR)},
+					{ID: MaxAge, Value: uint32(70000)},
+				},
+				Code:        POST,
+				Payload:     []byte{0x00, 0xFF, 0x10},
+				MessageID:   0x1234,
+				Type:        NonConfirmable,
+				EncoderID:   1,
+				EncoderType: 6,
+				Crc16:       0xBEEF,
+				Rsum8:       0x7F,
+			},
+		},
+		{
+			name: "unset",
+			msg:  Message{MessageID: -1, Type: Unset, EncoderID: -1, EncoderType: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.msg.MarshalCBOR()
+			require.NoError(t, err)
+			var got Message
+			require.NoError(t, got.UnmarshalCBOR(data))
+			require.Equal(t, tt.msg, got)
+		})
+	}
+
+	var got Message
+	require.Error(t, got.UnmarshalCBOR([]byte{0xA1, 0x06, 0x81, 0x82, 0x0E, 0x61, 0x78}))
+}
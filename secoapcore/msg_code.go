@@ -16,6 +16,7 @@ package secoapcore
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 )
 
@@ -42,6 +43,9 @@ const (
 	POST   Code = 2
 	PUT    Code = 3
 	DELETE Code = 4
+	FETCH  Code = 5 // RFC 8132
+	PATCH  Code = 6 // RFC 8132
+	IPATCH Code = 7 // iPATCH, RFC 8132
 
 	// Response Codes
 	Created                 Code = 65
@@ -108,6 +112,9 @@ var codeToString = map[Code]string{
 	POST:   "POST",
 	PUT:    "PUT",
 	DELETE: "DELETE",
+	FETCH:  "FETCH",
+	PATCH:  "PATCH",
+	IPATCH: "iPATCH",
 
 	Created:                 "Created",
 	Deleted:                 "Deleted",
@@ -162,6 +169,26 @@ var codeToString = map[Code]string{
 	GiterlabErrnoDeviceUpdateForcedFailed:    "GiterlabErrnoDeviceUpdateForcedFailed",
 }
 
+// ValidateCode reports whether the code is in a defined range of RFC 7252 §12.1 or the GiterLab extensions.
+func ValidateCode(c Code) bool {
+	switch {
+	case c == Empty:
+	case c >= GET && c <= IPATCH:
+	case c >= Created && c <= Content:
+	case c == Continue:
+	case c >= BadRequest && c <= RequestEntityTooLarge:
+	case c == UnsupportedMediaType:
+	case c == TooManyRequests:
+	case c >= InternalServerError && c <= ProxyingNotSupported:
+	case c >= GiterlabErrnoOk && c <= GiterlabErrnoUserCommand:
+	case c == GiterlabErrnoEnterFlightMode:
+	case c >= GiterlabErrnoIllegalKey && c <= GiterlabErrnoDeviceUpdateForcedFailed:
+	default:
+		return false
+	}
+	return true
+}
+
 func (c Code) String() string {
 	str, ok := codeToString[c]
 	if !ok {
@@ -178,3 +205,136 @@ func ToCode(v string) (Code, error) {
 	}
 	return 0, errors.New("not found")
 }
+
+// CodeClass is the class of a Code, see Code.Class.
+type CodeClass uint8
+
+const (
+	ClassUnknown         CodeClass = iota
+	ClassEmpty                     // 0.00
+	ClassRequest                   // 0.01-0.31
+	ClassSuccess                   // 2.00-2.31
+	ClassClientError               // 4.00-4.31
+	ClassServerError               // 5.00-5.31
+	ClassGiterlabSuccess           // 6.00-6.31
+	ClassGiterlabError             // 7.00-7.31
+)
+
+var codeClassToString = map[CodeClass]string{
+	ClassUnknown:         "Unknown",
+	ClassEmpty:           "Empty",
+	ClassRequest:         "Request",
+	ClassSuccess:         "Success",
+	ClassClientError:     "ClientError",
+	ClassServerError:     "ServerError",
+	ClassGiterlabSuccess: "GiterlabSuccess",
+	ClassGiterlabError:   "GiterlabError",
+}
+
+func (c CodeClass) String() string {
+	str, ok := codeClassToString[c]
+	if !ok {
+		return "CodeClass(" + strconv.FormatInt(int64(c), 10) + ")"
+	}
+	return str
+}
+
+// IsEmpty reports whether the code is 0.00.
+func (c Code) IsEmpty() bool { return c == Empty }
+
+// IsRequest reports whether the code is a method code (0.01-0.31).
+func (c Code) IsRequest() bool { return c >= 1 && c <= 31 }
+
+// IsPatchMethod reports whether the code is PATCH or iPATCH.
+func (c Code) IsPatchMethod() bool { return c == PATCH || c == IPATCH }
+
+// IsSuccess reports whether the code is a success response (2.00-2.31).
+func (c Code) IsSuccess() bool { return c >= 64 && c <= 95 }
+
+// IsClientError reports whether the code is a client error response (4.00-4.31).
+func (c Code) IsClientError() bool { return c >= 128 && c <= 159 }
+
+// IsServerError reports whether the code is a server error response (5.00-5.31).
+func (c Code) IsServerError() bool { return c >= 160 && c <= 191 }
+
+// IsGiterlabSuccess reports whether the code is a GiterLab success response (6.00-6.31).
+func (c Code) IsGiterlabSuccess() bool { return c >= 192 && c <= 223 }
+
+// IsGiterlabError reports whether the code is a GiterLab error response (7.00-7.31).
+func (c Code) IsGiterlabError() bool { return c >= 224 }
+
+// Class returns the class of the code, the reserved 1.xx and 3.xx classes are ClassUnknown.
+func (c Code) Class() CodeClass {
+	switch {
+	case c.IsEmpty():
+		return ClassEmpty
+	case c.IsRequest():
+		return ClassRequest
+	case c.IsSuccess():
+		return ClassSuccess
+	case c.IsClientError():
+		return ClassClientError
+	case c.IsServerError():
+		return ClassServerError
+	case c.IsGiterlabSuccess():
+		return ClassGiterlabSuccess
+	case c.IsGiterlabError():
+		return ClassGiterlabError
+	}
+	return ClassUnknown
+}
+
+var codeToHTTPStatus = map[Code]int{
+	Created:                 http.StatusCreated,
+	Deleted:                 http.StatusOK,
+	Valid:                   http.StatusNotModified,
+	Changed:                 http.StatusNoContent,
+	Content:                 http.StatusOK,
+	Continue:                http.StatusContinue,
+	BadRequest:              http.StatusBadRequest,
+	Unauthorized:            http.StatusUnauthorized,
+	BadOption:               http.StatusBadRequest,
+	Forbidden:               http.StatusForbidden,
+	NotFound:                http.StatusNotFound,
+	MethodNotAllowed:        http.StatusMethodNotAllowed,
+	NotAcceptable:           http.StatusNotAcceptable,
+	RequestEntityIncomplete: http.StatusBadRequest,
+	PreconditionFailed:      http.StatusPreconditionFailed,
+	RequestEntityTooLarge:   http.StatusRequestEntityTooLarge,
+	UnsupportedMediaType:    http.StatusUnsupportedMediaType,
+	TooManyRequests:         http.StatusTooManyRequests,
+	InternalServerError:     http.StatusInternalServerError,
+	NotImplemented:          http.StatusNotImplemented,
+	BadGateway:              http.StatusBadGateway,
+	ServiceUnavailable:      http.StatusServiceUnavailable,
+	GatewayTimeout:          http.StatusGatewayTimeout,
+	ProxyingNotSupported:    http.StatusBadGateway,
+
+	GiterlabErrnoIllegalKey:                 http.StatusUnauthorized,
+	GiterlabErrnoDeviceNotExist:             http.StatusNotFound,
+	GiterlabErrnoRequestTimeout:             http.StatusGatewayTimeout,
+	GiterlabErrnoDuoxieyunServerRequestBusy: http.StatusServiceUnavailable,
+	GiterlabErrnoSluanServerRequestBusy:     http.StatusServiceUnavailable,
+	GiterlabErrnoCacheServiceErrors:         http.StatusInternalServerError,
+	GiterlabErrnoTableStoreServiceErrors:    http.StatusInternalServerError,
+	GiterlabErrnoDatabaseServiceErrors:      http.StatusInternalServerError,
+}
+
+// HTTPStatusCode maps the response code to the nearest HTTP status code.
+//
+// Codes without a direct equivalent fall back to the generic status of their
+// class, 0 is returned for the empty message, requests and reserved codes.
+func (c Code) HTTPStatusCode() int {
+	if status, ok := codeToHTTPStatus[c]; ok {
+		return status
+	}
+	switch c.Class() {
+	case ClassSuccess, ClassGiterlabSuccess:
+		return http.StatusOK
+	case ClassClientError, ClassGiterlabError:
+		return http.StatusBadRequest
+	case ClassServerError:
+		return http.StatusInternalServerError
+	}
+	return 0
+}
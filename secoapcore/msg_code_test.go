@@ -0,0 +1,147 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCode(t *testing.T) {
+	for code := range codeToString {
+		require.True(t, ValidateCode(code), "%v", code)
+	}
+	for _, code := range []Code{GiterlabErrnoUserCommand, GiterlabErrnoEnterFlightMode} {
+		require.True(t, ValidateCode(code), "%v", code)
+	}
+	for _, code := range []Code{8, 31, 64, 70, 96, 127, 142, 144, 158, 166, 191, 196, 221, 246, 255} {
+		require.False(t, ValidateCode(code), "%v", code)
+	}
+}
+
+func TestCodeIsPatchMethod(t *testing.T) {
+	require.True(t, PATCH.IsPatchMethod())
+	require.True(t, IPATCH.IsPatchMethod())
+	for _, code := range []Code{GET, POST, PUT, DELETE, FETCH, Changed} {
+		require.False(t, code.IsPatchMethod(), "%v", code)
+	}
+	require.Equal(t, "iPATCH", IPATCH.String())
+	code, err := ToCode("FETCH")
+	require.NoError(t, err)
+	require.Equal(t, FETCH, code)
+	require.Equal(t, ClassRequest, PATCH.Class())
+}
+
+func TestCodeClass(t *testing.T) {
+	tests := []struct {
+		code  Code
+		class CodeClass
+	}{
+		{code: Empty, class: ClassEmpty},
+		{code: GET, class: ClassRequest},
+		{code: 31, class: ClassRequest},
+		{code: 32, class: ClassUnknown},
+		{code: 63, class: ClassUnknown},
+		{code: 64, class: ClassSuccess},
+		{code: Content, class: ClassSuccess},
+		{code: Continue, class: ClassSuccess},
+		{code: 96, class: ClassUnknown},
+		{code: 127, class: ClassUnknown},
+		{code: BadRequest, class: ClassClientError},
+		{code: 159, class: ClassClientError},
+		{code: InternalServerError, class: ClassServerError},
+		{code: 191, class: ClassServerError},
+		{code: GiterlabErrnoOk, class: ClassGiterlabSuccess},
+		{code: GiterlabErrnoEnterFlightMode, class: ClassGiterlabSuccess},
+		{code: 223, class: ClassGiterlabSuccess},
+		{code: GiterlabErrnoIllegalKey, class: ClassGiterlabError},
+		{code: 255, class: ClassGiterlabError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			require.Equal(t, tt.class, tt.code.Class())
+			require.Equal(t, tt.class == ClassEmpty, tt.code.IsEmpty())
+			require.Equal(t, tt.class == ClassRequest, tt.code.IsRequest())
+			require.Equal(t, tt.class == ClassSuccess, tt.code.IsSuccess())
+			require.Equal(t, tt.class == ClassClientError, tt.code.IsClientError())
+			require.Equal(t, tt.class == ClassServerError, tt.code.IsServerError())
+			require.Equal(t, tt.class == ClassGiterlabSuccess, tt.code.IsGiterlabSuccess())
+			require.Equal(t, tt.class == ClassGiterlabError, tt.code.IsGiterlabError())
+		})
+	}
+
+	// every code has exactly one class
+	for c := 0; c <= 255; c++ {
+		code := Code(c)
+		n := 0
+		for _, is := range []bool{code.IsEmpty(), code.IsRequest(), code.IsSuccess(), code.IsClientError(),
+			code.IsServerError(), code.IsGiterlabSuccess(), code.IsGiterlabError()} {
+			if is {
+				n++
+			}
+		}
+		if code.Class() == ClassUnknown {
+			require.Equal(t, 0, n, "%v", code)
+		} else {
+			require.Equal(t, 1, n, "%v", code)
+		}
+	}
+	require.Equal(t, "ClientError", ClassClientError.String())
+	require.Equal(t, "CodeClass(42)", CodeClass(42).String())
+}
+
+func TestCodeHTTPStatusCode(t *testing.T) {
+	tests := []struct {
+		code Code
+		want int
+	}{
+		{code: Empty, want: 0},
+		{code: GET, want: 0},
+		{code: 100, want: 0},
+		{code: Created, want: http.StatusCreated},
+		{code: Deleted, want: http.StatusOK},
+		{code: Valid, want: http.StatusNotModified},
+		{code: Changed, want: http.StatusNoContent},
+		{code: Content, want: http.StatusOK},
+		{code: 70, want: http.StatusOK},
+		{code: BadRequest, want: http.StatusBadRequest},
+		{code: Unauthorized, want: http.StatusUnauthorized},
+		{code: Forbidden, want: http.StatusForbidden},
+		{code: NotFound, want: http.StatusNotFound},
+		{code: MethodNotAllowed, want: http.StatusMethodNotAllowed},
+		{code: PreconditionFailed, want: http.StatusPreconditionFailed},
+		{code: RequestEntityTooLarge, want: http.StatusRequestEntityTooLarge},
+		{code: UnsupportedMediaType, want: http.StatusUnsupportedMediaType},
+		{code: TooManyRequests, want: http.StatusTooManyRequests},
+		{code: 150, want: http.StatusBadRequest},
+		{code: InternalServerError, want: http.StatusInternalServerError},
+		{code: NotImplemented, want: http.StatusNotImplemented},
+		{code: BadGateway, want: http.StatusBadGateway},
+		{code: ServiceUnavailable, want: http.StatusServiceUnavailable},
+		{code: GatewayTimeout, want: http.StatusGatewayTimeout},
+		{code: ProxyingNotSupported, want: http.StatusBadGateway},
+		{code: 170, want: http.StatusInternalServerError},
+		{code: GiterlabErrnoOk, want: http.StatusOK},
+		{code: GiterlabErrnoIllegalKey, want: http.StatusUnauthorized},
+		{code: GiterlabErrnoDeviceNotExist, want: http.StatusNotFound},
+		{code: GiterlabErrnoDataError, want: http.StatusBadRequest},
+		{code: GiterlabErrnoDatabaseServiceErrors, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, tt.code.HTTPStatusCode(), "%v", tt.code)
+	}
+}
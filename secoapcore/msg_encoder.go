@@ -14,6 +14,11 @@
 
 package secoapcore
 
+import (
+	"fmt"
+	"sync"
+)
+
 const (
 	// EncoderTypeNoneUserDefine none/userdefine
 	EncoderTypeNoneUserDefine = "none/userdefine"
@@ -31,77 +36,114 @@ const (
 	EncoderTypeApplicationJson = "application/json"
 )
 
-// GetEncoderType 获取协议 Payload 编码类型, coap协议默认是 application/protobuf
-func GetEncoderType(encoderType int32, encoderID int32) string {
-	switch encoderType {
-	case 0:
-		switch encoderID {
-		case 0:
-			return EncoderTypeNoneUserDefine
-		}
-	case 1:
-		switch encoderID {
-		case 0:
-			return EncoderTypeTextBase64
-		}
-	case 2:
-		switch encoderID {
-		case 0:
-			return EncoderTypeTextPlain
-		}
-	case 3:
-		switch encoderID {
-		case 0:
-			return EncoderTypeTextHex
-		}
-	case 4:
-		switch encoderID {
-		case 0:
-			return EncoderTypeApplicationOctetStream
+var encoderTypeToString = map[[2]int32]string{
+	{0, 0}: EncoderTypeNoneUserDefine,
+	{1, 0}: EncoderTypeTextBase64,
+	{2, 0}: EncoderTypeTextPlain,
+	{3, 0}: EncoderTypeTextHex,
+	{4, 0}: EncoderTypeApplicationOctetStream,
+	{5, 0}: EncoderTypeApplicationProtobuf,
+	{6, 0}: EncoderTypeApplicationJson,
+}
+
+var (
+	customEncoderTypesMu sync.RWMutex
+	customEncoderTypes   = map[[2]int32]string{}
+)
+
+// RegisterEncoderType 注册自定义的编码类型, 如 encoderType 为 0 时 encoderID 1-15 的用户自定义编码,
+// (encoderType, encoderID) 或 name 已注册为其他值时返回 ErrEncoderTypeConflict, 重复注册相同的值不报错
+func RegisterEncoderType(encoderType, encoderID int32, name string) error {
+	if !ValidateETP(encoderType) || !ValidateEID(encoderID) {
+		return fmt.Errorf("invalid encoder type %d or encoder id %d, expected 0-15", encoderType, encoderID)
+	}
+	if name == "" {
+		return fmt.Errorf("%w: empty name for %d/%d", ErrEncoderTypeConflict, encoderType, encoderID)
+	}
+	key := [2]int32{encoderType, encoderID}
+	customEncoderTypesMu.Lock()
+	defer customEncoderTypesMu.Unlock()
+	if str, ok := lookupEncoderType(key); ok {
+		if str == name {
+			return nil
 		}
-	case 5:
-		switch encoderID {
-		case 0:
-			return EncoderTypeApplicationProtobuf
+		return fmt.Errorf("%w: %d/%d is registered as %q", ErrEncoderTypeConflict, encoderType, encoderID, str)
+	}
+	if other, ok := lookupEncoderName(name); ok {
+		return fmt.Errorf("%w: %q is registered as %d/%d", ErrEncoderTypeConflict, name, other[0], other[1])
+	}
+	customEncoderTypes[key] = name
+	return nil
+}
+
+// UnregisterEncoderType 移除 RegisterEncoderType 注册的编码类型, 内置的编码类型不能移除
+func UnregisterEncoderType(encoderType, encoderID int32) {
+	customEncoderTypesMu.Lock()
+	defer customEncoderTypesMu.Unlock()
+	delete(customEncoderTypes, [2]int32{encoderType, encoderID})
+}
+
+// ListEncoderTypes 返回所有已知的编码类型, 包括内置和注册的, 键为 {encoderType, encoderID}
+func ListEncoderTypes() map[[2]int32]string {
+	customEncoderTypesMu.RLock()
+	defer customEncoderTypesMu.RUnlock()
+	types := make(map[[2]int32]string, len(encoderTypeToString)+len(customEncoderTypes))
+	for key, name := range encoderTypeToString {
+		types[key] = name
+	}
+	for key, name := range customEncoderTypes {
+		types[key] = name
+	}
+	return types
+}
+
+// lookupEncoderType 调用者需持有 customEncoderTypesMu
+func lookupEncoderType(key [2]int32) (string, bool) {
+	if name, ok := customEncoderTypes[key]; ok {
+		return name, true
+	}
+	name, ok := encoderTypeToString[key]
+	return name, ok
+}
+
+// lookupEncoderName 调用者需持有 customEncoderTypesMu
+func lookupEncoderName(name string) ([2]int32, bool) {
+	for key, val := range customEncoderTypes {
+		if val == name {
+			return key, true
 		}
-	case 6:
-		switch encoderID {
-		case 0:
-			return EncoderTypeApplicationJson
+	}
+	for key, val := range encoderTypeToString {
+		if val == name {
+			return key, true
 		}
 	}
+	return [2]int32{}, false
+}
+
+// EncoderTypeName 获取协议 Payload 编码类型的名称, 未知的编码类型返回 none/userdefine
+func EncoderTypeName(encoderType, encoderID int32) string {
+	customEncoderTypesMu.RLock()
+	defer customEncoderTypesMu.RUnlock()
+	if name, ok := lookupEncoderType([2]int32{encoderType, encoderID}); ok {
+		return name
+	}
 	return EncoderTypeNoneUserDefine // 默认是 protobuf 编码 / 或者用户自定义协议
 }
 
-// GetEncoder 根据编码器，获取对应的编码类型
+// GetEncoderType 获取协议 Payload 编码类型, 同 EncoderTypeName, 为兼容保留
+func GetEncoderType(encoderType int32, encoderID int32) string {
+	return EncoderTypeName(encoderType, encoderID)
+}
+
+// GetEncoder 根据编码类型的名称获取对应的 encoderType 和 encoderID, 未知的名称返回 0, 0
 func GetEncoder(encoderTypeX string) (encoderType int32, encoderID int32) {
-	switch encoderTypeX {
-	case EncoderTypeNoneUserDefine: // none/userdefine
-		encoderType = 0
-		encoderID = 0
-	case EncoderTypeTextBase64: // text/base64
-		encoderType = 1
-		encoderID = 0
-	case EncoderTypeTextPlain: // text/plain
-		encoderType = 2
-		encoderID = 0
-	case EncoderTypeTextHex: // text/hex
-		encoderType = 3
-		encoderID = 0
-	case EncoderTypeApplicationOctetStream: // application/octet-stream
-		encoderType = 4
-		encoderID = 0
-	case EncoderTypeApplicationProtobuf: // application/protobuf
-		encoderType = 5
-		encoderID = 0
-	case EncoderTypeApplicationJson: // application/json
-		encoderType = 6
-		encoderID = 0
-	default:
-		encoderType = 0
-		encoderID = 0
+	customEncoderTypesMu.RLock()
+	defer customEncoderTypesMu.RUnlock()
+	if key, ok := lookupEncoderName(encoderTypeX); ok {
+		return key[0], key[1]
 	}
-	return encoderType, encoderID
+	return 0, 0
 }
 
 // ValidateEID validates a message eid for Payload. (0 <= eid <= 15)
@@ -0,0 +1,61 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderTypeRoundTrip(t *testing.T) {
+	for key, name := range ListEncoderTypes() {
+		require.Equal(t, name, EncoderTypeName(key[0], key[1]))
+		require.Equal(t, name, GetEncoderType(key[0], key[1]))
+		etp, eid := GetEncoder(EncoderTypeName(key[0], key[1]))
+		require.Equal(t, key, [2]int32{etp, eid})
+	}
+	require.Equal(t, EncoderTypeApplicationJson, EncoderTypeName(6, 0))
+	// 未知的编码类型
+	require.Equal(t, EncoderTypeNoneUserDefine, EncoderTypeName(0, 1))
+	etp, eid := GetEncoder("application/unknown")
+	require.Equal(t, [2]int32{0, 0}, [2]int32{etp, eid})
+}
+
+func TestRegisterEncoderType(t *testing.T) {
+	const name = "application/vnd.giterlab.sensor"
+	require.NoError(t, RegisterEncoderType(0, 1, name))
+	t.Cleanup(func() { UnregisterEncoderType(0, 1) })
+	require.Equal(t, name, EncoderTypeName(0, 1))
+	etp, eid := GetEncoder(name)
+	require.Equal(t, [2]int32{0, 1}, [2]int32{etp, eid})
+	require.Equal(t, name, ListEncoderTypes()[[2]int32{0, 1}])
+	require.Equal(t, EncoderTypeTextHex, ListEncoderTypes()[[2]int32{3, 0}])
+
+	// 相同的值可以重复注册
+	require.NoError(t, RegisterEncoderType(0, 1, name))
+	require.ErrorIs(t, RegisterEncoderType(0, 1, "application/other"), ErrEncoderTypeConflict)
+	require.ErrorIs(t, RegisterEncoderType(0, 2, name), ErrEncoderTypeConflict)
+	require.ErrorIs(t, RegisterEncoderType(6, 0, "application/not-json"), ErrEncoderTypeConflict)
+	require.ErrorIs(t, RegisterEncoderType(0, 2, ""), ErrEncoderTypeConflict)
+	require.Error(t, RegisterEncoderType(16, 0, "application/out-of-range"))
+	require.Error(t, RegisterEncoderType(0, -1, "application/out-of-range"))
+
+	UnregisterEncoderType(0, 1)
+	require.Equal(t, EncoderTypeNoneUserDefine, EncoderTypeName(0, 1))
+	// 内置的编码类型不能移除
+	UnregisterEncoderType(6, 0)
+	require.Equal(t, EncoderTypeApplicationJson, EncoderTypeName(6, 0))
+}
@@ -0,0 +1,347 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// hexDumpBytesPerLine 每行显示的字节数
+const hexDumpBytesPerLine = 8
+
+// Decoder 解码指定协议版本的消息
+type Decoder interface {
+	Decode(data []byte, m *Message) (int, error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[Ver]Decoder{}
+)
+
+// RegisterDecoder 注册协议版本对应的 Decoder, HexDump 用其检查校验和等字段
+//
+// 与 RegisterSizer 相同, coderv0, coderv1, coderv2 在 init 中注册各自的 DefaultCoder
+func RegisterDecoder(ver Ver, d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	if d == nil {
+		delete(decoders, ver)
+		return
+	}
+	decoders[ver] = d
+}
+
+func lookupDecoder(ver Ver) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[ver]
+	return d, ok
+}
+
+// HexDump 按协议版本 ver 输出 data 的逐字段十六进制分析, 格式类似 Wireshark 的报文详情
+//
+// 每个字段(版本, 类型, TKL, 代码, 消息ID, 令牌, 选项, 负载)单独一行, 包含偏移, 原始字节和
+// 位域说明. 解析失败时输出到失败处为止, 之后的字节标记为 Unparsed 并追加
+// [PARSE ERROR: ...] 行. 对应版本的 coder 包已导入时(导入 secoap 包即可), 还会调用其
+// Decode 检查 CRC16, RSUM8 等校验.
+func HexDump(data []byte, ver Ver) string {
+	var sb strings.Builder
+	_ = HexDumpWriter(data, ver, &sb)
+	return sb.String()
+}
+
+// HexDumpWriter 将 HexDump 的结果写入 w, 只返回写入 w 的错误, 解析错误写在输出中
+func HexDumpWriter(data []byte, ver Ver, w io.Writer) error {
+	d := &hexDumper{w: w, data: data}
+	d.printf("%-6s  %-28s %s\n", "Offset", "Hex", "Description")
+
+	var off int
+	var opts int
+	var err error
+	switch ver {
+	case Version0:
+		off, err = d.dumpV0()
+	case Version1:
+		off, opts, err = d.dumpV1()
+	case Version2:
+		off, opts, err = d.dumpV2()
+	default:
+		err = fmt.Errorf("%w: %v", ErrMessageInvalidVersion, ver)
+	}
+	if err != nil {
+		if off < len(data) {
+			d.bytes(off, data[off:], fmt.Sprintf("Unparsed (%d bytes)", len(data)-off))
+		}
+		d.parseError(err)
+		return d.err
+	}
+	if dec, ok := lookupDecoder(ver); ok {
+		m := Message{Opts: make(Options, 0, opts)}
+		if _, err := dec.Decode(data, &m); err != nil {
+			d.parseError(err)
+		}
+	}
+	return d.err
+}
+
+type hexDumper struct {
+	w    io.Writer
+	data []byte
+	err  error
+}
+
+func (d *hexDumper) printf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+// line 输出一行, b 为空时省略偏移和十六进制
+func (d *hexDumper) line(off int, b []byte, desc string) {
+	if len(b) == 0 {
+		d.printf("%-6s  %-28s %s\n", "", "", desc)
+		return
+	}
+	if desc == "" {
+		d.printf("%06X  % X\n", off, b)
+		return
+	}
+	d.printf("%06X  %-28s %s\n", off, fmt.Sprintf("% X", b), desc)
+}
+
+// bytes 输出一个字段, 超过 hexDumpBytesPerLine 的部分分行输出
+func (d *hexDumper) bytes(off int, b []byte, desc string) {
+	for i := 0; i < len(b); i += hexDumpBytesPerLine {
+		end := i + hexDumpBytesPerLine
+		if end > len(b) {
+			end = len(b)
+		}
+		if i > 0 {
+			desc = ""
+		}
+		d.line(off+i, b[i:end], desc)
+	}
+}
+
+// bits 输出字节 b 中从第 hi 位开始 width 位的位域说明, 如 "01.. .... = Version: 1"
+func (d *hexDumper) bits(b byte, hi, width int, desc string) {
+	var sb strings.Builder
+	for i := 7; i >= 0; i-- {
+		switch {
+		case i <= hi && i > hi-width:
+			sb.WriteByte('0' + (b>>i)&1)
+		default:
+			sb.WriteByte('.')
+		}
+		if i == 4 {
+			sb.WriteByte(' ')
+		}
+	}
+	d.line(0, nil, sb.String()+" = "+desc)
+}
+
+func (d *hexDumper) parseError(err error) {
+	d.printf("[PARSE ERROR: %v]\n", err)
+}
+
+// version 输出版本位, 与 ver 不一致时返回 ErrMessageInvalidVersion
+func (d *hexDumper) version(ver Ver) error {
+	v := Ver(d.data[0] >> 6)
+	d.bits(d.data[0], 7, 2, fmt.Sprintf("Version: %d", v))
+	if v != ver {
+		return fmt.Errorf("%w: got %d, want %d", ErrMessageInvalidVersion, v, ver)
+	}
+	return nil
+}
+
+func (d *hexDumper) encoder(off int) {
+	b := d.data[off]
+	d.line(off, d.data[off:off+1], fmt.Sprintf("Encoder: %s", EncoderTypeName(int32(b&0xf), int32(b>>4))))
+	d.bits(b, 7, 4, fmt.Sprintf("Encoder ID: %d", b>>4))
+	d.bits(b, 3, 4, fmt.Sprintf("Encoder Type: %d", b&0xf))
+}
+
+func (d *hexDumper) code(off int) {
+	c := Code(d.data[off])
+	d.line(off, d.data[off:off+1], fmt.Sprintf("Code: %s (%d.%02d)", c, c>>5, c&0x1f))
+}
+
+func (d *hexDumper) messageID(off int) {
+	mid := binary.BigEndian.Uint16(d.data[off : off+2])
+	d.line(off, d.data[off:off+2], fmt.Sprintf("Message ID: 0x%04X (%d)", mid, mid))
+}
+
+func (d *hexDumper) token(off, tkl int) (int, error) {
+	if tkl > MaxTokenSize {
+		return off, ErrTokenTooLong
+	}
+	if len(d.data)-off < tkl {
+		return off, ErrMessageTruncated
+	}
+	if tkl > 0 {
+		d.bytes(off, d.data[off:off+tkl], fmt.Sprintf("Token: %X", d.data[off:off+tkl]))
+	}
+	return off + tkl, nil
+}
+
+// dumpV0 输出 V0 头部和负载, 返回解析结束的偏移
+func (d *hexDumper) dumpV0() (int, error) {
+	if len(d.data) < 4 {
+		return 0, ErrMessageTruncated
+	}
+	b := d.data[0]
+	d.line(0, d.data[:1], "Header")
+	if err := d.version(Version0); err != nil {
+		return 1, err
+	}
+	d.bits(b, 5, 4, "Reserved")
+	d.bits(b, 1, 2, fmt.Sprintf("Type: %s (%d)", Type(b&0x3), b&0x3))
+	d.encoder(1)
+	d.line(2, d.data[2:4], fmt.Sprintf("CRC16: 0x%04X (little endian)", binary.LittleEndian.Uint16(d.data[2:4])))
+	if len(d.data) > 4 {
+		d.bytes(4, d.data[4:], fmt.Sprintf("Payload (%d bytes)", len(d.data)-4))
+	}
+	return len(d.data), nil
+}
+
+// dumpV1 输出 V1 头部, 选项和负载, 返回解析结束的偏移和选项个数
+func (d *hexDumper) dumpV1() (int, int, error) {
+	if len(d.data) < 4 {
+		return 0, 0, ErrMessageTruncated
+	}
+	b := d.data[0]
+	tkl := int(b & 0xf)
+	d.line(0, d.data[:1], "Header")
+	if err := d.version(Version1); err != nil {
+		return 1, 0, err
+	}
+	d.bits(b, 5, 2, fmt.Sprintf("Type: %s (%d)", Type((b>>4)&0x3), (b>>4)&0x3))
+	d.bits(b, 3, 4, fmt.Sprintf("Token Length: %d", tkl))
+	d.code(1)
+	d.messageID(2)
+	off, err := d.token(4, tkl)
+	if err != nil {
+		return off, 0, err
+	}
+	return d.body(off)
+}
+
+// dumpV2 输出 V2 头部, 选项和负载, 返回解析结束的偏移和选项个数
+func (d *hexDumper) dumpV2() (int, int, error) {
+	if len(d.data) < 8 {
+		return 0, 0, ErrMessageTruncated
+	}
+	b := d.data[0]
+	tkl := int((b >> 2) & 0xf)
+	d.line(0, d.data[:1], "Header")
+	if err := d.version(Version2); err != nil {
+		return 1, 0, err
+	}
+	d.bits(b, 5, 4, fmt.Sprintf("Token Length: %d", tkl))
+	d.bits(b, 1, 2, fmt.Sprintf("Type: %s (%d)", Type(b&0x3), b&0x3))
+	d.encoder(1)
+	d.line(2, d.data[2:4], fmt.Sprintf("CRC16: 0x%04X", binary.BigEndian.Uint16(d.data[2:4])))
+	d.messageID(4)
+	d.code(6)
+	d.line(7, d.data[7:8], fmt.Sprintf("RSUM8: 0x%02X", d.data[7]))
+	off, err := d.token(8, tkl)
+	if err != nil {
+		return off, 0, err
+	}
+	return d.body(off)
+}
+
+// body 输出选项, 负载分隔符和负载, 返回解析结束的偏移和选项个数
+func (d *hexDumper) body(off int) (int, int, error) {
+	defs := OptionDefs()
+	prev := 0
+	count := 0
+	for off < len(d.data) {
+		data := d.data[off:]
+		if data[0] == 0xff {
+			d.line(off, data[:1], "Payload Marker")
+			off++
+			if off < len(d.data) {
+				d.bytes(off, d.data[off:], fmt.Sprintf("Payload (%d bytes)", len(d.data)-off))
+			}
+			return len(d.data), count, nil
+		}
+		delta := int(data[0] >> 4)
+		length := int(data[0] & 0x0f)
+		if delta == ExtendOptionError || length == ExtendOptionError {
+			return off, count, ErrOptionUnexpectedExtendMarker
+		}
+		hdr := 1
+		proc, delta, err := parseExtOpt(data[hdr:], delta)
+		if err != nil {
+			return off, count, err
+		}
+		hdr += proc
+		proc, length, err = parseExtOpt(data[hdr:], length)
+		if err != nil {
+			return off, count, err
+		}
+		hdr += proc
+		if len(data)-hdr < length {
+			return off, count, ErrOptionTruncated
+		}
+
+		id := OptionID(prev + delta)
+		d.bytes(off, data[:hdr], fmt.Sprintf("Option: %s (%d)", id, id))
+		d.bits(data[0], 7, 4, fmt.Sprintf("Delta: %d", delta))
+		d.bits(data[0], 3, 4, fmt.Sprintf("Length: %d", length))
+		value := data[hdr : hdr+length]
+		desc, err := optionValueString(defs, id, value)
+		if err != nil {
+			return off + hdr, count, err
+		}
+		if length > 0 {
+			d.bytes(off+hdr, value, "Value: "+desc)
+		} else {
+			d.line(0, nil, "Value: "+desc)
+		}
+		off += hdr + length
+		prev = int(id)
+		count++
+	}
+	return off, count, nil
+}
+
+// optionValueString 按选项定义格式化选项值, 未知选项返回错误
+func optionValueString(defs map[OptionID]OptionDef, id OptionID, value []byte) (string, error) {
+	var o Option
+	if _, err := o.Unmarshal(defs, id, value); err != nil {
+		return "", err
+	}
+	if o.ID == 0 {
+		return fmt.Sprintf("%X (ignored)", value), nil
+	}
+	switch v := o.Value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v), nil
+	case []byte:
+		if len(v) == 0 {
+			return "Empty", nil
+		}
+		return fmt.Sprintf("%X", v), nil
+	}
+	return fmt.Sprintf("%v", o.Value), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+type errDecoder struct {
+	err error
+}
+
+func (d errDecoder) Decode([]byte, *Message) (int, error) {
+	return -1, d.err
+}
+
+func TestHexDump(t *testing.T) {
+	tests := []struct {
+		name string
+		ver  Ver
+		data []byte
+	}{
+		{
+			name: "v0",
+			ver:  Version0,
+			data: append([]byte{0x01, 0x12, 0x34, 0x12}, "hello"...),
+		},
+		{
+			name: "v1",
+			ver:  Version1,
+			data: append([]byte{0x44, 0x03, 0x12, 0x34, 0x01, 0x02, 0x03, 0x04, 0xB3, 'l', 'e', 'd', 0x10, 0xFF}, "on"...),
+		},
+		{
+			name: "v2",
+			ver:  Version2,
+			data: append(append([]byte{0x91, 0x12, 0xAB, 0xCD, 0x00, 0x2A, 0x45, 0x00, 0xA1, 0xB2, 0xC3, 0xD4, 0xBC},
+				"temperature1"...), append([]byte{0x00, 0x00, 0xFF}, `{"t":21.5,"h":40}`...)...),
+		},
+		{
+			name: "truncated_token",
+			ver:  Version1,
+			data: []byte{0x44, 0x01, 0x00, 0x01, 0x01, 0x02},
+		},
+		{
+			name: "truncated_option",
+			ver:  Version2,
+			data: []byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0xB5, 'a', 'b'},
+		},
+		{
+			name: "wrong_version",
+			ver:  Version2,
+			data: []byte{0x40, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "unsupported_version",
+			ver:  Version3,
+			data: []byte{0xC0, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "empty",
+			ver:  Version1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, HexDump(tt.data, tt.ver))
+		})
+	}
+}
+
+func TestHexDumpDecodeError(t *testing.T) {
+	RegisterDecoder(Version0, errDecoder{err: ErrInvalidRCRC16})
+	t.Cleanup(func() { RegisterDecoder(Version0, nil) })
+	checkGolden(t, "decode_error", HexDump([]byte{0x00, 0x00, 0xFF, 0xFF, 0x01}, Version0))
+}
+
+func TestHexDumpWriterError(t *testing.T) {
+	require.ErrorIs(t, HexDumpWriter([]byte{0x00, 0x00, 0x00, 0x00}, Version0, errWriter{}), os.ErrClosed)
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "hexdump", name+".golden")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), got)
+}
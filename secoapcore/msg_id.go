@@ -45,3 +45,40 @@ func RandMID() int32 {
 func ValidateMID(mid int32) bool {
 	return mid >= 0 && mid <= math.MaxUint16
 }
+
+// DecodeMessageIDV1 peeks the message id from a version 1 header without a full decode.
+func DecodeMessageIDV1(data []byte) (int32, error) {
+	if len(data) < 4 {
+		return -1, ErrMessageTruncated
+	}
+	if data[0]>>6 != 1 {
+		return -1, ErrMessageInvalidVersion
+	}
+	return int32(binary.BigEndian.Uint16(data[2:4])), nil
+}
+
+// DecodeMessageIDV2 peeks the message id from a version 2 header without a full decode.
+func DecodeMessageIDV2(data []byte) (int32, error) {
+	if len(data) < 8 {
+		return -1, ErrMessageTruncated
+	}
+	if data[0]>>6 != 2 {
+		return -1, ErrMessageInvalidVersion
+	}
+	return int32(binary.BigEndian.Uint16(data[4:6])), nil
+}
+
+// DecodeMessageID peeks the message id according to the version bits, version 0 has no message id.
+func DecodeMessageID(data []byte) (int32, error) {
+	ver, err := GetVersion(data)
+	if err != nil {
+		return -1, err
+	}
+	switch ver {
+	case Version1:
+		return DecodeMessageIDV1(data)
+	case Version2:
+		return DecodeMessageIDV2(data)
+	}
+	return -1, ErrMessageInvalidVersion
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMessageID(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    int32
+		wantErr error
+	}{
+		{
+			name: "v1",
+			data: []byte{0x40, 0x01, 0x12, 0x34},
+			want: 0x1234,
+		},
+		{
+			name: "v2",
+			data: []byte{0x80, 0x00, 0xFF, 0xFF, 0xAB, 0xCD, 0x01, 0x00},
+			want: 0xABCD,
+		},
+		{
+			name:    "v1 truncated",
+			data:    []byte{0x40, 0x01, 0x12},
+			wantErr: ErrMessageTruncated,
+		},
+		{
+			name:    "v2 truncated",
+			data:    []byte{0x80, 0x00, 0xFF, 0xFF, 0xAB, 0xCD},
+			wantErr: ErrMessageTruncated,
+		},
+		{
+			name:    "v0",
+			data:    []byte{0x00, 0x00, 0xFF, 0xFF},
+			wantErr: ErrMessageInvalidVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMessageID(tt.data)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+	_, err := DecodeMessageIDV1([]byte{0x80, 0x00, 0x00, 0x00})
+	require.ErrorIs(t, err, ErrMessageInvalidVersion)
+}
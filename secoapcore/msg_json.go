@@ -0,0 +1,135 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+type jsonCode struct {
+	Name  string `json:"name"`
+	Value uint8  `json:"value"`
+}
+
+// jsonOption value is a string for string options, a number for uint options and hex otherwise.
+type jsonOption struct {
+	ID    uint32          `json:"id"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonMessage struct {
+	Ver         Ver          `json:"ver"`
+	Type        Type         `json:"type"`
+	Code        jsonCode     `json:"code"`
+	MessageID   int32        `json:"messageId"`
+	Token       string       `json:"token,omitempty"`
+	Options     []jsonOption `json:"options,omitempty"`
+	Payload     []byte       `json:"payload,omitempty"`
+	EncoderID   int32        `json:"encoderId"`
+	EncoderType int32        `json:"encoderType"`
+	Crc16       uint16       `json:"crc16"`
+	Rsum8       uint8        `json:"rsum8"`
+}
+
+// MarshalJSON encodes the message to JSON, the token is hex encoded and the payload base64 encoded.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	jm := jsonMessage{
+		Ver:         m.Ver,
+		Type:        m.Type,
+		Code:        jsonCode{Name: m.Code.String(), Value: uint8(m.Code)},
+		MessageID:   m.MessageID,
+		Token:       hex.EncodeToString(m.Token),
+		Payload:     m.Payload,
+		EncoderID:   m.EncoderID,
+		EncoderType: m.EncoderType,
+		Crc16:       m.Crc16,
+		Rsum8:       m.Rsum8,
+	}
+	for _, o := range m.Opts {
+		var value interface{}
+		switch def, _ := LookupOptionDef(o.ID); def.ValueFormat {
+		case ValueString:
+			value = string(o.ToBytes())
+		case ValueUint:
+			value = decodeInt(o.ToBytes())
+		default:
+			value = hex.EncodeToString(o.ToBytes())
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		jm.Options = append(jm.Options, jsonOption{ID: uint32(o.ID), Name: o.ID.String(), Value: raw})
+	}
+	return json.Marshal(jm)
+}
+
+// UnmarshalJSON decodes the message from the format written by MarshalJSON.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var jm jsonMessage
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	token, err := hex.DecodeString(jm.Token)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	var opts Options
+	if len(jm.Options) > 0 {
+		opts = NewOptions(len(jm.Options))
+	}
+	for _, jo := range jm.Options {
+		o := Option{ID: OptionID(jo.ID)}
+		switch def, _ := LookupOptionDef(o.ID); def.ValueFormat {
+		case ValueString:
+			var v string
+			err = json.Unmarshal(jo.Value, &v)
+			o.Value = v
+		case ValueUint:
+			var v uint32
+			err = json.Unmarshal(jo.Value, &v)
+			o.Value = v
+		default:
+			var v string
+			if err = json.Unmarshal(jo.Value, &v); err == nil {
+				o.Value, err = hex.DecodeString(v)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value of option %v: %w", o.ID, err)
+		}
+		opts = append(opts, o)
+	}
+
+	*m = Message{
+		Ver:         jm.Ver,
+		Code:        Code(jm.Code.Value),
+		Opts:        opts,
+		Payload:     jm.Payload,
+		MessageID:   jm.MessageID,
+		Type:        jm.Type,
+		EncoderID:   jm.EncoderID,
+		EncoderType: jm.EncoderType,
+		Crc16:       jm.Crc16,
+		Rsum8:       jm.Rsum8,
+	}
+	if len(token) > 0 {
+		m.Token = token
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageJSON(t *testing.T) {
+	m := Message{
+		Ver:   Version2,
+		Token: Token{0x01, 0x02, 0xAB},
+		Opts: Options{
+			{ID: ETag, Value: []byte{0xDE, 0xAD}},
+			{ID: URIPath, Value: "iotda"},
+			{ID: URIPath, Value: "status"},
+			{ID: ContentFormat, Value: uint32(AppJSON)},
+			{ID: MaxAge, Value: uint32(70000)},
+		},
+		Code:        POST,
+		Payload:     []byte(`{"a":1}`),
+		MessageID:   0x1234,
+		Type:        NonConfirmable,
+		EncoderID:   1,
+		EncoderType: 6,
+		Crc16:       0xBEEF,
+		Rsum8:       0x7F,
+	}
+
+	data, err := json.Marshal(&m)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"token":"0102ab"`)
+	require.Contains(t, string(data), `"code":{"name":"POST","value":2}`)
+	require.Contains(t, string(data), `{"id":4,"name":"ETag","value":"dead"}`)
+	require.Contains(t, string(data), `{"id":11,"name":"URIPath","value":"iotda"}`)
+	require.Contains(t, string(data), `{"id":14,"name":"MaxAge","value":70000}`)
+
+	var got Message
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, m, got)
+
+	empty := Message{MessageID: -1, Type: Unset}
+	data, err = json.Marshal(&empty)
+	require.NoError(t, err)
+	got = Message{}
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, empty, got)
+
+	require.Error(t, json.Unmarshal([]byte(`{"token":"zz"}`), &got))
+	require.Error(t, json.Unmarshal([]byte(`{"options":[{"id":14,"value":"x"}]}`), &got))
+}
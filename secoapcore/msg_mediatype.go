@@ -16,7 +16,9 @@ package secoapcore
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"sync"
 )
 
 // MediaType specifies the content type of a message.
@@ -83,8 +85,81 @@ var mediaTypeToString = map[MediaType]string{
 	AppLwm2mCbor:      "application/vnd.oma.lwm2m+cbor",
 }
 
+var (
+	customMediaTypesMu sync.RWMutex
+	customMediaTypes   = map[MediaType]string{}
+)
+
+// RegisterMediaType 注册自定义的内容格式, id 或 mimeString 已注册为其他值时返回 ErrMediaTypeConflict,
+// 重复注册相同的值不报错
+func RegisterMediaType(id MediaType, mimeString string) error {
+	if mimeString == "" {
+		return fmt.Errorf("%w: empty mime string for %d", ErrMediaTypeConflict, id)
+	}
+	customMediaTypesMu.Lock()
+	defer customMediaTypesMu.Unlock()
+	if str, ok := lookupMediaType(id); ok {
+		if str == mimeString {
+			return nil
+		}
+		return fmt.Errorf("%w: %d is registered as %q", ErrMediaTypeConflict, id, str)
+	}
+	if other, ok := lookupMimeString(mimeString); ok {
+		return fmt.Errorf("%w: %q is registered as %d", ErrMediaTypeConflict, mimeString, other)
+	}
+	customMediaTypes[id] = mimeString
+	return nil
+}
+
+// UnregisterMediaType 移除 RegisterMediaType 注册的内容格式, 内置的内容格式不能移除
+func UnregisterMediaType(id MediaType) {
+	customMediaTypesMu.Lock()
+	defer customMediaTypesMu.Unlock()
+	delete(customMediaTypes, id)
+}
+
+// RegisteredMediaTypes 返回所有已知的内容格式, 包括内置和注册的
+func RegisteredMediaTypes() map[MediaType]string {
+	customMediaTypesMu.RLock()
+	defer customMediaTypesMu.RUnlock()
+	types := make(map[MediaType]string, len(mediaTypeToString)+len(customMediaTypes))
+	for id, str := range mediaTypeToString {
+		types[id] = str
+	}
+	for id, str := range customMediaTypes {
+		types[id] = str
+	}
+	return types
+}
+
+// lookupMediaType 调用者需持有 customMediaTypesMu
+func lookupMediaType(id MediaType) (string, bool) {
+	if str, ok := customMediaTypes[id]; ok {
+		return str, true
+	}
+	str, ok := mediaTypeToString[id]
+	return str, ok
+}
+
+// lookupMimeString 调用者需持有 customMediaTypesMu
+func lookupMimeString(v string) (MediaType, bool) {
+	for key, val := range customMediaTypes {
+		if val == v {
+			return key, true
+		}
+	}
+	for key, val := range mediaTypeToString {
+		if val == v {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
 func (c MediaType) String() string {
-	str, ok := mediaTypeToString[c]
+	customMediaTypesMu.RLock()
+	str, ok := lookupMediaType(c)
+	customMediaTypesMu.RUnlock()
 	if !ok {
 		return "MediaType(" + strconv.FormatInt(int64(c), 10) + ")"
 	}
@@ -92,10 +167,10 @@ func (c MediaType) String() string {
 }
 
 func ToMediaType(v string) (MediaType, error) {
-	for key, val := range mediaTypeToString {
-		if val == v {
-			return key, nil
-		}
+	customMediaTypesMu.RLock()
+	defer customMediaTypesMu.RUnlock()
+	if key, ok := lookupMimeString(v); ok {
+		return key, nil
 	}
 	return 0, errors.New("not found")
 }
@@ -0,0 +1,51 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMediaType(t *testing.T) {
+	const custom MediaType = 65000
+	const mime = "application/vnd.giterlab.telemetry+cbor"
+	require.Equal(t, "MediaType(65000)", custom.String())
+
+	require.NoError(t, RegisterMediaType(custom, mime))
+	t.Cleanup(func() { UnregisterMediaType(custom) })
+	require.Equal(t, mime, custom.String())
+	got, err := ToMediaType(mime)
+	require.NoError(t, err)
+	require.Equal(t, custom, got)
+	require.Equal(t, mime, RegisteredMediaTypes()[custom])
+	require.Equal(t, "application/json", RegisteredMediaTypes()[AppJSON])
+
+	// 相同的值可以重复注册
+	require.NoError(t, RegisterMediaType(custom, mime))
+	require.ErrorIs(t, RegisterMediaType(custom, "application/other"), ErrMediaTypeConflict)
+	require.ErrorIs(t, RegisterMediaType(65001, mime), ErrMediaTypeConflict)
+	require.ErrorIs(t, RegisterMediaType(AppJSON, "application/not-json"), ErrMediaTypeConflict)
+	require.ErrorIs(t, RegisterMediaType(65001, ""), ErrMediaTypeConflict)
+
+	UnregisterMediaType(custom)
+	require.Equal(t, "MediaType(65000)", custom.String())
+	_, err = ToMediaType(mime)
+	require.Error(t, err)
+	// 内置的内容格式不能移除
+	UnregisterMediaType(AppJSON)
+	require.Equal(t, "application/json", AppJSON.String())
+}
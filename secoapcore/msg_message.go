@@ -15,8 +15,11 @@
 package secoapcore
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"net/http"
 	"reflect"
 	"strings"
 )
@@ -28,7 +31,7 @@ const MaxTokenSize = 8
 type Message struct {
 	Ver     Ver
 	Token   Token
-	Opts    Options
+	Opts    Options // prefer GetOpts/SetOpts, direct access will be deprecated in a future version
 	Code    Code
 	Payload []byte
 
@@ -45,15 +48,91 @@ type Message struct {
 	Rsum8 uint8
 }
 
+// ValidatePayload checks the payload for a 0xFF byte within its first 4 bytes.
+//
+// This is legal, but may indicate a mis-assembled message, so it is only
+// reported through the package logger and nil is returned.
+func ValidatePayload(payload []byte) error {
+	head := payload
+	if len(head) > 4 {
+		head = head[:4]
+	}
+	if i := bytes.IndexByte(head, 0xFF); i >= 0 {
+		GetLogger().Debug("payload contains 0xFF near its start, message may be mis-assembled", "offset", i, "payloadLen", len(payload))
+	}
+	return nil
+}
+
 // IsConfirmable returns true if this message is confirmable.
 func (m Message) IsConfirmable() bool {
 	return m.Type == Confirmable
 }
 
+// PayloadReader returns a reader over the payload, or http.NoBody if the message has no payload.
+func (m *Message) PayloadReader() io.Reader {
+	if len(m.Payload) == 0 {
+		return http.NoBody
+	}
+	return bytes.NewReader(m.Payload)
+}
+
+// GetOpts returns a copy of the options, modifying it does not affect the message.
+//
+// The error of Options.Clone is returned as is.
+func (m *Message) GetOpts() (Options, error) {
+	return m.Opts.Clone()
+}
+
+// SetOpts replaces the options of the message.
+func (m *Message) SetOpts(opts Options) {
+	m.Opts = opts
+}
+
+// Clone returns a deep copy of the message which shares no memory with m.
+//
+// The options are copied with Options.DeepCopy, so their values are []byte.
+func (m *Message) Clone() Message {
+	c := *m
+	if m.Token != nil {
+		c.Token = append(make(Token, 0, len(m.Token)), m.Token...)
+	}
+	c.Opts = m.Opts.DeepCopy()
+	if m.Payload != nil {
+		c.Payload = append(make([]byte, 0, len(m.Payload)), m.Payload...)
+	}
+	return c
+}
+
+// Equal reports whether both messages have the same fields, including the
+// checksums. Options are compared by ID and encoded value, see Options.Equal.
+func (m *Message) Equal(other *Message) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	return m.Ver == other.Ver &&
+		m.Code == other.Code &&
+		m.MessageID == other.MessageID &&
+		m.Type == other.Type &&
+		m.EncoderID == other.EncoderID &&
+		m.EncoderType == other.EncoderType &&
+		m.Crc16 == other.Crc16 &&
+		m.Rsum8 == other.Rsum8 &&
+		bytes.Equal(m.Token, other.Token) &&
+		bytes.Equal(m.Payload, other.Payload) &&
+		m.Opts.Equal(other.Opts)
+}
+
 // Options gets all the values for the given option.
 func (m Message) Options(o OptionID) []interface{} {
 	var rv []interface{}
 
+	if m.Opts.IsSorted() {
+		start, end := m.Opts.RangeByID(o)
+		for _, v := range m.Opts[start:end] {
+			rv = append(rv, v.Value)
+		}
+		return rv
+	}
 	for _, v := range m.Opts {
 		if o == v.ID {
 			rv = append(rv, v.Value)
@@ -65,10 +144,14 @@ func (m Message) Options(o OptionID) []interface{} {
 
 // Option gets the first value for the given option ID.
 func (m Message) Option(o OptionID) interface{} {
-	for _, v := range m.Opts {
-		if o == v.ID {
-			return v.Value
+	if m.Opts.IsSorted() {
+		if start, end := m.Opts.RangeByID(o); start < end {
+			return m.Opts[start].Value
 		}
+		return nil
+	}
+	if opt, ok := m.Opts.FindFirst(o); ok {
+		return opt.Value
 	}
 	return nil
 }
@@ -88,7 +171,7 @@ func (m Message) Path() []string {
 
 // PathString gets a path as a / separated string.
 func (m Message) PathString() string {
-	return strings.Join(m.Path(), "/")
+	return m.Opts.PathString()
 }
 
 // SetPathString sets a path by a / separated string.
@@ -165,10 +248,18 @@ func (m *Message) String() string {
 
 // Anlayse 协议分析
 func (m *Message) Analyse() string {
+	var sb strings.Builder
+	_ = m.AnalyseTo(&sb)
+	return sb.String()
+}
+
+// AnalyseTo 将协议分析结果写入 w, Payload 分块写入, 不会一次性生成整个 HEX 字符串
+func (m *Message) AnalyseTo(w io.Writer) error {
 	var out string
 
 	if m == nil {
-		return "nil"
+		_, err := io.WriteString(w, "nil")
+		return err
 	}
 
 	bf := func(num int, bits int) string {
@@ -192,6 +283,7 @@ func (m *Message) Analyse() string {
 		return fmt.Sprintf("% 02X", v)
 	}
 
+	payload := m.Payload
 	switch m.Ver {
 	case Version0:
 		tmpbufCRC16 := []byte{0, 0}
@@ -206,15 +298,14 @@ func (m *Message) Analyse() string {
    |%v|0 0 0 0|%v|%v|%v|%v|
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |Payload: HEX(%d)
-   | %v`,
+   | `,
 			m.Ver, m.Type, m.EncoderID, m.EncoderType, m.Crc16,
 			bf(int(m.Ver), 2),
 			bf(int(m.Type), 2),
 			bf(int(m.EncoderID), 4),
 			bf(int(m.EncoderType), 4),
 			bf(int(crc16), 16),
-			len(m.Payload),
-			nilf(m.Payload))
+			len(m.Payload))
 
 	case Version1:
 		out = fmt.Sprintf(`
@@ -233,7 +324,7 @@ func (m *Message) Analyse() string {
    | %v
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |SEP: 0xFF      |Payload: HEX(%d)
-   |%v| %v`,
+   |%v| `,
 			m.Ver, m.Type, len(m.Token), m.Code, m.MessageID,
 			bf(int(m.Ver), 2),
 			bf(int(m.Type), 2),
@@ -245,8 +336,7 @@ func (m *Message) Analyse() string {
 			m.Opts.URL(),
 			m.Opts.String("\n   | "),
 			len(m.Payload),
-			bf(int(0xFF), 8),
-			nilf(m.Payload))
+			bf(int(0xFF), 8))
 
 	case Version2:
 		out = fmt.Sprintf(`
@@ -268,7 +358,7 @@ func (m *Message) Analyse() string {
    | %v
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |SEP: 0xFF      |Payload: HEX(%d)
-   |%v| %v`,
+   |%v| `,
 			m.Ver, len(m.Token), m.Type, m.EncoderID, m.EncoderType, m.Crc16,
 			bf(int(m.Ver), 2),
 			bf(int(len(m.Token)), 4),
@@ -285,9 +375,56 @@ func (m *Message) Analyse() string {
 			m.Opts.URL(),
 			m.Opts.String("\n   | "),
 			len(m.Payload),
-			bf(int(0xFF), 8),
-			nilf(m.Payload))
+			bf(int(0xFF), 8))
+
+	default:
+		// 保留版本无法解析, Payload 中为原始数据, 显示前4个字节
+		raw := m.Payload
+		if len(raw) > 4 {
+			raw = raw[:4]
+		}
+		out = fmt.Sprintf(`
+    0                   1                   2                   3
+    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |V %d| %v is reserved, can not be analysed
+   |%v|
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |Raw: (first 4 bytes) HEX(%d)
+   | `,
+			m.Ver, m.Ver,
+			bf(int(m.Ver)&0x3, 2),
+			len(raw))
+		payload = raw
+	}
+
+	if _, err := io.WriteString(w, out); err != nil {
+		return err
 	}
+	return writeHex(w, payload)
+}
+
+// analyseChunkSize 每次格式化写入的 Payload 字节数
+const analyseChunkSize = 1024
 
-	return out
+// writeHex 按 "% 02X" 格式分块写入 data, data 为空时写入 Empty
+func writeHex(w io.Writer, data []byte) error {
+	if len(data) == 0 {
+		_, err := io.WriteString(w, "Empty")
+		return err
+	}
+	for i := 0; i < len(data); i += analyseChunkSize {
+		end := i + analyseChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		sep := ""
+		if i > 0 {
+			sep = " "
+		}
+		if _, err := fmt.Fprintf(w, "%s% 02X", sep, data[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,133 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageGetOpts(t *testing.T) {
+	m := Message{}
+	m.SetOpts(newPathOptions(t, "/a/b"))
+
+	opts, err := m.GetOpts()
+	require.NoError(t, err)
+	require.Equal(t, "a/b", opts.PathString())
+	opts[0].Value.([]byte)[0] = 'x'
+	opts = opts.Remove(URIPath)
+	require.Empty(t, opts)
+	require.Equal(t, "a/b", m.Opts.PathString())
+}
+
+func TestMessageAnalyseReserved(t *testing.T) {
+	m := Message{Ver: Version3, Payload: []byte{0xC0, 0x01, 0x02, 0x03, 0x04}}
+	out := m.Analyse()
+	require.Contains(t, out, "Ver3 is reserved")
+	require.True(t, strings.HasSuffix(out, " C0 01 02 03"))
+	require.NotContains(t, out, "04")
+}
+
+func TestMessagePayloadReader(t *testing.T) {
+	m := Message{}
+	require.Equal(t, http.NoBody, m.PayloadReader())
+
+	m.Payload = []byte("hello")
+	b, err := io.ReadAll(m.PayloadReader())
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(b))
+}
+
+func TestMessageClone(t *testing.T) {
+	m := Message{
+		Ver:         Version2,
+		Token:       Token{0x01, 0x02, 0x03, 0x04},
+		Opts:        append(newPathOptions(t, "/a/b"), Option{ID: ContentFormat, Value: AppJSON}),
+		Code:        POST,
+		Payload:     []byte("payload"),
+		MessageID:   0x1234,
+		Type:        Confirmable,
+		EncoderID:   1,
+		EncoderType: 6,
+		Crc16:       0xABCD,
+		Rsum8:       0x12,
+	}
+	c := m.Clone()
+	require.True(t, m.Equal(&c))
+	require.True(t, c.Equal(&m))
+
+	c.Token[0] = 0xFF
+	c.Payload[0] = 'x'
+	c.Opts[0].Value.([]byte)[0] = 'x'
+	require.Equal(t, Token{0x01, 0x02, 0x03, 0x04}, m.Token)
+	require.Equal(t, []byte("payload"), m.Payload)
+	require.Equal(t, "a/b", m.Opts.PathString())
+	require.False(t, m.Equal(&c))
+
+	empty := Message{}
+	c = empty.Clone()
+	require.Nil(t, c.Token)
+	require.Nil(t, c.Opts)
+	require.Nil(t, c.Payload)
+	require.True(t, empty.Equal(&c))
+}
+
+func TestMessageEqual(t *testing.T) {
+	a := Message{Ver: Version2, Token: Token{0x01}, Opts: Options{{ID: ContentFormat, Value: AppJSON}}, Payload: []byte("p")}
+	b := a.Clone()
+	require.True(t, a.Equal(&b))
+
+	b.Crc16 = 1
+	require.False(t, a.Equal(&b))
+	b = a.Clone()
+	b.Rsum8 = 1
+	require.False(t, a.Equal(&b))
+	b = a.Clone()
+	b.Opts = Options{{ID: ContentFormat, Value: AppCBOR}}
+	require.False(t, a.Equal(&b))
+
+	var nilMsg *Message
+	require.True(t, nilMsg.Equal(nil))
+	require.False(t, a.Equal(nil))
+	require.False(t, nilMsg.Equal(&a))
+}
+
+func TestMessageOptionReassignedInPlace(t *testing.T) {
+	m := Message{Opts: Options{{ID: URIPath, Value: "a"}, {ID: URIPath, Value: "b"}, {ID: URIQuery, Value: "x"}}}
+	require.Equal(t, "a", m.Option(URIPath))
+
+	// 同长度原地改写为未排序的选项
+	m.Opts = append(m.Opts[:0], Option{ID: URIQuery, Value: "y"}, Option{ID: URIPath, Value: "b"}, Option{ID: URIPath, Value: "c"})
+	require.Equal(t, "b", m.Option(URIPath))
+	require.Equal(t, []interface{}{"b", "c"}, m.Options(URIPath))
+	require.Equal(t, []interface{}{"y"}, m.Options(URIQuery))
+}
+
+func TestMessagePathStringUnsorted(t *testing.T) {
+	var m Message
+	m.AddOption(URIQuery, "x=1")
+	m.AddOption(URIPath, "a")
+	m.AddOption(ContentFormat, AppJSON)
+	m.AddOption(URIPath, "b")
+	require.False(t, m.Opts.IsSorted())
+	require.Equal(t, "a/b", m.PathString())
+
+	m.SetPath([]string{"c", "", "d"})
+	require.Equal(t, "c//d", m.PathString())
+}
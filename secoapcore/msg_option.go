@@ -36,6 +36,7 @@ type OptionID uint32
    |   7 | x  | x | - |   | Uri-Port       | uint   | 0-2    | (see    |
    |     |    |   |   |   |                |        |        | below)  |
    |   8 |    |   |   | x | Location-Path  | string | 0-255  | (none)  |
+   |   9 | x  | x | - |   | OSCORE         | opaque | 0-255  | (none)  |
    |  11 | x  | x | - | x | Uri-Path       | string | 0-255  | (none)  |
    |  12 |    |   |   |   | Content-Format | uint   | 0-2    | (none)  |
    |  14 |    | x | - |   | Max-Age        | uint   | 0-4    | 60      |
@@ -61,6 +62,7 @@ const (
 	Observe       OptionID = 6
 	URIPort       OptionID = 7
 	LocationPath  OptionID = 8
+	OSCORE        OptionID = 9 // RFC 8613
 	URIPath       OptionID = 11
 	ContentFormat OptionID = 12
 	MaxAge        OptionID = 14
@@ -86,15 +88,17 @@ const (
 	// 65000 and 65535 inclusive are reserved for experiments.  They are not
 	// meant for vendor-specific use of any kind and MUST NOT be used in
 	// operational deployments.
-	GiterLabID    OptionID = 65000
-	GiterLabKey   OptionID = 65001
-	AccessID      OptionID = 65002
-	AccessKey     OptionID = 65003
-	CheckCRC32    OptionID = 65004
-	EncoderType   OptionID = 65005
-	EncoderID     OptionID = 65006
-	Flags         OptionID = 65007
-	PackageNumber OptionID = 65100
+	GiterLabID      OptionID = 65000
+	GiterLabKey     OptionID = 65001
+	AccessID        OptionID = 65002
+	AccessKey       OptionID = 65003
+	CheckCRC32      OptionID = 65004
+	EncoderType     OptionID = 65005
+	EncoderID       OptionID = 65006
+	Flags           OptionID = 65007
+	ContentEncoding OptionID = 65008 // payload compression, see package codec
+	ObserveDelta    OptionID = 65010 // varint delta of an observed value, see DeltaEncoder
+	PackageNumber   OptionID = 65100
 )
 
 var optionIDToString = map[OptionID]string{
@@ -105,6 +109,7 @@ var optionIDToString = map[OptionID]string{
 	Observe:       "Observe",
 	URIPort:       "URIPort",
 	LocationPath:  "LocationPath",
+	OSCORE:        "OSCORE",
 	URIPath:       "URIPath",
 	ContentFormat: "ContentFormat",
 	MaxAge:        "MaxAge",
@@ -120,15 +125,17 @@ var optionIDToString = map[OptionID]string{
 	NoResponse:    "NoResponse",
 
 	// GiterLab: add private options
-	GiterLabID:    "GiterLabID",
-	GiterLabKey:   "GiterLabKey",
-	AccessID:      "AccessID",
-	AccessKey:     "AccessKey",
-	CheckCRC32:    "CheckCRC32",
-	EncoderType:   "EncoderType",
-	EncoderID:     "EncoderID",
-	Flags:         "Flags",
-	PackageNumber: "PackageNumber",
+	GiterLabID:      "GiterLabID",
+	GiterLabKey:     "GiterLabKey",
+	AccessID:        "AccessID",
+	AccessKey:       "AccessKey",
+	CheckCRC32:      "CheckCRC32",
+	EncoderType:     "EncoderType",
+	EncoderID:       "EncoderID",
+	Flags:           "Flags",
+	ContentEncoding: "ContentEncoding",
+	ObserveDelta:    "ObserveDelta",
+	PackageNumber:   "PackageNumber",
 }
 
 func (o OptionID) String() string {
@@ -173,6 +180,7 @@ var CoapOptionDefs = map[OptionID]OptionDef{
 	Observe:       {ValueFormat: ValueUint, MinLen: 0, MaxLen: 3},
 	URIPort:       {ValueFormat: ValueUint, MinLen: 0, MaxLen: 2},
 	LocationPath:  {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
+	OSCORE:        {ValueFormat: ValueOpaque, MinLen: 0, MaxLen: 255},
 	URIPath:       {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
 	ContentFormat: {ValueFormat: ValueUint, MinLen: 0, MaxLen: 2},
 	MaxAge:        {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
@@ -185,17 +193,20 @@ var CoapOptionDefs = map[OptionID]OptionDef{
 	ProxyURI:      {ValueFormat: ValueString, MinLen: 1, MaxLen: 1034},
 	ProxyScheme:   {ValueFormat: ValueString, MinLen: 1, MaxLen: 255},
 	Size1:         {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	NoResponse:    {ValueFormat: ValueUint, MinLen: 0, MaxLen: 1},
 
 	// GiterLab: add private options
-	GiterLabID:    {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
-	GiterLabKey:   {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
-	AccessID:      {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
-	AccessKey:     {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
-	CheckCRC32:    {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
-	EncoderType:   {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
-	EncoderID:     {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
-	Flags:         {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
-	PackageNumber: {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	GiterLabID:      {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
+	GiterLabKey:     {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
+	AccessID:        {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
+	AccessKey:       {ValueFormat: ValueString, MinLen: 0, MaxLen: 255},
+	CheckCRC32:      {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	EncoderType:     {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	EncoderID:       {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	Flags:           {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	ContentEncoding: {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
+	ObserveDelta:    {ValueFormat: ValueOpaque, MinLen: 1, MaxLen: 9},
+	PackageNumber:   {ValueFormat: ValueUint, MinLen: 0, MaxLen: 4},
 }
 
 // VerifyOptLen checks whether valueLen is within (min, max) length limits for given option.
@@ -385,6 +396,105 @@ func (o Option) ToBytes() []byte {
 	return encodeInt(v)
 }
 
+// uintLen 返回整数按 encodeInt 编码后的字节数
+func uintLen(v uint32) int {
+	switch {
+	case v == 0:
+		return 0
+	case v <= max1ByteNumber:
+		return 1
+	case v <= max2ByteNumber:
+		return 2
+	case v <= max3ByteNumber:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// uintValue 返回整数类型 Option 的值, ok 为 false 表示 Value 不是整数
+func (o Option) uintValue() (v uint32, ok bool) {
+	switch i := o.Value.(type) {
+	case MediaType:
+		return uint32(i), true
+	case int:
+		return uint32(i), true
+	case int32:
+		return uint32(i), true
+	case uint:
+		return uint32(i), true
+	case uint32:
+		return i, true
+	}
+	return 0, false
+}
+
+// valueLen 返回 Option Value 编码后的字节数, 与 len(o.ToBytes()) 相同但不分配内存
+func (o Option) valueLen() (int, error) {
+	switch i := o.Value.(type) {
+	case string:
+		return len(i), nil
+	case []byte:
+		return len(i), nil
+	}
+	v, ok := o.uintValue()
+	if !ok {
+		return -1, fmt.Errorf("invalid type for option %x: %T (%v)", o.ID, o.Value, o.Value)
+	}
+	return uintLen(v), nil
+}
+
+// putValue 将 Option Value 写入 buf, buf 的长度必须不小于 valueLen
+func (o Option) putValue(buf []byte) int {
+	switch i := o.Value.(type) {
+	case string:
+		return copy(buf, i)
+	case []byte:
+		return copy(buf, i)
+	}
+	v, _ := o.uintValue()
+	n := uintLen(v)
+	for k := n - 1; k >= 0; k-- {
+		buf[k] = byte(v)
+		v >>= 8
+	}
+	return n
+}
+
+// optionHeaderExtLen 返回 Option Delta 或 Option Length 扩展的字节数
+func optionHeaderExtLen(opt int) int {
+	switch {
+	case opt >= ExtendOptionWordAddend:
+		return 2
+	case opt >= ExtendOptionByteAddend:
+		return 1
+	}
+	return 0
+}
+
+// optionHeaderLen 返回 Option Header 的字节数
+func optionHeaderLen(delta, length int) int {
+	return 1 + optionHeaderExtLen(delta) + optionHeaderExtLen(length)
+}
+
+// MarshalHeaderTo writes only the option header (delta and length) to buf.
+//
+// It returns the header size, if buf is too short nothing is written and the
+// required size is returned without an error. The value can then be written
+// by the caller right after the header.
+func (o Option) MarshalHeaderTo(buf []byte, previousID OptionID) (int, error) {
+	length, err := o.valueLen()
+	if err != nil {
+		return -1, err
+	}
+	delta := int(o.ID) - int(previousID)
+	size := optionHeaderLen(delta, length)
+	if len(buf) < size {
+		return size, nil
+	}
+	return marshalOptionHeader(buf, delta, length)
+}
+
 func (o Option) MarshalValue(buf []byte) (int, error) {
 	value := o.ToBytes()
 	if len(buf) < len(value) {
@@ -491,14 +601,23 @@ func (o *Option) Unmarshal(optionDefs map[OptionID]OptionDef, optionID OptionID,
 	if def, ok := optionDefs[optionID]; ok {
 		if def.ValueFormat == ValueUnknown {
 			// Skip unrecognized options (RFC7252 section 5.4.1)
+			if DebugEnabled() {
+				GetLogger().Debug("skip option with unknown value format", "id", optionID, "len", len(data))
+			}
 			return len(data), nil
 		}
 		valueLen := len(data)
 		if !VerifyOptLen(optionDefs, optionID, valueLen) {
 			// Skip options with illegal value length (RFC7252 section 5.4.3)
+			if DebugEnabled() {
+				GetLogger().Debug("skip option with illegal value length", "id", optionID, "len", valueLen, "min", def.MinLen, "max", def.MaxLen)
+			}
 			return valueLen, nil
 		}
 	} else {
+		if DebugEnabled() {
+			GetLogger().Debug("unrecognized option", "id", optionID, "len", len(data))
+		}
 		return -1, fmt.Errorf("unrecognized option %d", optionID)
 	}
 	o.ID = optionID
@@ -15,7 +15,10 @@
 package secoapcore
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -23,6 +26,17 @@ type Options []Option
 
 const maxPathValue = 255
 
+// DefaultOptionsCapacity is the number of options preallocated for a message.
+const DefaultOptionsCapacity = 16
+
+// NewOptions allocates empty options with the given capacity.
+func NewOptions(capacity int) Options {
+	if capacity < 0 {
+		capacity = DefaultOptionsCapacity
+	}
+	return make(Options, 0, capacity)
+}
+
 func (o Options) Len() int {
 	return len(o)
 }
@@ -49,6 +63,83 @@ func (o Options) Minus(oid OptionID) Options {
 	return rv
 }
 
+// Filter returns a new Options with the options for which pred returns true,
+// nil is returned when no option matches.
+func (o Options) Filter(pred func(Option) bool) Options {
+	var rv Options
+	for _, opt := range o {
+		if pred(opt) {
+			rv = append(rv, opt)
+		}
+	}
+	return rv
+}
+
+// Map returns a new Options with every option replaced by fn(option).
+//
+// The result is sorted by ID, options with the same ID keep their relative order.
+func (o Options) Map(fn func(Option) Option) Options {
+	if o == nil {
+		return nil
+	}
+	rv := make(Options, len(o))
+	for i, opt := range o {
+		rv[i] = fn(opt)
+	}
+	sort.SliceStable(rv, func(i, j int) bool {
+		return rv[i].ID < rv[j].ID
+	})
+	return rv
+}
+
+// Reduce calls fn for every option, passing the result of the previous call as acc,
+// and returns the final result. init is the acc of the first call.
+func (o Options) Reduce(init interface{}, fn func(acc interface{}, o Option) interface{}) interface{} {
+	acc := init
+	for _, opt := range o {
+		acc = fn(acc, opt)
+	}
+	return acc
+}
+
+// FindFirst returns the first option with the given ID.
+func (o Options) FindFirst(id OptionID) (Option, bool) {
+	for _, opt := range o {
+		if opt.ID == id {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
+
+// IsSorted reports whether the options are sorted by ID, as required by FindByID and RangeByID.
+func (o Options) IsSorted() bool {
+	for i := 1; i < len(o); i++ {
+		if o[i].ID < o[i-1].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// FindByID returns the index of the first option with the given ID using binary search.
+//
+// The options must be sorted by ID, see IsSorted.
+func (o Options) FindByID(id OptionID) (int, bool) {
+	i := sort.Search(len(o), func(i int) bool { return o[i].ID >= id })
+	return i, i < len(o) && o[i].ID == id
+}
+
+// RangeByID returns the half-open range [start, end) of the options with the given ID,
+// start == end when there is none.
+//
+// The options must be sorted by ID, see IsSorted.
+func (o Options) RangeByID(id OptionID) (start, end int) {
+	start = sort.Search(len(o), func(i int) bool { return o[i].ID >= id })
+	end = start + sort.Search(len(o)-start, func(i int) bool { return o[start+i].ID > id })
+	return start, end
+}
+
 // GetPathBufferSize gets the size of the buffer required to store path in URI-Path options.
 //
 // If the path cannot be stored an error is returned.
@@ -132,6 +223,48 @@ func (options Options) SetLocationPath(buf []byte, path string) (Options, int, e
 	return setPath(options, LocationPath, buf, path)
 }
 
+// GetQueryBufferSize gets the size of the buffer required to store query in URI-Query options.
+//
+// If the query cannot be stored an error is returned.
+func GetQueryBufferSize(query string) (int, error) {
+	size := 0
+	for _, q := range strings.Split(strings.TrimPrefix(query, "?"), "&") {
+		if len(q) > maxPathValue {
+			return -1, ErrInvalidValueLength
+		}
+		size += len(q)
+	}
+	return size, nil
+}
+
+// SetQuery splits query by '&' to URIQuery options and copies it to buffer.
+//
+// A leading '?' is ignored, empty parameters are skipped.
+// Returns modified options, number of used buf bytes and error if occurs.
+func (options Options) SetQuery(buf []byte, query string) (Options, int, error) {
+	requiredSize, err := GetQueryBufferSize(query)
+	if err != nil {
+		return options, -1, err
+	}
+	if requiredSize > len(buf) {
+		return options, -1, ErrTooSmall
+	}
+	o := options.Remove(URIQuery)
+	encoded := 0
+	for _, q := range strings.Split(strings.TrimPrefix(query, "?"), "&") {
+		if q == "" {
+			continue
+		}
+		var enc int
+		o, enc, err = o.AddString(buf[encoded:], URIQuery, q)
+		if err != nil {
+			return o, -1, err
+		}
+		encoded += enc
+	}
+	return o, encoded, nil
+}
+
 func (options Options) path(buf []byte, id OptionID) (int, error) {
 	firstIdx, lastIdx, err := options.Find(id)
 	if err != nil {
@@ -173,6 +306,37 @@ func (options Options) Path() (string, error) {
 	return string(buf), nil
 }
 
+// PathString joins URIPath options by '/' without a leading '/'.
+//
+// Unlike Message.Path it does not build an intermediate []string. The options
+// are scanned linearly, so they do not need to be sorted.
+func (options Options) PathString() string {
+	size, n := 0, 0
+	for _, o := range options {
+		if o.ID == URIPath {
+			size += len(o.ToBytes())
+			n++
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.Grow(size + n - 1)
+	first := true
+	for _, o := range options {
+		if o.ID != URIPath {
+			continue
+		}
+		if !first {
+			sb.WriteByte('/')
+		}
+		first = false
+		sb.Write(o.ToBytes())
+	}
+	return sb.String()
+}
+
 // LocationPath joins Location-Path options by '/' to the buf.
 //
 // Returns number of used buf bytes or error when occurs.
@@ -278,11 +442,20 @@ func (options Options) GetStrings(id OptionID, r []string) (int, error) {
 
 // Queries gets URIQuery parameters.
 func (options Options) Queries() ([]string, error) {
+	return options.strings(URIQuery)
+}
+
+// LocationQueries returns the values of all Location-Query options.
+func (options Options) LocationQueries() ([]string, error) {
+	return options.strings(LocationQuery)
+}
+
+func (options Options) strings(id OptionID) ([]string, error) {
 	q := make([]string, 4)
-	n, err := options.GetStrings(URIQuery, q)
+	n, err := options.GetStrings(id, q)
 	if errors.Is(err, ErrTooSmall) {
 		q = append(q, make([]string, n-len(q))...)
-		n, err = options.GetStrings(URIQuery, q)
+		n, err = options.GetStrings(id, q)
 	}
 	if err != nil {
 		return nil, err
@@ -369,6 +542,52 @@ func (options Options) SetUint32(buf []byte, id OptionID, value uint32) (Options
 	return o, enc, err
 }
 
+// SetMany replaces/stores several options at once, pairs are OptionID, value alternating.
+//
+// Supported values are string, []byte and unsigned integer types (uint32, int, MediaType, ...).
+// Pairs are processed left-to-right and the function returns after the first error.
+// Returns modified options, number of used buf bytes and error if occurs.
+func (options Options) SetMany(buf []byte, pairs ...interface{}) (Options, int, error) {
+	if len(pairs)%2 != 0 {
+		return options, -1, fmt.Errorf("odd number of arguments: %d", len(pairs))
+	}
+	used := 0
+	for i := 0; i < len(pairs); i += 2 {
+		id, ok := pairs[i].(OptionID)
+		if !ok {
+			return options, -1, fmt.Errorf("invalid option id at %d: %T", i, pairs[i])
+		}
+		var n int
+		var err error
+		switch v := pairs[i+1].(type) {
+		case string:
+			options, n, err = options.SetString(buf[used:], id, v)
+		case []byte:
+			options, n, err = options.SetBytes(buf[used:], id, v)
+		case uint32:
+			options, n, err = options.SetUint32(buf[used:], id, v)
+		case MediaType:
+			options, n, err = options.SetUint32(buf[used:], id, uint32(v))
+		case int:
+			options, n, err = options.SetUint32(buf[used:], id, uint32(v))
+		case int32:
+			options, n, err = options.SetUint32(buf[used:], id, uint32(v))
+		case uint:
+			options, n, err = options.SetUint32(buf[used:], id, uint32(v))
+		default:
+			return options, -1, fmt.Errorf("invalid type for option %v: %T", id, v)
+		}
+		if err != nil {
+			if errors.Is(err, ErrTooSmall) {
+				return options, used + n, err
+			}
+			return options, -1, err
+		}
+		used += n
+	}
+	return options, used, nil
+}
+
 // SetContentFormat sets ContentFormat option.
 func (options Options) SetContentFormat(buf []byte, contentFormat MediaType) (Options, int, error) {
 	return options.SetUint32(buf, ContentFormat, uint32(contentFormat))
@@ -395,6 +614,17 @@ func (options Options) Accept() (MediaType, error) {
 	return MediaType(v), err
 }
 
+// ByID builds an index of the options by ID for O(1) lookups.
+//
+// The index is not cached, build it once and reuse it for repeated lookups.
+func (options Options) ByID() map[OptionID][]Option {
+	index := make(map[OptionID][]Option, len(options))
+	for _, o := range options {
+		index[o.ID] = append(index[o.ID], o)
+	}
+	return index
+}
+
 // Find returns range of type options. First number is index and second number is index of next option type.
 func (options Options) Find(id OptionID) (int, int, error) {
 	idxPre, idxPost := options.findPosition(id)
@@ -577,8 +807,54 @@ func (options Options) Marshal(buf []byte) (int, error) {
 	return length, nil
 }
 
+// MarshalTo marshals the options to buf without allocating.
+//
+// It returns the number of written bytes. If buf is nil or too short nothing
+// is written and the required size is returned without an error, so callers
+// must compare the result with len(buf).
+func (options Options) MarshalTo(buf []byte) (int, error) {
+	size := 0
+	previousID := OptionID(0)
+	for _, o := range options {
+		length, err := o.valueLen()
+		if err != nil {
+			return -1, err
+		}
+		size += optionHeaderLen(int(o.ID)-int(previousID), length) + length
+		previousID = o.ID
+	}
+	if len(buf) < size {
+		return size, nil
+	}
+
+	n := 0
+	previousID = 0
+	for _, o := range options {
+		hdr, err := o.MarshalHeaderTo(buf[n:], previousID)
+		if err != nil {
+			return -1, err
+		}
+		n += hdr
+		n += o.putValue(buf[n:])
+		previousID = o.ID
+	}
+	return n, nil
+}
+
 // Unmarshal unmarshals data bytes to options and returns the number of consumed bytes.
 func (options *Options) Unmarshal(data []byte, optionDefs map[OptionID]OptionDef) (int, error) {
+	return options.unmarshal(data, optionDefs, false)
+}
+
+// UnmarshalSkipPadding 同 Unmarshal, 但将 Payload 分隔符 0xFF 前连续的 0x00 字节当作填充跳过
+//
+// 0x00 同时是合法的选项编码 (delta 0, 长度 0, 如重复的空 Uri-Path 或空 If-Match),
+// 跳过填充会丢弃这些选项, 只应在确认对端会填充选项时使用
+func (options *Options) UnmarshalSkipPadding(data []byte, optionDefs map[OptionID]OptionDef) (int, error) {
+	return options.unmarshal(data, optionDefs, true)
+}
+
+func (options *Options) unmarshal(data []byte, optionDefs map[OptionID]OptionDef, skipPadding bool) (int, error) {
 	prev := 0
 	processed := 0
 	for len(data) > 0 {
@@ -586,6 +862,15 @@ func (options *Options) Unmarshal(data []byte, optionDefs map[OptionID]OptionDef
 			processed++
 			break
 		}
+		if skipPadding && data[0] == 0x00 {
+			// some implementations pad the options with 0x00 bytes before the payload marker
+			if n := paddingLen(data); n < len(data) && data[n] == 0xff {
+				GetLogger().Debug("skip padding before payload marker", "bytes", n)
+				processed += n
+				data = data[n:]
+				continue
+			}
+		}
 
 		delta := int(data[0] >> 4)
 		length := int(data[0] & 0x0f)
@@ -636,6 +921,15 @@ func (options *Options) Unmarshal(data []byte, optionDefs map[OptionID]OptionDef
 	return processed, nil
 }
 
+// paddingLen returns the number of leading 0x00 bytes in data.
+func paddingLen(data []byte) int {
+	n := 0
+	for n < len(data) && data[n] == 0x00 {
+		n++
+	}
+	return n
+}
+
 // ResetOptionsTo resets options to in options.
 //
 // Returns modified options, number of used buf bytes and error if occurs.
@@ -662,7 +956,7 @@ func (options Options) ResetOptionsTo(buf []byte, in Options) (Options, int, err
 
 // Clone create duplicates of options.
 func (options Options) Clone() (Options, error) {
-	opts := make(Options, 0, len(options))
+	opts := NewOptions(len(options))
 	buf := make([]byte, 64)
 	opts, used, err := opts.ResetOptionsTo(buf, options)
 	if errors.Is(err, ErrTooSmall) {
@@ -675,17 +969,149 @@ func (options Options) Clone() (Options, error) {
 	return opts, nil
 }
 
-// URL returns the URL of the options.
+// DeepCopy returns a copy of options whose values are stored in a single newly
+// allocated buffer, so it shares no memory with options.
+//
+// Like ResetOptionsTo, every value of the copy is stored as []byte.
+func (options Options) DeepCopy() Options {
+	if options == nil {
+		return nil
+	}
+	size := 0
+	for _, o := range options {
+		size += len(o.ToBytes())
+	}
+	buf := make([]byte, size)
+	opts := make(Options, len(options))
+	for i, o := range options {
+		n := copy(buf, o.ToBytes())
+		opts[i] = Option{ID: o.ID, Value: buf[:n:n]}
+		buf = buf[n:]
+	}
+	return opts
+}
+
+// Equal reports whether both options have the same IDs and encoded values in
+// the same order, so uint32(50) equals MediaType(50) and []byte{50}.
+func (options Options) Equal(other Options) bool {
+	if len(options) != len(other) {
+		return false
+	}
+	for i := range options {
+		if options[i].ID != other[i].ID || !bytes.Equal(options[i].ToBytes(), other[i].ToBytes()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks all options against defs and returns every error found.
+//
+// For each option the value length must be within [MinLen, MaxLen], uint values
+// must fit into 4 bytes and string values must not contain null bytes.
+func (options Options) Validate(defs map[OptionID]OptionDef) []error {
+	var errs []error
+	for _, o := range options {
+		def, ok := defs[o.ID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unrecognized option %d", o.ID))
+			continue
+		}
+		value := o.ToBytes()
+		if !VerifyOptLen(defs, o.ID, len(value)) {
+			errs = append(errs, fmt.Errorf("%w: %d for %s, expected %d-%d", ErrInvalidValueLength, len(value), o.ID, def.MinLen, def.MaxLen))
+		}
+		switch def.ValueFormat {
+		case ValueUint:
+			if len(value) > 4 {
+				errs = append(errs, fmt.Errorf("%w: uint option %s has %d bytes", ErrInvalidEncoding, o.ID, len(value)))
+			}
+		case ValueString:
+			if strings.IndexByte(string(value), 0) >= 0 {
+				errs = append(errs, fmt.Errorf("%w: string option %s contains null byte", ErrInvalidEncoding, o.ID))
+			}
+		case ValueEmpty:
+			if len(value) != 0 {
+				errs = append(errs, fmt.Errorf("%w: empty option %s has %d bytes", ErrInvalidEncoding, o.ID, len(value)))
+			}
+		}
+	}
+	return errs
+}
+
+// URL returns the relative URI of the Uri-Path and Uri-Query options.
+//
+// The URI is composed as described in RFC 7252 section 6.5: "/" when there is
+// no Uri-Path option, zero-length segments are kept and the characters which
+// are not allowed in a path segment or query are percent-encoded.
 func (options Options) URL() string {
-	path, err := options.Path()
-	if err != nil {
-		return ""
+	var sb strings.Builder
+	segments, _ := options.PathSegments()
+	if len(segments) == 0 {
+		sb.WriteByte('/')
 	}
-	queries, err := options.Queries()
-	if err != nil {
-		return path
+	for _, seg := range segments {
+		sb.WriteByte('/')
+		sb.WriteString(escapeURIComponent(seg, false))
+	}
+	queries, _ := options.Queries()
+	for i, q := range queries {
+		if i == 0 {
+			sb.WriteByte('?')
+		} else {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(escapeURIComponent(q, true))
+	}
+	return sb.String()
+}
+
+// PathSegments returns the values of all Uri-Path options without joining
+// them, zero-length segments are preserved.
+func (options Options) PathSegments() ([]string, error) {
+	return options.strings(URIPath)
+}
+
+// escapeURIComponent percent-encodes s as a path segment (pchar) or, when
+// query is true, as a query argument where '/' and '?' are allowed but '&'
+// separates the arguments (RFC 3986 section 3.3 and 3.4).
+func escapeURIComponent(s string, query bool) string {
+	const upperhex = "0123456789ABCDEF"
+	keep := func(c byte) bool {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			return true
+		}
+		switch c {
+		case '-', '.', '_', '~', // unreserved
+			'!', '$', '\'', '(', ')', '*', '+', ',', ';', '=', // sub-delims without '&'
+			':', '@':
+			return true
+		case '&':
+			return !query
+		case '/', '?':
+			return query
+		}
+		return false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if !keep(s[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s)+2*n)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; keep(c) {
+			b = append(b, c)
+		} else {
+			b = append(b, '%', upperhex[c>>4], upperhex[c&15])
+		}
 	}
-	return path + "?" + strings.Join(queries, "&")
+	return string(b)
 }
 
 func (options Options) String(sep string) string {
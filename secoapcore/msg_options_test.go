@@ -0,0 +1,599 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newPathOptions(t testing.TB, path string) Options {
+	opts := NewOptions(DefaultOptionsCapacity)
+	buf := make([]byte, 256)
+	opts, _, err := opts.SetPath(buf, path)
+	require.NoError(t, err)
+	return opts
+}
+
+func TestOptionsPathString(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "empty",
+			path: "",
+			want: "",
+		},
+		{
+			name: "single",
+			path: "/sensor",
+			want: "sensor",
+		},
+		{
+			name: "multi",
+			path: "/iotda/v3/device/status/x",
+			want: "iotda/v3/device/status/x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newPathOptions(t, tt.path)
+			require.Equal(t, tt.want, opts.PathString())
+			if tt.want != "" {
+				path, err := opts.Path()
+				require.NoError(t, err)
+				require.Equal(t, "/"+tt.want, path)
+			}
+		})
+	}
+}
+
+func BenchmarkOptionsPathString(b *testing.B) {
+	opts := newPathOptions(b, "/iotda/v3/device/status/x")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = opts.PathString()
+	}
+}
+
+func BenchmarkOptionsPathJoin(b *testing.B) {
+	opts := newPathOptions(b, "/iotda/v3/device/status/x")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := make([]string, 8)
+		n, _ := opts.GetStrings(URIPath, r)
+		_ = strings.Join(r[:n], "/")
+	}
+}
+
+func TestOptionsURL(t *testing.T) {
+	path := func(segs ...string) Options {
+		var opts Options
+		for _, seg := range segs {
+			opts = append(opts, Option{ID: URIPath, Value: seg})
+		}
+		return opts
+	}
+	query := func(opts Options, qs ...string) Options {
+		for _, q := range qs {
+			opts = append(opts, Option{ID: URIQuery, Value: q})
+		}
+		return opts
+	}
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "no path",
+			want: "/",
+		},
+		{
+			name: "single segment",
+			opts: path("sensor"),
+			want: "/sensor",
+		},
+		{
+			name: "multi segment",
+			opts: path("a", "b", "c"),
+			want: "/a/b/c",
+		},
+		{
+			name: "single query",
+			opts: query(path("path"), "k=v"),
+			want: "/path?k=v",
+		},
+		{
+			name: "multiple queries",
+			opts: query(path("path"), "a=1", "b=2"),
+			want: "/path?a=1&b=2",
+		},
+		{
+			name: "query without path",
+			opts: query(nil, "a=1"),
+			want: "/?a=1",
+		},
+		{
+			name: "unsafe characters",
+			opts: query(path("a b", "c/d", "e?f", "100%"), "q=x&y", "r=/s?t", "u=ü"),
+			want: "/a%20b/c%2Fd/e%3Ff/100%25?q=x%26y&r=/s?t&u=%C3%BC",
+		},
+		{
+			name: "zero-length segments",
+			opts: path("a", "", "b", ""),
+			want: "/a//b/",
+		},
+		{
+			name: "single zero-length segment",
+			opts: path(""),
+			want: "/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.opts.URL())
+		})
+	}
+}
+
+func TestOptionsPathSegments(t *testing.T) {
+	_, err := Options{}.PathSegments()
+	require.ErrorIs(t, err, ErrOptionNotFound)
+
+	opts := Options{
+		{ID: URIPath, Value: "a"},
+		{ID: URIPath, Value: ""},
+		{ID: URIPath, Value: []byte("b")},
+		{ID: URIQuery, Value: "k=v"},
+	}
+	segs, err := opts.PathSegments()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "", "b"}, segs)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr []error
+	}{
+		{
+			name: "valid",
+			opts: Options{
+				{ID: URIPath, Value: "a"},
+				{ID: ContentFormat, Value: []byte{50}},
+			},
+		},
+		{
+			name: "too long",
+			opts: Options{
+				{ID: ETag, Value: make([]byte, 9)},
+			},
+			wantErr: []error{ErrInvalidValueLength},
+		},
+		{
+			name: "uint too long",
+			opts: Options{
+				{ID: Size1, Value: make([]byte, 5)},
+			},
+			wantErr: []error{ErrInvalidValueLength, ErrInvalidEncoding},
+		},
+		{
+			name: "null byte",
+			opts: Options{
+				{ID: URIPath, Value: "a\x00b"},
+			},
+			wantErr: []error{ErrInvalidEncoding},
+		},
+		{
+			name: "all errors",
+			opts: Options{
+				{ID: URIHost, Value: ""},
+				{ID: IfNoneMatch, Value: []byte{1}},
+				{ID: URIPath, Value: "\x00"},
+			},
+			wantErr: []error{ErrInvalidValueLength, ErrInvalidValueLength, ErrInvalidEncoding, ErrInvalidEncoding},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.opts.Validate(CoapOptionDefs)
+			require.Len(t, errs, len(tt.wantErr))
+			for i, err := range errs {
+				require.ErrorIs(t, err, tt.wantErr[i])
+			}
+		})
+	}
+	require.Len(t, Options{{ID: 9999, Value: []byte{}}}.Validate(CoapOptionDefs), 1)
+}
+
+func TestOptionsUnmarshalPadding(t *testing.T) {
+	opts := newPathOptions(t, "/a/b")
+	buf := make([]byte, 64)
+	n, err := opts.Marshal(buf)
+	require.NoError(t, err)
+
+	data := append([]byte{}, buf[:n]...)
+	data = append(data, 0x00, 0x00, 0x00, 0x00, 0xff, 'h', 'i')
+
+	l := useRecordLogger(t)
+	got := NewOptions(DefaultOptionsCapacity)
+	processed, err := got.UnmarshalSkipPadding(data, CoapOptionDefs)
+	require.NoError(t, err)
+	require.Equal(t, len(data)-2, processed)
+	require.Equal(t, "a/b", got.PathString())
+	require.Equal(t, []string{"skip padding before payload marker"}, l.msgs)
+
+	// 默认不跳过填充, 0x00 解码为重复的空 Uri-Path
+	got = NewOptions(DefaultOptionsCapacity)
+	processed, err = got.Unmarshal(data, CoapOptionDefs)
+	require.NoError(t, err)
+	require.Equal(t, len(data)-2, processed)
+	segs, err := got.PathSegments()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "", "", "", ""}, segs)
+}
+
+func TestOptionsUnmarshalEmptyOptionBeforePayload(t *testing.T) {
+	// Uri-Path "a", Uri-Path "", 0xFF, payload
+	data := []byte{0xb1, 'a', 0x00, 0xff, 'x'}
+	got := NewOptions(DefaultOptionsCapacity)
+	processed, err := got.Unmarshal(data, CoapOptionDefs)
+	require.NoError(t, err)
+	require.Equal(t, 4, processed)
+	segs, err := got.PathSegments()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", ""}, segs)
+	require.Equal(t, "/a/", got.URL())
+
+	buf := make([]byte, 16)
+	n, err := got.Marshal(buf)
+	require.NoError(t, err)
+	require.Equal(t, data[:3], buf[:n])
+}
+
+func newMarshalOptions(t testing.TB) Options {
+	opts := newPathOptions(t, "/iotda/v3/status")
+	buf := make([]byte, 16)
+	opts, _, err := opts.SetContentFormat(buf, AppJSON)
+	require.NoError(t, err)
+	return opts
+}
+
+func TestOptionsMarshalTo(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty", opts: Options{}},
+		{name: "path and content format", opts: newMarshalOptions(t)},
+		{
+			name: "extended delta and length",
+			opts: Options{
+				{ID: URIPath, Value: strings.Repeat("a", 20)},
+				{ID: ProxyURI, Value: strings.Repeat("b", 300)},
+				{ID: Size1, Value: uint32(0x01020304)},
+				{ID: GiterLabID, Value: "id"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make([]byte, 1024)
+			n, err := tt.opts.Marshal(want)
+			require.NoError(t, err)
+			want = want[:n]
+
+			size, err := tt.opts.MarshalTo(nil)
+			require.NoError(t, err)
+			require.Equal(t, len(want), size)
+			if size > 0 {
+				short := make([]byte, size-1)
+				n, err = tt.opts.MarshalTo(short)
+				require.NoError(t, err)
+				require.Equal(t, size, n)
+				require.Equal(t, make([]byte, size-1), short)
+			}
+
+			buf := make([]byte, size)
+			n, err = tt.opts.MarshalTo(buf)
+			require.NoError(t, err)
+			require.Equal(t, want, buf[:n])
+		})
+	}
+}
+
+func TestOptionMarshalHeaderTo(t *testing.T) {
+	o := Option{ID: ProxyURI, Value: strings.Repeat("b", 300)}
+	n, err := o.MarshalHeaderTo(nil, URIPath)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	buf := make([]byte, n)
+	n, err = o.MarshalHeaderTo(buf, URIPath)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []byte{0xDE, 35 - 11 - 13, 0x00, 300 - 269}, buf)
+}
+
+func TestOptionsMarshalToAllocs(t *testing.T) {
+	opts := newMarshalOptions(t)
+	require.Len(t, opts, 4)
+	buf := make([]byte, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := opts.MarshalTo(buf); err != nil {
+			t.Fatal(err)
+		}
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkOptionsMarshal(b *testing.B) {
+	opts := newMarshalOptions(b)
+	buf := make([]byte, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := opts.Marshal(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOptionsMarshalTo(b *testing.B) {
+	opts := newMarshalOptions(b)
+	buf := make([]byte, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := opts.MarshalTo(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOptionsDeepCopy(t *testing.T) {
+	opts := newPathOptions(t, "/a/b")
+	opts = opts.Add(Option{ID: ETag, Value: []byte{0x01, 0x02}})
+	opts = opts.Add(Option{ID: ContentFormat, Value: uint32(AppJSON)})
+
+	cp := opts.DeepCopy()
+	require.Len(t, cp, len(opts))
+	for i := range opts {
+		require.Equal(t, opts[i].ID, cp[i].ID)
+		require.Equal(t, opts[i].ToBytes(), cp[i].ToBytes())
+	}
+
+	// mutate the source values in place
+	for _, o := range opts {
+		if b, ok := o.Value.([]byte); ok {
+			for i := range b {
+				b[i] = 'x'
+			}
+		}
+	}
+	path, err := cp.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/a/b", path)
+	etag, err := cp.GetBytes(ETag)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02}, etag)
+	cf, err := cp.ContentFormat()
+	require.NoError(t, err)
+	require.Equal(t, AppJSON, cf)
+
+	require.Nil(t, Options(nil).DeepCopy())
+}
+
+func TestOptionsFilterMapReduce(t *testing.T) {
+	require.Nil(t, Options{}.Filter(func(Option) bool { return true }))
+	require.Nil(t, Options(nil).Filter(func(Option) bool { return true }))
+
+	opts := newPathOptions(t, "/A/B")
+	opts = opts.Add(Option{ID: ContentFormat, Value: uint32(AppJSON)})
+	opts = opts.Add(Option{ID: URIQuery, Value: "Q=1"})
+	orig := append(Options(nil), opts...)
+
+	noPath := opts.Filter(func(o Option) bool { return o.ID != URIPath })
+	require.Len(t, noPath, 2)
+	require.False(t, noPath.HasOption(URIPath))
+	require.Equal(t, orig, opts)
+
+	lower := opts.Map(func(o Option) Option {
+		switch v := o.Value.(type) {
+		case string:
+			o.Value = strings.ToLower(v)
+		case []byte:
+			o.Value = bytes.ToLower(v)
+		}
+		return o
+	})
+	require.Equal(t, orig, opts)
+	path, err := lower.Path()
+	require.NoError(t, err)
+	require.Equal(t, "/a/b", path)
+	for i := 1; i < len(lower); i++ {
+		require.LessOrEqual(t, lower[i-1].ID, lower[i].ID)
+	}
+
+	// changing IDs keeps the result sorted, equal IDs keep their order
+	moved := opts.Map(func(o Option) Option {
+		if o.ID == URIQuery {
+			o.ID = IfMatch
+		}
+		return o
+	})
+	require.Equal(t, IfMatch, moved[0].ID)
+	require.Equal(t, []byte("A"), moved[1].Value)
+	require.Equal(t, []byte("B"), moved[2].Value)
+
+	count := opts.Reduce(0, func(acc interface{}, o Option) interface{} {
+		if o.ID == URIPath {
+			return acc.(int) + 1
+		}
+		return acc
+	})
+	require.Equal(t, 2, count)
+
+	opt, ok := opts.FindFirst(URIPath)
+	require.True(t, ok)
+	require.Equal(t, []byte("A"), opt.Value)
+	_, ok = opts.FindFirst(ETag)
+	require.False(t, ok)
+}
+
+func TestOptionsFindByID(t *testing.T) {
+	opts := Options{
+		{ID: IfMatch, Value: []byte{1}},
+		{ID: URIPath, Value: "a"},
+		{ID: URIPath, Value: "b"},
+		{ID: URIPath, Value: "c"},
+		{ID: ContentFormat, Value: AppJSON},
+		{ID: URIQuery, Value: "x=1"},
+	}
+	require.True(t, opts.IsSorted())
+
+	i, ok := opts.FindByID(URIPath)
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+	start, end := opts.RangeByID(URIPath)
+	require.Equal(t, 1, start)
+	require.Equal(t, 4, end)
+
+	i, ok = opts.FindByID(ETag)
+	require.False(t, ok)
+	require.Equal(t, 1, i)
+	start, end = opts.RangeByID(ETag)
+	require.Equal(t, start, end)
+	_, ok = opts.FindByID(Size1)
+	require.False(t, ok)
+	start, end = Options(nil).RangeByID(URIPath)
+	require.Equal(t, 0, start)
+	require.Equal(t, 0, end)
+
+	m := Message{Opts: opts}
+	require.Equal(t, []interface{}{"a", "b", "c"}, m.Options(URIPath))
+	require.Equal(t, AppJSON, m.Option(ContentFormat))
+	require.Nil(t, m.Option(ETag))
+
+	// 未排序的选项使用线性查找
+	unsorted := Options{{ID: URIQuery, Value: "x=1"}, {ID: URIPath, Value: "a"}}
+	require.False(t, unsorted.IsSorted())
+	m = Message{Opts: unsorted}
+	require.Equal(t, "a", m.Option(URIPath))
+	require.Equal(t, []interface{}{"x=1"}, m.Options(URIQuery))
+}
+
+// newSortedOptions returns n options with distinct IDs 1..n.
+func newSortedOptions(n int) Options {
+	opts := make(Options, n)
+	for i := range opts {
+		opts[i] = Option{ID: OptionID(i + 1), Value: uint32(i)}
+	}
+	return opts
+}
+
+// BenchmarkOptionsFind compares the linear FindFirst with the binary FindByID,
+// looking up the last option which is the worst case of the linear scan.
+func BenchmarkOptionsFind(b *testing.B) {
+	for _, n := range []int{4, 8, 16, 32} {
+		opts := newSortedOptions(n)
+		id := OptionID(n)
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, ok := opts.FindFirst(id); !ok {
+					b.Fatal("not found")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("binary/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, ok := opts.FindByID(id); !ok {
+					b.Fatal("not found")
+				}
+			}
+		})
+	}
+}
+
+func TestOptionsEqual(t *testing.T) {
+	a := Options{{ID: URIPath, Value: "a"}, {ID: ContentFormat, Value: uint32(50)}}
+	require.True(t, a.Equal(Options{{ID: URIPath, Value: []byte("a")}, {ID: ContentFormat, Value: AppJSON}}))
+	require.True(t, Options(nil).Equal(Options{}))
+	require.False(t, a.Equal(a[:1]))
+	require.False(t, a.Equal(Options{{ID: URIPath, Value: "b"}, {ID: ContentFormat, Value: AppJSON}}))
+	require.False(t, a.Equal(Options{{ID: URIQuery, Value: "a"}, {ID: ContentFormat, Value: AppJSON}}))
+}
+
+func TestOptionsByID(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want map[OptionID][]Option
+	}{
+		{
+			name: "nil",
+			want: map[OptionID][]Option{},
+		},
+		{
+			name: "empty",
+			opts: Options{},
+			want: map[OptionID][]Option{},
+		},
+		{
+			name: "distinct ids",
+			opts: Options{{ID: URIPath, Value: "a"}, {ID: ContentFormat, Value: AppJSON}},
+			want: map[OptionID][]Option{
+				URIPath:       {{ID: URIPath, Value: "a"}},
+				ContentFormat: {{ID: ContentFormat, Value: AppJSON}},
+			},
+		},
+		{
+			name: "repeated ids keep their order",
+			opts: Options{
+				{ID: URIQuery, Value: "x=1"},
+				{ID: URIPath, Value: "b"},
+				{ID: URIPath, Value: "a"},
+				{ID: URIQuery, Value: "y=2"},
+				{ID: URIPath, Value: "c"},
+			},
+			want: map[OptionID][]Option{
+				URIPath:  {{ID: URIPath, Value: "b"}, {ID: URIPath, Value: "a"}, {ID: URIPath, Value: "c"}},
+				URIQuery: {{ID: URIQuery, Value: "x=1"}, {ID: URIQuery, Value: "y=2"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.ByID()
+			require.NotNil(t, got)
+			require.Equal(t, tt.want, got)
+			n := 0
+			for id, opts := range got {
+				for _, o := range opts {
+					require.Equal(t, id, o.ID)
+				}
+				n += len(opts)
+			}
+			require.Equal(t, len(tt.opts), n)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"math/rand"
+	"sort"
+)
+
+const (
+	randomMaxOptions    = 16
+	randomMaxPayload    = 512
+	randomMaxStringOpt  = 64 // 限制随机字符串选项的长度, 避免消息过大
+	randomStringCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-._~"
+)
+
+// RandomMessage 生成随机的合法消息, 用于编解码器的往返测试
+//
+// 消息包含随机的 Code, 0-8 字节的 Token, 0-16 个从 OptionDefs 中选取且长度合法的
+// 选项(按 ID 排序), 0-512 字节的 Payload 以及合法的 EncoderID/EncoderType.
+// 选项值使用解码后的类型(uint32, string, []byte), 空 Token 和 Payload 为 nil,
+// Crc16 和 Rsum8 由编码器计算, 保持为 0.
+func RandomMessage(r *rand.Rand) Message {
+	m := Message{
+		Ver:         Ver(r.Intn(3)),
+		Code:        randomCode(r),
+		MessageID:   int32(r.Intn(1 << 16)),
+		Type:        Type(r.Intn(4)),
+		EncoderID:   int32(r.Intn(1 << 4)),
+		EncoderType: int32(r.Intn(1 << 4)),
+	}
+	if n := r.Intn(MaxTokenSize + 1); n > 0 {
+		m.Token = make(Token, n)
+		r.Read(m.Token)
+	}
+	m.Opts = randomOptions(r)
+	if n := r.Intn(randomMaxPayload + 1); n > 0 {
+		m.Payload = make([]byte, n)
+		r.Read(m.Payload)
+	}
+	return m
+}
+
+func randomCode(r *rand.Rand) Code {
+	for {
+		if c := Code(r.Intn(256)); ValidateCode(c) {
+			return c
+		}
+	}
+}
+
+func randomOptions(r *rand.Rand) Options {
+	defs := OptionDefs()
+	ids := make([]OptionID, 0, len(defs))
+	for id, def := range defs {
+		if def.ValueFormat != ValueUnknown {
+			ids = append(ids, id)
+		}
+	}
+	// map 的遍历顺序是随机的, 排序后结果只取决于 r
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	picked := make([]OptionID, r.Intn(randomMaxOptions+1))
+	for i := range picked {
+		picked[i] = ids[r.Intn(len(ids))]
+	}
+	sort.Slice(picked, func(i, j int) bool { return picked[i] < picked[j] })
+
+	opts := make(Options, 0, len(picked))
+	for _, id := range picked {
+		def := defs[id]
+		opts = append(opts, Option{ID: id, Value: randomOptionValue(r, def, def.MinLen)})
+	}
+	return opts
+}
+
+func randomOptionValue(r *rand.Rand, def OptionDef, minLen int) interface{} {
+	maxLen := def.MaxLen
+	switch def.ValueFormat {
+	case ValueUint:
+		if maxLen > 4 {
+			maxLen = 4
+		}
+		n := minLen + r.Intn(maxLen-minLen+1)
+		if n == 0 {
+			return uint32(0)
+		}
+		// 最高字节非零, 保证编码长度为 n
+		lo := uint64(1) << (8 * (n - 1))
+		hi := uint64(1) << (8 * n)
+		return uint32(lo + uint64(r.Int63n(int64(hi-lo))))
+	case ValueString:
+		if maxLen > randomMaxStringOpt {
+			maxLen = randomMaxStringOpt
+		}
+		b := make([]byte, minLen+r.Intn(maxLen-minLen+1))
+		for i := range b {
+			b[i] = randomStringCharset[r.Intn(len(randomStringCharset))]
+		}
+		return string(b)
+	default:
+		b := make([]byte, minLen+r.Intn(maxLen-minLen+1))
+		r.Read(b)
+		return b
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomMessage(t *testing.T) {
+	require.Equal(t, RandomMessage(rand.New(rand.NewSource(7))), RandomMessage(rand.New(rand.NewSource(7))))
+
+	r := rand.New(rand.NewSource(1))
+	defs := OptionDefs()
+	for i := 0; i < 200; i++ {
+		m := RandomMessage(r)
+		require.True(t, ValidateCode(m.Code))
+		require.NoError(t, ValidateToken(m.Token, false))
+		require.True(t, ValidateMID(m.MessageID))
+		require.True(t, ValidateEID(m.EncoderID))
+		require.True(t, ValidateETP(m.EncoderType))
+		require.LessOrEqual(t, len(m.Opts), randomMaxOptions)
+		require.LessOrEqual(t, len(m.Payload), randomMaxPayload)
+		require.True(t, m.Opts.IsSorted())
+		for _, o := range m.Opts {
+			require.True(t, VerifyOptLen(defs, o.ID, len(o.ToBytes())), "option %v", o)
+		}
+	}
+}
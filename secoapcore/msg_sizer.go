@@ -0,0 +1,87 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Sizer 计算消息编码后的字节数
+type Sizer interface {
+	Size(m Message) (int, error)
+}
+
+// Encoder 按协议版本将消息编码到 buf
+type Encoder interface {
+	Sizer
+	Encode(m Message, buf []byte) (int, error)
+}
+
+var (
+	sizersMu sync.RWMutex
+	sizers   = map[Ver]Sizer{}
+
+	encodersMu sync.RWMutex
+	encoders   = map[Ver]Encoder{}
+)
+
+// RegisterSizer 注册协议版本对应的 Sizer
+//
+// coderv0, coderv1, coderv2 在 init 中注册各自的 DefaultCoder, 因为这些包依赖
+// secoapcore, secoapcore 无法直接引用它们
+func RegisterSizer(ver Ver, s Sizer) {
+	sizersMu.Lock()
+	defer sizersMu.Unlock()
+	if s == nil {
+		delete(sizers, ver)
+		return
+	}
+	sizers[ver] = s
+}
+
+// RegisterEncoder 注册协议版本对应的 Encoder, 供 message.Message.DumpHex 按消息版本编码
+//
+// 与 RegisterSizer 相同, coderv0, coderv1, coderv2 在 init 中注册各自的 DefaultCoder
+func RegisterEncoder(ver Ver, e Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if e == nil {
+		delete(encoders, ver)
+		return
+	}
+	encoders[ver] = e
+}
+
+// LookupEncoder 返回协议版本对应的 Encoder, 未注册时 ok 为 false
+func LookupEncoder(ver Ver) (e Encoder, ok bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	e, ok = encoders[ver]
+	return e, ok
+}
+
+// EncodedSize 返回消息按指定协议版本编码后的字节数, 可用于预分配发送缓冲区
+//
+// 对应版本的 coder 包需要已被导入 (导入 secoap 包即可)
+func (m *Message) EncodedSize(ver Ver) (int, error) {
+	sizersMu.RLock()
+	s, ok := sizers[ver]
+	sizersMu.RUnlock()
+	if !ok {
+		return -1, fmt.Errorf("%w: no coder registered for %v", ErrMessageInvalidVersion, ver)
+	}
+	return s.Size(*m)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type payloadSizer struct{}
+
+func (payloadSizer) Size(m Message) (int, error) {
+	return 4 + len(m.Payload), nil
+}
+
+func TestMessageEncodedSize(t *testing.T) {
+	m := Message{Payload: []byte("hello")}
+	_, err := m.EncodedSize(Version3)
+	require.ErrorIs(t, err, ErrMessageInvalidVersion)
+
+	RegisterSizer(Version3, payloadSizer{})
+	t.Cleanup(func() { RegisterSizer(Version3, nil) })
+	size, err := m.EncodedSize(Version3)
+	require.NoError(t, err)
+	require.Equal(t, 9, size)
+}
+
+type payloadEncoder struct {
+	payloadSizer
+}
+
+func (payloadEncoder) Encode(m Message, buf []byte) (int, error) {
+	return copy(buf[4:], m.Payload) + 4, nil
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	_, ok := LookupEncoder(Version3)
+	require.False(t, ok)
+
+	RegisterEncoder(Version3, payloadEncoder{})
+	e, ok := LookupEncoder(Version3)
+	require.True(t, ok)
+	require.Equal(t, payloadEncoder{}, e)
+
+	RegisterEncoder(Version3, nil)
+	_, ok = LookupEncoder(Version3)
+	require.False(t, ok)
+}
@@ -16,8 +16,12 @@ package secoapcore
 
 import (
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"hash/crc64"
+	mrand "math/rand"
 )
 
 type Token []byte
@@ -26,13 +30,28 @@ func (t Token) String() string {
 	return hex.EncodeToString(t)
 }
 
+// Base64 returns the token encoded with base64.StdEncoding.
+func (t Token) Base64() string {
+	return base64.StdEncoding.EncodeToString(t)
+}
+
 func (t Token) Hash() uint64 {
 	return crc64.Checksum(t, crc64.MakeTable(crc64.ISO))
 }
 
 // GetToken generates a random token by a given length
+//
+// It is kept for backward compatibility, see CryptoRandToken.
 func GetToken() (Token, error) {
-	b := make(Token, 8)
+	return CryptoRandToken()
+}
+
+// CryptoRandToken generates a random 8 bytes token read from crypto/rand.
+//
+// Production code should always use CryptoRandToken, tokens must not be
+// predictable by an off-path attacker (RFC7252 section 5.3.1).
+func CryptoRandToken() (Token, error) {
+	b := make(Token, MaxTokenSize)
 	_, err := rand.Read(b)
 	// Note that err == nil only if we read len(b) bytes.
 	if err != nil {
@@ -41,3 +60,52 @@ func GetToken() (Token, error) {
 
 	return b, nil
 }
+
+// InsecureRandToken generates a 8 bytes token from the given PRNG.
+//
+// The token is predictable, use it only for reproducible tests.
+func InsecureRandToken(rng *mrand.Rand) Token {
+	b := make(Token, MaxTokenSize)
+	binary.BigEndian.PutUint64(b, rng.Uint64())
+	return b
+}
+
+// ValidateToken validates the token length, required reports whether an empty token is an error.
+func ValidateToken(t Token, required bool) error {
+	if len(t) > MaxTokenSize {
+		return ErrTokenTooLong
+	}
+	if required && len(t) == 0 {
+		return ErrTokenRequired
+	}
+	return nil
+}
+
+// TokenFromHex parses a token from its hex representation, see Token.String.
+//
+// The decoded token must be 1 to 8 bytes long, otherwise ErrInvalidTokenLen is returned.
+func TokenFromHex(s string) (Token, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex token: %w", err)
+	}
+	return tokenFromBytes(b)
+}
+
+// TokenFromBase64 parses a token encoded with base64.StdEncoding, see Token.Base64.
+//
+// The decoded token must be 1 to 8 bytes long, otherwise ErrInvalidTokenLen is returned.
+func TokenFromBase64(s string) (Token, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 token: %w", err)
+	}
+	return tokenFromBytes(b)
+}
+
+func tokenFromBytes(b []byte) (Token, error) {
+	if len(b) == 0 || len(b) > MaxTokenSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidTokenLen, len(b))
+	}
+	return Token(b), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		required bool
+		wantErr  error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "empty required",
+			required: true,
+			wantErr:  ErrTokenRequired,
+		},
+		{
+			name:     "max",
+			token:    make(Token, MaxTokenSize),
+			required: true,
+		},
+		{
+			name:    "too long",
+			token:   make(Token, MaxTokenSize+1),
+			wantErr: ErrTokenTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateToken(tt.token, tt.required)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+			require.ErrorIs(t, err, ErrInvalidTokenLen)
+		})
+	}
+	require.NotErrorIs(t, ErrTokenTooLong, ErrTokenRequired)
+}
+
+func TestTokenFromHex(t *testing.T) {
+	want := Token{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+	got, err := TokenFromHex(want.String())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	got, err = TokenFromHex("0123456789ABCDEF")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = TokenFromHex("abc")
+	require.Error(t, err)
+	_, err = TokenFromHex("zz")
+	require.Error(t, err)
+	_, err = TokenFromHex("")
+	require.ErrorIs(t, err, ErrInvalidTokenLen)
+	_, err = TokenFromHex("000102030405060708")
+	require.ErrorIs(t, err, ErrInvalidTokenLen)
+}
+
+func TestTokenFromBase64(t *testing.T) {
+	want := Token{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+	require.Equal(t, "ASNFZ4mrze8=", want.Base64())
+	got, err := TokenFromBase64(want.Base64())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = TokenFromBase64("not base64!")
+	require.Error(t, err)
+	_, err = TokenFromBase64("")
+	require.ErrorIs(t, err, ErrInvalidTokenLen)
+	_, err = TokenFromBase64(make(Token, MaxTokenSize+1).Base64())
+	require.ErrorIs(t, err, ErrInvalidTokenLen)
+}
@@ -30,12 +30,15 @@ const (
 	Version1 Ver = 1
 	// Version2
 	Version2 Ver = 2
+	// Version3 reserved
+	Version3 Ver = 3
 )
 
 var verToString = map[Ver]string{
 	Version0: "Ver0",
 	Version1: "Ver1",
 	Version2: "Ver2",
+	Version3: "Ver3",
 }
 
 func (c Ver) String() string {
@@ -61,6 +64,8 @@ func ValidateVer(typ Ver) bool {
 }
 
 // GetVersion gets the version from the payload.
+//
+// Only the first byte is read, so the payload may be an incomplete header.
 func GetVersion(payload []byte) (ver Ver, err error) {
 	if len(payload) == 0 {
 		return 0, errors.New("empty payload")
@@ -68,3 +73,34 @@ func GetVersion(payload []byte) (ver Ver, err error) {
 	ver = Ver(payload[0] >> 6)
 	return ver, nil
 }
+
+// PeekVersion gets the version from the first byte of b, ErrTooSmall is
+// returned when b is empty.
+//
+// It allows a stream receiver to select the decoder before the full header
+// has been received, see HasFullHeader.
+func PeekVersion(b []byte) (Ver, error) {
+	if len(b) < 1 {
+		return 0, ErrTooSmall
+	}
+	return Ver(b[0] >> 6), nil
+}
+
+// VersionMinHeaderSize returns the minimum number of bytes of a valid header
+// of the given version, 0 for the reserved versions.
+func VersionMinHeaderSize(ver Ver) int {
+	switch ver {
+	case Version0, Version1:
+		return 4
+	case Version2:
+		return 8
+	}
+	return 0
+}
+
+// HasFullHeader reports whether data holds at least the minimum header of
+// the given version, it is always false for the reserved versions.
+func HasFullHeader(ver Ver, data []byte) bool {
+	size := VersionMinHeaderSize(ver)
+	return size > 0 && len(data) >= size
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeekVersion(t *testing.T) {
+	_, err := PeekVersion(nil)
+	require.ErrorIs(t, err, ErrTooSmall)
+	_, err = GetVersion([]byte{})
+	require.Error(t, err)
+
+	for _, ver := range []Ver{Version0, Version1, Version2, Version3} {
+		b := []byte{byte(ver) << 6}
+		got, err := PeekVersion(b)
+		require.NoError(t, err)
+		require.Equal(t, ver, got)
+		got, err = GetVersion(b)
+		require.NoError(t, err)
+		require.Equal(t, ver, got)
+	}
+}
+
+func TestHasFullHeader(t *testing.T) {
+	tests := []struct {
+		ver  Ver
+		size int
+	}{
+		{Version0, 4},
+		{Version1, 4},
+		{Version2, 8},
+		{Version3, 0},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.size, VersionMinHeaderSize(tt.ver), "%v", tt.ver)
+		if tt.size == 0 {
+			require.False(t, HasFullHeader(tt.ver, make([]byte, 64)))
+			continue
+		}
+		require.False(t, HasFullHeader(tt.ver, make([]byte, tt.size-1)), "%v", tt.ver)
+		require.True(t, HasFullHeader(tt.ver, make([]byte, tt.size)), "%v", tt.ver)
+	}
+}
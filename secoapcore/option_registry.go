@@ -0,0 +1,130 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// MinCustomOptionID is the lowest option number RegisterOption accepts, 0..255
+// are reserved for options defined by the IETF.
+const MinCustomOptionID OptionID = 256
+
+type optionRegistry struct {
+	merged map[OptionID]OptionDef // CoapOptionDefs and the custom options
+	custom map[OptionID]OptionDef
+}
+
+var (
+	optionRegistryMu sync.Mutex // serializes writers
+	optionRegistryV  atomic.Value
+)
+
+func init() {
+	ResetOptionRegistry()
+}
+
+func loadOptionRegistry() *optionRegistry {
+	return optionRegistryV.Load().(*optionRegistry)
+}
+
+// RegisterOption registers a custom option, id must be within 256..65535.
+//
+// Registering an id again with the same def is a no-op, a different def or an
+// id of CoapOptionDefs with a different def returns ErrOptionConflict.
+// The registry is copy-on-write, so decoding never blocks on registration.
+func RegisterOption(id OptionID, def OptionDef) error {
+	if id < MinCustomOptionID || id > 65535 {
+		return fmt.Errorf("%w: %d", ErrOptionIDOutOfRange, id)
+	}
+	if def.MinLen < 0 || def.MaxLen < def.MinLen {
+		return fmt.Errorf("%w: option %d has length %d-%d", ErrInvalidValueLength, id, def.MinLen, def.MaxLen)
+	}
+	optionRegistryMu.Lock()
+	defer optionRegistryMu.Unlock()
+	r := loadOptionRegistry()
+	if old, ok := r.merged[id]; ok {
+		if old == def {
+			return nil
+		}
+		return fmt.Errorf("%w: %s has %+v", ErrOptionConflict, id, old)
+	}
+	next := &optionRegistry{
+		merged: make(map[OptionID]OptionDef, len(r.merged)+1),
+		custom: make(map[OptionID]OptionDef, len(r.custom)+1),
+	}
+	for k, v := range r.merged {
+		next.merged[k] = v
+	}
+	for k, v := range r.custom {
+		next.custom[k] = v
+	}
+	next.merged[id] = def
+	next.custom[id] = def
+	optionRegistryV.Store(next)
+	return nil
+}
+
+// UnregisterOption removes an option added by RegisterOption, it reports
+// whether the option was registered. Options of CoapOptionDefs cannot be removed.
+func UnregisterOption(id OptionID) bool {
+	optionRegistryMu.Lock()
+	defer optionRegistryMu.Unlock()
+	r := loadOptionRegistry()
+	if _, ok := r.custom[id]; !ok {
+		return false
+	}
+	next := &optionRegistry{
+		merged: make(map[OptionID]OptionDef, len(r.merged)),
+		custom: make(map[OptionID]OptionDef, len(r.custom)),
+	}
+	for k, v := range r.merged {
+		if k != id {
+			next.merged[k] = v
+		}
+	}
+	for k, v := range r.custom {
+		if k != id {
+			next.custom[k] = v
+		}
+	}
+	optionRegistryV.Store(next)
+	return true
+}
+
+// ResetOptionRegistry removes all options added by RegisterOption.
+func ResetOptionRegistry() {
+	optionRegistryMu.Lock()
+	defer optionRegistryMu.Unlock()
+	merged := make(map[OptionID]OptionDef, len(CoapOptionDefs))
+	for k, v := range CoapOptionDefs {
+		merged[k] = v
+	}
+	optionRegistryV.Store(&optionRegistry{merged: merged, custom: map[OptionID]OptionDef{}})
+}
+
+// LookupOptionDef returns the definition of a registered or built-in option.
+func LookupOptionDef(id OptionID) (OptionDef, bool) {
+	def, ok := loadOptionRegistry().merged[id]
+	return def, ok
+}
+
+// OptionDefs returns CoapOptionDefs merged with the custom options, the coders
+// pass it to Options.Unmarshal. The returned map must not be modified.
+func OptionDefs() map[OptionID]OptionDef {
+	return loadOptionRegistry().merged
+}
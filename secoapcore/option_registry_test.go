@@ -0,0 +1,78 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterOption(t *testing.T) {
+	t.Cleanup(ResetOptionRegistry)
+	const vendor OptionID = 3000
+	def := OptionDef{ValueFormat: ValueOpaque, MinLen: 1, MaxLen: 4}
+
+	opts := Options{{ID: URIPath, Value: "a"}, {ID: vendor, Value: []byte{0xca, 0xfe}}}
+	buf := make([]byte, 32)
+	n, err := opts.Marshal(buf)
+	require.NoError(t, err)
+
+	var got Options = make(Options, 0, 4)
+	_, err = got.Unmarshal(buf[:n], OptionDefs())
+	require.Error(t, err)
+
+	require.NoError(t, RegisterOption(vendor, def))
+	require.NoError(t, RegisterOption(vendor, def))
+	d, ok := LookupOptionDef(vendor)
+	require.True(t, ok)
+	require.Equal(t, def, d)
+	_, ok = CoapOptionDefs[vendor]
+	require.False(t, ok, "CoapOptionDefs must not be modified")
+
+	got = make(Options, 0, 4)
+	_, err = got.Unmarshal(buf[:n], OptionDefs())
+	require.NoError(t, err)
+	v, err := got.GetBytes(vendor)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xca, 0xfe}, v)
+
+	require.True(t, UnregisterOption(vendor))
+	require.False(t, UnregisterOption(vendor))
+	_, ok = LookupOptionDef(vendor)
+	require.False(t, ok)
+}
+
+func TestRegisterOptionErrors(t *testing.T) {
+	t.Cleanup(ResetOptionRegistry)
+	def := OptionDef{ValueFormat: ValueUint, MinLen: 0, MaxLen: 4}
+	require.ErrorIs(t, RegisterOption(URIPath, def), ErrOptionIDOutOfRange)
+	require.ErrorIs(t, RegisterOption(255, def), ErrOptionIDOutOfRange)
+	require.ErrorIs(t, RegisterOption(65536, def), ErrOptionIDOutOfRange)
+	require.ErrorIs(t, RegisterOption(300, OptionDef{MinLen: 2, MaxLen: 1}), ErrInvalidValueLength)
+
+	// 内置的私有选项
+	require.NoError(t, RegisterOption(CheckCRC32, CoapOptionDefs[CheckCRC32]))
+	require.ErrorIs(t, RegisterOption(CheckCRC32, OptionDef{ValueFormat: ValueString, MaxLen: 8}), ErrOptionConflict)
+	require.False(t, UnregisterOption(CheckCRC32))
+
+	require.NoError(t, RegisterOption(4000, def))
+	require.ErrorIs(t, RegisterOption(4000, OptionDef{ValueFormat: ValueOpaque, MaxLen: 4}), ErrOptionConflict)
+	ResetOptionRegistry()
+	_, ok := LookupOptionDef(4000)
+	require.False(t, ok)
+	_, ok = LookupOptionDef(URIPath)
+	require.True(t, ok)
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+// OSCOREContext is the security context of Object Security for Constrained
+// RESTful Environments (RFC 8613).
+//
+// Only the context parameters are defined yet, Seal and Open return ErrNotImplemented.
+type OSCOREContext struct {
+	SenderID     []byte
+	RecipientID  []byte
+	MasterSecret []byte
+}
+
+// Seal protects m with the sender context and sets the OSCORE option.
+func (c *OSCOREContext) Seal(m *Message) error {
+	return ErrNotImplemented
+}
+
+// Open verifies and decrypts m with the recipient context.
+func (c *OSCOREContext) Open(m *Message) error {
+	return ErrNotImplemented
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSCOREOption(t *testing.T) {
+	require.Equal(t, "OSCORE", OSCORE.String())
+	opts := Options{{ID: OSCORE, Value: []byte{0x09, 0x14}}, {ID: URIPath, Value: "a"}}
+	require.Empty(t, opts.Validate(CoapOptionDefs))
+	require.Empty(t, Options{{ID: OSCORE, Value: []byte{}}}.Validate(CoapOptionDefs))
+	require.NotEmpty(t, Options{{ID: OSCORE, Value: make([]byte, 256)}}.Validate(CoapOptionDefs))
+
+	ctx := &OSCOREContext{SenderID: []byte{0x01}, RecipientID: []byte{}, MasterSecret: make([]byte, 16)}
+	require.ErrorIs(t, ctx.Seal(&Message{}), ErrNotImplemented)
+	require.ErrorIs(t, ctx.Open(&Message{}), ErrNotImplemented)
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"sync"
+)
+
+const (
+	// DefaultMaxTokens is the default limit of in-flight tokens of a TokenStore.
+	DefaultMaxTokens = 65536
+	// DefaultTokenRetries is the default number of attempts to generate an unused token.
+	DefaultTokenRetries = 8
+)
+
+// TokenStore tracks the tokens of in-flight requests and avoids collisions.
+//
+// Acquire and Release are safe for concurrent use. The fields must not be
+// changed after the first Acquire.
+type TokenStore struct {
+	// MaxTokens limits the number of acquired tokens.
+	MaxTokens int
+	// MaxRetries limits the attempts to generate an unused token.
+	MaxRetries int
+	// Generate creates a candidate token, CryptoRandToken by default.
+	Generate func() (Token, error)
+
+	mu     sync.RWMutex
+	tokens map[string]struct{}
+}
+
+// NewTokenStore creates a token store, maxTokens <= 0 uses DefaultMaxTokens.
+func NewTokenStore(maxTokens int) *TokenStore {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	return &TokenStore{
+		MaxTokens:  maxTokens,
+		MaxRetries: DefaultTokenRetries,
+		Generate:   CryptoRandToken,
+		tokens:     make(map[string]struct{}),
+	}
+}
+
+// Acquire generates a token not used by any other in-flight request.
+func (s *TokenStore) Acquire() (Token, error) {
+	generate := s.Generate
+	if generate == nil {
+		generate = CryptoRandToken
+	}
+	retries := s.MaxRetries
+	if retries <= 0 {
+		retries = DefaultTokenRetries
+	}
+	for i := 0; i < retries; i++ {
+		t, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		ok, err := s.add(t)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return t, nil
+		}
+	}
+	return nil, ErrTokenCollision
+}
+
+func (s *TokenStore) add(t Token) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string]struct{})
+	}
+	limit := s.MaxTokens
+	if limit <= 0 {
+		limit = DefaultMaxTokens
+	}
+	if len(s.tokens) >= limit {
+		return false, ErrTokenStoreFull
+	}
+	if _, ok := s.tokens[string(t)]; ok {
+		return false, nil
+	}
+	s.tokens[string(t)] = struct{}{}
+	return true, nil
+}
+
+// Release removes the token from the store, so it can be used again.
+func (s *TokenStore) Release(t Token) {
+	s.mu.Lock()
+	delete(s.tokens, string(t))
+	s.mu.Unlock()
+}
+
+// Len returns the number of acquired tokens.
+func (s *TokenStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tokens)
+}
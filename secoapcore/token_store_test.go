@@ -0,0 +1,74 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secoapcore
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStoreCollision(t *testing.T) {
+	s := NewTokenStore(2)
+	s.Generate = func() (Token, error) { return Token{0x01}, nil }
+
+	tok, err := s.Acquire()
+	require.NoError(t, err)
+	_, err = s.Acquire()
+	require.ErrorIs(t, err, ErrTokenCollision)
+
+	s.Release(tok)
+	require.Equal(t, 0, s.Len())
+	_, err = s.Acquire()
+	require.NoError(t, err)
+}
+
+func TestTokenStoreFull(t *testing.T) {
+	s := NewTokenStore(2)
+	for i := 0; i < 2; i++ {
+		_, err := s.Acquire()
+		require.NoError(t, err)
+	}
+	_, err := s.Acquire()
+	require.ErrorIs(t, err, ErrTokenStoreFull)
+	require.Equal(t, DefaultMaxTokens, NewTokenStore(0).MaxTokens)
+}
+
+func TestTokenStoreConcurrent(t *testing.T) {
+	s := NewTokenStore(0)
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tok, err := s.Acquire()
+				if err != nil {
+					errs <- err
+					return
+				}
+				s.Release(tok)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, 0, s.Len())
+}
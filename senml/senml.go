@@ -0,0 +1,140 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package senml 编码和解码 RFC 8428 定义的 SenML JSON 格式 (application/senml+json)
+package senml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidRecord 记录不符合 RFC 8428 的要求
+var ErrInvalidRecord = errors.New("invalid senml record")
+
+// relativeTimeLimit 小于该值的时间为相对于当前时间的秒数, RFC 8428 §4.5.3
+const relativeTimeLimit = 1 << 28
+
+// Record SenML 记录, 字段与 RFC 8428 §4 一致
+type Record struct {
+	BaseName    string  `json:"bn,omitempty"`
+	BaseTime    float64 `json:"bt,omitempty"`
+	BaseUnit    string  `json:"bu,omitempty"`
+	BaseValue   float64 `json:"bv,omitempty"`
+	BaseSum     float64 `json:"bs,omitempty"`
+	BaseVersion int     `json:"bver,omitempty"`
+
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue *string  `json:"vs,omitempty"`
+	BoolValue   *bool    `json:"vb,omitempty"`
+	DataValue   *string  `json:"vd,omitempty"`
+	Sum         *float64 `json:"s,omitempty"`
+	Time        float64  `json:"t,omitempty"`
+	UpdateTime  float64  `json:"ut,omitempty"`
+}
+
+// EncodeJSON 将记录编码为 SenML JSON
+func EncodeJSON(records []Record) ([]byte, error) {
+	if records == nil {
+		records = []Record{}
+	}
+	return json.Marshal(records)
+}
+
+// DecodeJSON 解码 SenML JSON, 不做基础字段的解析, 见 Resolve
+func DecodeJSON(data []byte) ([]Record, error) {
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Resolve 按 RFC 8428 §4.6 将基础字段合并到每条记录中, 返回的记录不含基础字段,
+// 名称为完整名称, 时间为绝对时间 (相对时间以 now 为基准)
+func Resolve(records []Record, now time.Time) ([]Record, error) {
+	var base Record
+	nowSec := float64(now.UnixNano()) / float64(time.Second)
+	resolved := make([]Record, 0, len(records))
+	for i, r := range records {
+		if r.BaseName != "" {
+			base.BaseName = r.BaseName
+		}
+		if r.BaseTime != 0 {
+			base.BaseTime = r.BaseTime
+		}
+		if r.BaseUnit != "" {
+			base.BaseUnit = r.BaseUnit
+		}
+		if r.BaseValue != 0 {
+			base.BaseValue = r.BaseValue
+		}
+		if r.BaseSum != 0 {
+			base.BaseSum = r.BaseSum
+		}
+
+		out := Record{
+			Name:        base.BaseName + r.Name,
+			Unit:        r.Unit,
+			StringValue: r.StringValue,
+			BoolValue:   r.BoolValue,
+			DataValue:   r.DataValue,
+			Time:        base.BaseTime + r.Time,
+			UpdateTime:  r.UpdateTime,
+		}
+		if out.Unit == "" {
+			out.Unit = base.BaseUnit
+		}
+		if r.Value != nil {
+			v := base.BaseValue + *r.Value
+			out.Value = &v
+		}
+		if r.Sum != nil {
+			s := base.BaseSum + *r.Sum
+			out.Sum = &s
+		}
+		if out.Time < relativeTimeLimit {
+			out.Time += nowSec
+		}
+		if !validName(out.Name) {
+			return nil, fmt.Errorf("%w: record %d has invalid name %q", ErrInvalidRecord, i, out.Name)
+		}
+		if out.Value == nil && out.StringValue == nil && out.BoolValue == nil && out.DataValue == nil && out.Sum == nil {
+			return nil, fmt.Errorf("%w: record %d has no value", ErrInvalidRecord, i)
+		}
+		resolved = append(resolved, out)
+	}
+	return resolved, nil
+}
+
+// validName 名称只能包含 A-Z a-z 0-9 - : . / _, 且以字母或数字开头
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case i > 0 && (c == '-' || c == ':' || c == '.' || c == '/' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package senml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBaseFields(t *testing.T) {
+	// RFC 8428 §5.1.3
+	data := `[
+		{"bn":"urn:dev:ow:10e2073a0108006:","bt":1.276020076001e+09,"bu":"A","bver":5,"n":"voltage","u":"V","v":120.1},
+		{"n":"current","t":-5,"v":1.2},
+		{"n":"current","t":-4,"v":1.3}
+	]`
+	records, err := DecodeJSON([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "urn:dev:ow:10e2073a0108006:", records[0].BaseName)
+	require.Equal(t, 5, records[0].BaseVersion)
+
+	resolved, err := Resolve(records, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	require.Len(t, resolved, 3)
+	require.Equal(t, "urn:dev:ow:10e2073a0108006:voltage", resolved[0].Name)
+	require.Equal(t, "V", resolved[0].Unit)
+	require.InDelta(t, 120.1, *resolved[0].Value, 1e-9)
+	require.InDelta(t, 1.276020076001e+09, resolved[0].Time, 1e-3)
+	require.Equal(t, "urn:dev:ow:10e2073a0108006:current", resolved[1].Name)
+	require.Equal(t, "A", resolved[1].Unit)
+	require.InDelta(t, 1.276020071001e+09, resolved[1].Time, 1e-3)
+	require.InDelta(t, 1.276020072001e+09, resolved[2].Time, 1e-3)
+	require.Empty(t, resolved[1].BaseName)
+}
+
+func TestResolveRelativeTime(t *testing.T) {
+	v, s := 21.5, 10.0
+	records := []Record{
+		{BaseName: "dev1/", BaseValue: 20, BaseSum: 100, Name: "temp", Value: &v, Time: -10},
+		{Name: "energy", Sum: &s},
+	}
+	now := time.Unix(1700000000, 0)
+	resolved, err := Resolve(records, now)
+	require.NoError(t, err)
+	require.Equal(t, "dev1/temp", resolved[0].Name)
+	require.InDelta(t, 41.5, *resolved[0].Value, 1e-9)
+	require.InDelta(t, 1699999990, resolved[0].Time, 1e-3)
+	// 没有时间的记录使用当前时间
+	require.InDelta(t, 1700000000, resolved[1].Time, 1e-3)
+	require.InDelta(t, 110, *resolved[1].Sum, 1e-9)
+	// 原记录不被修改
+	require.Equal(t, 21.5, v)
+}
+
+func TestResolveInvalid(t *testing.T) {
+	v := 1.0
+	_, err := Resolve([]Record{{Value: &v}}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	_, err = Resolve([]Record{{Name: "-bad", Value: &v}}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	_, err = Resolve([]Record{{Name: "novalue"}}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidRecord)
+}
+
+func TestEncodeDecodeJSON(t *testing.T) {
+	v, b, str := 1.5, true, "on"
+	records := []Record{
+		{BaseName: "dev/", Name: "a", Unit: "Cel", Value: &v, Time: 1.5},
+		{Name: "b", BoolValue: &b},
+		{Name: "c", StringValue: &str, UpdateTime: 60},
+	}
+	data, err := EncodeJSON(records)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"bn":"dev/","n":"a","u":"Cel","v":1.5,"t":1.5},{"n":"b","vb":true},{"n":"c","vs":"on","ut":60}]`, string(data))
+	got, err := DecodeJSON(data)
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+
+	data, err = EncodeJSON(nil)
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(data))
+	_, err = DecodeJSON([]byte(`{"n":"a"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrDuplicateToken 已有相同令牌的请求在等待响应
+var ErrDuplicateToken = errors.New("session: token is already pending")
+
+type correlationEntry struct {
+	req        *message.Message
+	registered time.Time
+}
+
+// CorrelationStore 按令牌匹配响应与请求, 可并发使用
+type CorrelationStore struct {
+	// Timeout 请求等待响应的时间, 超时的请求由 ExpireAll 返回
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[uint64]correlationEntry
+	now     func() time.Time
+}
+
+func NewCorrelationStore(timeout time.Duration) *CorrelationStore {
+	return &CorrelationStore{
+		Timeout: timeout,
+		pending: make(map[uint64]correlationEntry),
+		now:     time.Now,
+	}
+}
+
+// Register 登记等待响应的请求, 令牌已在等待时返回 ErrDuplicateToken
+func (s *CorrelationStore) Register(req *message.Message) error {
+	if req == nil {
+		return secoapcore.ErrMessageNil
+	}
+	key := req.Token().Hash()
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[key]; ok {
+		return ErrDuplicateToken
+	}
+	s.pending[key] = correlationEntry{req: req, registered: now}
+	return nil
+}
+
+// Match 返回与响应令牌相同的请求, 并将其移除
+func (s *CorrelationStore) Match(resp *message.Message) (*message.Message, bool) {
+	if resp == nil {
+		return nil, false
+	}
+	key := resp.Token().Hash()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.pending[key]
+	if !ok {
+		return nil, false
+	}
+	delete(s.pending, key)
+	return e.req, true
+}
+
+// Pending 返回等待响应的请求数量
+func (s *CorrelationStore) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// ExpireAll 移除并返回登记时间超过 Timeout 的请求, 按登记时间排序
+func (s *CorrelationStore) ExpireAll(now time.Time) []*message.Message {
+	s.mu.Lock()
+	var expired []correlationEntry
+	for key, e := range s.pending {
+		if !now.Before(e.registered.Add(s.Timeout)) {
+			expired = append(expired, e)
+			delete(s.pending, key)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].registered.Before(expired[j].registered)
+	})
+	reqs := make([]*message.Message, len(expired))
+	for i, e := range expired {
+		reqs[i] = e.req
+	}
+	return reqs
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenMessage(token secoapcore.Token) *message.Message {
+	msg := message.NewMessage(context.Background())
+	msg.SetToken(token)
+	return msg
+}
+
+func TestCorrelationStore(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	now := base
+	s := NewCorrelationStore(5 * time.Second)
+	s.now = func() time.Time { return now }
+
+	req1 := newTokenMessage(secoapcore.Token{0x01})
+	require.NoError(t, s.Register(req1))
+	require.ErrorIs(t, s.Register(newTokenMessage(secoapcore.Token{0x01})), ErrDuplicateToken)
+	now = base.Add(2 * time.Second)
+	req2 := newTokenMessage(secoapcore.Token{0x02})
+	require.NoError(t, s.Register(req2))
+	now = base.Add(3 * time.Second)
+	req3 := newTokenMessage(secoapcore.Token{0x03})
+	require.NoError(t, s.Register(req3))
+	require.Equal(t, 3, s.Pending())
+
+	got, ok := s.Match(newTokenMessage(secoapcore.Token{0x02}))
+	require.True(t, ok)
+	require.Same(t, req2, got)
+	_, ok = s.Match(newTokenMessage(secoapcore.Token{0x02}))
+	require.False(t, ok)
+
+	require.Empty(t, s.ExpireAll(base.Add(4*time.Second)))
+	require.Equal(t, []*message.Message{req1}, s.ExpireAll(base.Add(5*time.Second)))
+	require.Equal(t, []*message.Message{req3}, s.ExpireAll(base.Add(time.Minute)))
+	require.Zero(t, s.Pending())
+	require.ErrorIs(t, s.Register(nil), secoapcore.ErrMessageNil)
+}
+
+func TestCorrelationStoreConcurrent(t *testing.T) {
+	s := NewCorrelationStore(time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				token := make(secoapcore.Token, 4)
+				binary.BigEndian.PutUint32(token, uint32(g*100+i))
+				req := newTokenMessage(token)
+				if err := s.Register(req); err != nil {
+					t.Error(err)
+					return
+				}
+				got, ok := s.Match(newTokenMessage(token))
+				if !ok || got != req {
+					t.Errorf("request %d not matched", g*100+i)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.Zero(t, s.Pending())
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+)
+
+// DefaultDedupWindowSize DedupStore 默认记录的消息数量
+const DefaultDedupWindowSize = 256
+
+type dedupKey struct {
+	mid   int32
+	token uint64
+}
+
+type dedupEntry struct {
+	key  dedupKey
+	seen time.Time
+}
+
+// DedupStore 按 (消息ID, 令牌哈希) 检测重复的消息, 只保存最近的 WindowSize 条记录, 可并发使用
+type DedupStore struct {
+	// WindowSize 记录的消息数量, <= 0 时使用 DefaultDedupWindowSize
+	WindowSize int
+
+	mu      sync.Mutex
+	lru     *list.List // 最近出现的在前
+	entries map[dedupKey]*list.Element
+	now     func() time.Time
+}
+
+func NewDedupStore(windowSize int) *DedupStore {
+	return &DedupStore{
+		WindowSize: windowSize,
+		lru:        list.New(),
+		entries:    make(map[dedupKey]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Seen 返回消息是否已记录过, 未记录时记录该消息
+func (s *DedupStore) Seen(msg *message.Message) bool {
+	key := dedupKey{mid: msg.MessageID(), token: msg.Token().Hash()}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*dedupEntry).seen = now
+		s.lru.MoveToFront(e)
+		return true
+	}
+	s.entries[key] = s.lru.PushFront(&dedupEntry{key: key, seen: now})
+	size := s.WindowSize
+	if size <= 0 {
+		size = DefaultDedupWindowSize
+	}
+	for s.lru.Len() > size {
+		s.remove(s.lru.Back())
+	}
+	return false
+}
+
+// Evict 移除最近一次出现早于 older 的记录
+func (s *DedupStore) Evict(older time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for e := s.lru.Back(); e != nil && e.Value.(*dedupEntry).seen.Before(older); e = s.lru.Back() {
+		s.remove(e)
+	}
+}
+
+// Len 返回记录的消息数量
+func (s *DedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+func (s *DedupStore) remove(e *list.Element) {
+	s.lru.Remove(e)
+	delete(s.entries, e.Value.(*dedupEntry).key)
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func newDedupMessage(mid int32, token secoapcore.Token) *message.Message {
+	msg := newTokenMessage(token)
+	msg.SetMessageID(mid)
+	return msg
+}
+
+func TestDedupStore(t *testing.T) {
+	s := NewDedupStore(0)
+	require.False(t, s.Seen(newDedupMessage(0x10, secoapcore.Token{0x01, 0x02})))
+	require.True(t, s.Seen(newDedupMessage(0x10, secoapcore.Token{0x01, 0x02})))
+	// 相同消息ID, 不同令牌
+	require.False(t, s.Seen(newDedupMessage(0x10, secoapcore.Token{0x01, 0x03})))
+	// 相同令牌, 不同消息ID
+	require.False(t, s.Seen(newDedupMessage(0x11, secoapcore.Token{0x01, 0x02})))
+	require.Equal(t, 3, s.Len())
+}
+
+func TestDedupStoreWindow(t *testing.T) {
+	s := NewDedupStore(2)
+	require.False(t, s.Seen(newDedupMessage(1, nil)))
+	require.False(t, s.Seen(newDedupMessage(2, nil)))
+	// 访问 1 后 2 成为最久未出现的记录
+	require.True(t, s.Seen(newDedupMessage(1, nil)))
+	require.False(t, s.Seen(newDedupMessage(3, nil)))
+	require.Equal(t, 2, s.Len())
+	require.True(t, s.Seen(newDedupMessage(1, nil)))
+	require.False(t, s.Seen(newDedupMessage(2, nil)))
+}
+
+func TestDedupStoreEvict(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	now := base
+	s := NewDedupStore(10)
+	s.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		now = base.Add(time.Duration(i) * time.Second)
+		s.Seen(newDedupMessage(int32(i), secoapcore.Token{byte(i)}))
+	}
+	s.Evict(base.Add(3 * time.Second))
+	require.Equal(t, 2, s.Len())
+	require.False(t, s.Seen(newDedupMessage(0, secoapcore.Token{0x00})))
+	require.True(t, s.Seen(newDedupMessage(3, secoapcore.Token{0x03})))
+}
+
+func TestDedupStoreConcurrent(t *testing.T) {
+	s := NewDedupStore(1000)
+	var mu sync.Mutex
+	fresh := 0
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if !s.Seen(newDedupMessage(int32(i), secoapcore.Token{0xaa})) {
+					mu.Lock()
+					fresh++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 100, fresh)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session 维护与通信对端相关的会话状态
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+)
+
+// ErrOutOfOrder 收到的 PackageNumber 不是期望的下一个值
+var ErrOutOfOrder = errors.New("session: package number out of order")
+
+type packageKey struct {
+	path  string
+	token string
+}
+
+type packageCounter struct {
+	mu  sync.Mutex
+	val uint16
+}
+
+// PackageNumberManager 按 (path, token) 维护私有 PackageNumber 选项的序号
+//
+// 每个 (path, token) 的序号从 0 开始, Next 返回的第一个值为 1, 65535 之后回绕为 0.
+type PackageNumberManager struct {
+	counters sync.Map // packageKey -> *packageCounter
+}
+
+func NewPackageNumberManager() *PackageNumberManager {
+	return &PackageNumberManager{}
+}
+
+func (m *PackageNumberManager) counter(path string, token secoapcore.Token) *packageCounter {
+	key := packageKey{path: path, token: string(token)}
+	if c, ok := m.counters.Load(key); ok {
+		return c.(*packageCounter)
+	}
+	c, _ := m.counters.LoadOrStore(key, &packageCounter{})
+	return c.(*packageCounter)
+}
+
+// Next 递增并返回 (path, token) 的下一个序号
+func (m *PackageNumberManager) Next(path string, token secoapcore.Token) uint16 {
+	c := m.counter(path, token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.val++
+	return c.val
+}
+
+// Reset 将 (path, token) 的序号恢复为 0
+func (m *PackageNumberManager) Reset(path string, token secoapcore.Token) {
+	c := m.counter(path, token)
+	c.mu.Lock()
+	c.val = 0
+	c.mu.Unlock()
+}
+
+// AttachTo 取下一个序号并设置到消息的 PackageNumber 选项
+func (m *PackageNumberManager) AttachTo(msg *message.Message, path string, token secoapcore.Token) {
+	msg.SetOptionUint32(secoapcore.PackageNumber, uint32(m.Next(path, token)))
+}
+
+// VerifySequential 校验消息的 PackageNumber 是否为期望的下一个序号, 校验通过后序号前进
+func (m *PackageNumberManager) VerifySequential(msg *message.Message, path string, token secoapcore.Token) error {
+	v, err := msg.GetOptionUint32(secoapcore.PackageNumber)
+	if err != nil {
+		return err
+	}
+	c := m.counter(path, token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := c.val + 1
+	if v != uint32(want) {
+		return fmt.Errorf("%w: got %d, want %d", ErrOutOfOrder, v, want)
+	}
+	c.val = want
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/GiterLab/go-secoap/secoapcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageNumberManager(t *testing.T) {
+	m := NewPackageNumberManager()
+	token := secoapcore.Token{0x01, 0x02}
+	require.Equal(t, uint16(1), m.Next("/a", token))
+	require.Equal(t, uint16(2), m.Next("/a", token))
+	require.Equal(t, uint16(1), m.Next("/b", token))
+	require.Equal(t, uint16(1), m.Next("/a", secoapcore.Token{0x03}))
+
+	m.Reset("/a", token)
+	require.Equal(t, uint16(1), m.Next("/a", token))
+
+	for i := 1; i < 65535; i++ {
+		m.Next("/wrap", nil)
+	}
+	require.Equal(t, uint16(65535), m.Next("/wrap", nil))
+	require.Equal(t, uint16(0), m.Next("/wrap", nil))
+}
+
+func TestPackageNumberVerifySequential(t *testing.T) {
+	sender := NewPackageNumberManager()
+	receiver := NewPackageNumberManager()
+	token := secoapcore.Token{0x01}
+	msg := message.NewMessage(context.Background())
+
+	_, err := msg.GetOptionUint32(secoapcore.PackageNumber)
+	require.ErrorIs(t, receiver.VerifySequential(msg, "/up", token), err)
+
+	for i := 1; i <= 3; i++ {
+		sender.AttachTo(msg, "/up", token)
+		v, err := msg.GetOptionUint32(secoapcore.PackageNumber)
+		require.NoError(t, err)
+		require.Equal(t, uint32(i), v)
+		require.NoError(t, receiver.VerifySequential(msg, "/up", token))
+	}
+
+	// a replayed message is rejected and does not advance the counter
+	require.ErrorIs(t, receiver.VerifySequential(msg, "/up", token), ErrOutOfOrder)
+	sender.Next("/up", token) // lost message
+	sender.AttachTo(msg, "/up", token)
+	require.ErrorIs(t, receiver.VerifySequential(msg, "/up", token), ErrOutOfOrder)
+}
+
+func TestPackageNumberConcurrent(t *testing.T) {
+	m := NewPackageNumberManager()
+	var wg sync.WaitGroup
+	seen := make([]int32, 1<<16)
+	var mu sync.Mutex
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				n := m.Next("/c", nil)
+				mu.Lock()
+				seen[n]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for i := 1; i <= 10000; i++ {
+		require.Equal(t, int32(1), seen[i], i)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+)
+
+type pendingEntry struct {
+	msg     *message.Message
+	expires time.Time
+	index   int
+}
+
+// pendingHeap 按过期时间排序的最小堆
+type pendingHeap []*pendingEntry
+
+func (h pendingHeap) Len() int           { return len(h) }
+func (h pendingHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+func (h pendingHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *pendingHeap) Push(x interface{}) {
+	e := x.(*pendingEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *pendingHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// PendingMessageStore 保存等待响应的消息, 消息在 CreatedAt + TTL 时过期
+type PendingMessageStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	heap    pendingHeap
+	entries map[*message.Message]*pendingEntry
+}
+
+// NewPendingMessageStore 创建存储, ttl 为消息的有效期, 0 表示添加后立即过期
+func NewPendingMessageStore(ttl time.Duration) *PendingMessageStore {
+	return &PendingMessageStore{
+		ttl:     ttl,
+		entries: make(map[*message.Message]*pendingEntry),
+	}
+}
+
+// Add 添加消息, 已存在的消息不会重复添加
+func (s *PendingMessageStore) Add(msg *message.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[msg]; ok {
+		return
+	}
+	e := &pendingEntry{msg: msg, expires: msg.CreatedAt.Add(s.ttl)}
+	heap.Push(&s.heap, e)
+	s.entries[msg] = e
+}
+
+// Remove 移除消息, 例如收到响应之后, 返回消息是否存在
+func (s *PendingMessageStore) Remove(msg *message.Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[msg]
+	if !ok {
+		return false
+	}
+	heap.Remove(&s.heap, e.index)
+	delete(s.entries, msg)
+	return true
+}
+
+// Expired 返回过期时间不晚于 now 的消息, 按过期时间排序, 消息不会被移除
+func (s *PendingMessageStore) Expired(now time.Time) []*message.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []*pendingEntry
+	// 堆中子节点不早于父节点过期, 未过期的节点无需继续向下查找
+	var walk func(i int)
+	walk = func(i int) {
+		if i >= len(s.heap) || s.heap[i].expires.After(now) {
+			return
+		}
+		expired = append(expired, s.heap[i])
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	walk(0)
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].expires.Before(expired[j].expires)
+	})
+	msgs := make([]*message.Message, len(expired))
+	for i, e := range expired {
+		msgs[i] = e.msg
+	}
+	return msgs
+}
+
+// Purge 移除所有过期时间不晚于 now 的消息, 返回移除的数量
+func (s *PendingMessageStore) Purge(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for len(s.heap) > 0 && !s.heap[0].expires.After(now) {
+		e := heap.Pop(&s.heap).(*pendingEntry)
+		delete(s.entries, e.msg)
+		n++
+	}
+	return n
+}
+
+// Len 返回存储的消息数量
+func (s *PendingMessageStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.heap)
+}
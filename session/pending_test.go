@@ -0,0 +1,65 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GiterLab/go-secoap/message"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessageAt(t time.Time) *message.Message {
+	msg := message.NewMessage(context.Background())
+	msg.CreatedAt = t
+	return msg
+}
+
+func TestPendingMessageStoreZeroTTL(t *testing.T) {
+	s := NewPendingMessageStore(0)
+	msg := message.NewMessage(context.Background())
+	s.Add(msg)
+	require.Equal(t, []*message.Message{msg}, s.Expired(msg.CreatedAt))
+	require.Equal(t, 1, s.Purge(msg.CreatedAt))
+	require.Zero(t, s.Len())
+}
+
+func TestPendingMessageStore(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	s := NewPendingMessageStore(10 * time.Second)
+	msgs := make([]*message.Message, 6)
+	// added out of order
+	for i, offset := range []int{5, 0, 3, 1, 4, 2} {
+		msgs[i] = newMessageAt(base.Add(time.Duration(offset) * time.Second))
+		s.Add(msgs[i])
+	}
+	s.Add(msgs[0])
+	require.Equal(t, 6, s.Len())
+
+	require.Empty(t, s.Expired(base.Add(9*time.Second)))
+	expired := s.Expired(base.Add(12 * time.Second))
+	require.Equal(t, []*message.Message{msgs[1], msgs[3], msgs[5]}, expired)
+	require.Equal(t, 6, s.Len())
+
+	require.True(t, s.Remove(msgs[2]))
+	require.False(t, s.Remove(msgs[2]))
+	require.Equal(t, 3, s.Purge(base.Add(12*time.Second)))
+	require.Equal(t, 2, s.Len())
+	require.Equal(t, []*message.Message{msgs[4], msgs[0]}, s.Expired(base.Add(time.Minute)))
+	require.Equal(t, 2, s.Purge(base.Add(time.Minute)))
+	require.Zero(t, s.Len())
+}
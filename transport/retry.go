@@ -0,0 +1,145 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport 提供可靠传输相关的辅助功能, 如可确认消息的重传
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrMaxRetriesExceeded 发送次数达到上限仍未收到确认
+var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+
+// RFC 7252 §4.8 传输参数的默认值
+const (
+	DefaultAckTimeout      = 2 * time.Second
+	DefaultAckRandomFactor = 1.5
+	DefaultMaxRetransmit   = 4
+)
+
+// RetryPolicy 重传策略
+type RetryPolicy interface {
+	// Attempts 返回最大发送次数, 包括首次发送
+	Attempts() int
+	// NextBackoff 返回第 attempt 次发送 (从0开始) 后等待确认的时长
+	NextBackoff(attempt int) time.Duration
+}
+
+// RFC7252RetryPolicy 按 RFC 7252 §4.2 计算重传超时:
+// 首次超时为 [AckTimeout, AckTimeout*AckRandomFactor] 之间的随机值, 之后每次加倍
+//
+// 字段为零值时使用 RFC 7252 的默认值
+type RFC7252RetryPolicy struct {
+	AckTimeout      time.Duration
+	AckRandomFactor float64
+	MaxRetransmit   int
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// Attempts 返回 1 + MaxRetransmit
+func (p *RFC7252RetryPolicy) Attempts() int {
+	if p.MaxRetransmit <= 0 {
+		return 1 + DefaultMaxRetransmit
+	}
+	return 1 + p.MaxRetransmit
+}
+
+func (p *RFC7252RetryPolicy) NextBackoff(attempt int) time.Duration {
+	timeout := p.AckTimeout
+	if timeout <= 0 {
+		timeout = DefaultAckTimeout
+	}
+	factor := p.AckRandomFactor
+	if factor < 1 {
+		factor = DefaultAckRandomFactor
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+	initial := float64(timeout) * (1 + (factor-1)*p.float64())
+	return time.Duration(initial) << uint(attempt)
+}
+
+func (p *RFC7252RetryPolicy) float64() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rand == nil {
+		p.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return p.rand.Float64()
+}
+
+type constantRetryPolicy struct {
+	backoff time.Duration
+	max     int
+}
+
+// ConstantRetryPolicy 返回固定等待时长 d 的重传策略, max 为最大发送次数
+func ConstantRetryPolicy(d time.Duration, max int) RetryPolicy {
+	return &constantRetryPolicy{backoff: d, max: max}
+}
+
+func (p *constantRetryPolicy) Attempts() int {
+	return p.max
+}
+
+func (p *constantRetryPolicy) NextBackoff(int) time.Duration {
+	return p.backoff
+}
+
+// Retrier 按重传策略发送可确认消息, 直到收到确认
+type Retrier struct {
+	Policy RetryPolicy
+}
+
+// NewRetrier 创建重传器, policy 为 nil 时使用 RFC7252RetryPolicy
+func NewRetrier(policy RetryPolicy) *Retrier {
+	if policy == nil {
+		policy = &RFC7252RetryPolicy{}
+	}
+	return &Retrier{Policy: policy}
+}
+
+// Do 调用 send 发送消息, 并等待 ack 最多 NextBackoff(n), 超时后重新发送,
+// 发送 Attempts() 次后仍未收到确认时返回 ErrMaxRetriesExceeded
+//
+// send 返回错误或 ctx 结束时立即返回
+func (r *Retrier) Do(ctx context.Context, send func() error, ack chan struct{}) error {
+	for n := 0; n < r.Policy.Attempts(); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := send(); err != nil {
+			return err
+		}
+		timer := time.NewTimer(r.Policy.NextBackoff(n))
+		select {
+		case <-ack:
+			timer.Stop()
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return ErrMaxRetriesExceeded
+}
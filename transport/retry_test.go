@@ -0,0 +1,104 @@
+// Copyright 2024 tobyzxj
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC7252RetryPolicy(t *testing.T) {
+	p := &RFC7252RetryPolicy{}
+	require.Equal(t, 5, p.Attempts())
+	for attempt := 0; attempt < p.Attempts(); attempt++ {
+		lo := DefaultAckTimeout << uint(attempt)
+		hi := time.Duration(float64(DefaultAckTimeout)*DefaultAckRandomFactor) << uint(attempt)
+		for i := 0; i < 100; i++ {
+			d := p.NextBackoff(attempt)
+			require.GreaterOrEqual(t, d, lo)
+			require.LessOrEqual(t, d, hi)
+		}
+	}
+
+	p = &RFC7252RetryPolicy{AckTimeout: time.Second, AckRandomFactor: 1, MaxRetransmit: 2}
+	require.Equal(t, 3, p.Attempts())
+	require.Equal(t, 4*time.Second, p.NextBackoff(2))
+}
+
+func TestRetrierMaxRetries(t *testing.T) {
+	sent := 0
+	r := NewRetrier(ConstantRetryPolicy(time.Millisecond, 3))
+	err := r.Do(context.Background(), func() error {
+		sent++
+		return nil
+	}, make(chan struct{}))
+	require.ErrorIs(t, err, ErrMaxRetriesExceeded)
+	require.Equal(t, 3, sent)
+}
+
+func TestRetrierAck(t *testing.T) {
+	sent := 0
+	ack := make(chan struct{}, 1)
+	r := NewRetrier(ConstantRetryPolicy(10*time.Millisecond, 5))
+	err := r.Do(context.Background(), func() error {
+		sent++
+		if sent == 2 {
+			ack <- struct{}{}
+		}
+		return nil
+	}, ack)
+	require.NoError(t, err)
+	require.Equal(t, 2, sent)
+
+	errSend := errors.New("send failed")
+	err = r.Do(context.Background(), func() error { return errSend }, ack)
+	require.ErrorIs(t, err, errSend)
+}
+
+func TestRetrierContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRetrier(ConstantRetryPolicy(time.Hour, 5))
+	sent := 0
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- r.Do(ctx, func() error {
+			sent++
+			return nil
+		}, make(chan struct{}))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, 1, sent)
+	case <-time.After(time.Second):
+		t.Fatal("Do did not return after cancel")
+	}
+
+	// 已取消的 ctx 不再发送
+	err := r.Do(ctx, func() error {
+		sent++
+		return nil
+	}, make(chan struct{}))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, sent)
+}